@@ -63,6 +63,22 @@ type Context struct {
 	RetryData []byte
 	// SecretProvider exposes the support for getting and storing secrets
 	SecretProvider *security.SecretProvider
+	// ContentEncoding is the encoding, such as gzip, applied to the data by a previous function in the pipeline. It is
+	// used as the Content-Encoding header when exporting the data via HTTP.
+	ContentEncoding string
+	// ExportHeaders holds headers, such as a signature, added by a previous function in the pipeline that are sent
+	// along with the data when exporting the data via HTTP.
+	ExportHeaders map[string]string
+	// Values holds custom values, such as a device profile name, set by a previous function in the pipeline. They
+	// are used to resolve the placeholders, i.e. {profile}, in a dynamic topic when exporting the data.
+	Values map[string]string
+	// ExportTopics holds the topics, keys and subjects, keyed by their configured value, whose placeholders have been
+	// resolved by the export functions. They are stored along with the RetryData, so a retry exports to the same
+	// destination.
+	ExportTopics map[string]string
+	// ReceivedHeaders holds the headers received by the trigger along with the data, such as a signature added by the
+	// Sign function of another service. They are set by the HTTP, Kafka, AMQP, NATS and gRPC triggers.
+	ReceivedHeaders map[string]string
 }
 
 // Complete is optional and provides a way to return the specified data.
@@ -130,6 +146,40 @@ func (context *Context) PushToCoreData(deviceName string, readingName string, va
 	return newEdgeXEvent, nil
 }
 
+// PushEventToCoreData pushes the provided Event, which may contain multiple readings, to CoreData as a new event. The
+// IDs of the Event and its readings are cleared and the origin of the Event and its readings and the device of the
+// readings are set when missing. If validation is turned on in CoreServices then the device and reading names must
+// exist in the CoreMetadata and be properly registered in EdgeX.
+func (context *Context) PushEventToCoreData(event models.Event) (*models.Event, error) {
+	context.LoggingClient.Debug("Pushing Event to CoreData")
+	event.ID = ""
+	if event.Origin == 0 {
+		event.Origin = time.Now().UnixNano()
+	}
+
+	readings := make([]models.Reading, len(event.Readings))
+	for index, reading := range event.Readings {
+		reading.Id = ""
+		if reading.Device == "" {
+			reading.Device = event.Device
+		}
+		if reading.Origin == 0 {
+			reading.Origin = event.Origin
+		}
+		readings[index] = reading
+	}
+	event.Readings = readings
+
+	correlation := uuid.New().String()
+	ctx := syscontext.WithValue(syscontext.Background(), clients.CorrelationHeader, correlation)
+	result, err := context.EventClient.Add(ctx, &event)
+	if err != nil {
+		return nil, err
+	}
+	event.ID = result
+	return &event, nil
+}
+
 // GetSecrets retrieves secrets from a secret store.
 // path specifies the type or location of the secrets to retrieve.
 // keys specifies the secrets which to retrieve. If no keys are provided then all the keys associated with the
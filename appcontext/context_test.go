@@ -17,6 +17,7 @@
 package appcontext
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -136,6 +137,43 @@ func TestPushToCore(t *testing.T) {
 	assert.Equal(t, newEvent.Readings[0].Value, result.Readings[0].Value)
 }
 
+func TestPushEventToCore(t *testing.T) {
+	var received models.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("newId"))
+	}))
+
+	defer ts.Close()
+	eventClient = coredata.NewEventClient(localURL.New(ts.URL + clients.ApiEventRoute))
+	ctx := Context{
+		EventClient:   eventClient,
+		LoggingClient: lc,
+	}
+	event := models.Event{
+		ID:     "old-id",
+		Device: "device-name",
+		Readings: []models.Reading{
+			{Id: "old-reading-id", Name: "temperature", Value: "21.5"},
+			{Device: "other-device", Name: "humidity", Value: "40", Origin: 10},
+		},
+	}
+	result, err := ctx.PushEventToCoreData(event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "newId", result.ID)
+	assert.NotZero(t, result.Origin, "Origin should be set when missing")
+	assert.Empty(t, received.ID, "ID should be cleared before pushing")
+	require.Len(t, received.Readings, 2)
+	assert.Empty(t, received.Readings[0].Id, "Reading ID should be cleared before pushing")
+	assert.Equal(t, "device-name", received.Readings[0].Device)
+	assert.Equal(t, result.Origin, received.Readings[0].Origin)
+	assert.Equal(t, "other-device", received.Readings[1].Device)
+	assert.Equal(t, int64(10), received.Readings[1].Origin)
+	assert.Empty(t, event.Readings[0].Device, "Event passed in should not be modified")
+}
+
 func TestMarkAsPushedEventId(t *testing.T) {
 	testID := "eventId"
 
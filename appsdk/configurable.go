@@ -17,9 +17,13 @@
 package appsdk
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
@@ -42,6 +46,95 @@ const (
 	AutoReconnect    = "autoreconnect"
 	DeviceName       = "devicename"
 	ReadingName      = "readingname"
+	ReadingNames     = "readingnames"
+	Operator         = "operator"
+	Values           = "values"
+	WindowType       = "windowtype"
+	WindowSize       = "windowsize"
+	Aggregates       = "aggregates"
+	BatchThreshold   = "batchthreshold"
+	TimeInterval     = "timeinterval"
+	MaxBytes         = "maxbytes"
+	Rule             = "rule"
+	OutputFormat     = "outputformat"
+	CSVColumns       = "csvcolumns"
+	Template         = "template"
+	TemplateFile     = "templatefile"
+	Language         = "language"
+	Mappings         = "mappings"
+	KeepUnmapped     = "keepunmapped"
+	DevicePath       = "devicepath"
+	OriginPath       = "originpath"
+	Readings         = "readings"
+	Header           = "header"
+	Delimiter        = "delimiter"
+	Measurement      = "measurement"
+	Tags             = "tags"
+	DescriptorFile   = "descriptorfile"
+	MessageName      = "messagename"
+	Schema           = "schema"
+	SchemaFile       = "schemafile"
+	OutputEvent      = "outputevent"
+	SecretPath       = "secretpath"
+	SecretName       = "secretname"
+	AdditionalData   = "additionaldata"
+	CompressionLevel = "compressionlevel"
+	RawOutput        = "rawoutput"
+	Algorithm        = "algorithm"
+	Envelope         = "envelope"
+	HeaderName       = "headername"
+	Headers          = "headers"
+	AuthMode         = "authmode"
+	APIKeyHeader     = "apikeyheader"
+	CACert           = "cacert"
+	Timeout          = "timeout"
+	SuccessCodes     = "successcodes"
+	RetryCodes       = "retrycodes"
+	TokenURL         = "tokenurl"
+	Scopes           = "scopes"
+
+	MaxIdleConns        = "maxidleconns"
+	MaxIdleConnsPerHost = "maxidleconnsperhost"
+	IdleConnTimeout     = "idleconntimeout"
+	DisableKeepAlives   = "disablekeepalives"
+	DisableHTTP2        = "disablehttp2"
+	Proxy               = "proxy"
+	MaxResponseBytes    = "maxresponsebytes"
+
+	ProtocolVersion   = "protocolversion"
+	PersistentSession = "persistentsession"
+	SessionExpiry     = "sessionexpiry"
+	KeepAlive         = "keepalive"
+	ConnectTimeout    = "connecttimeout"
+	WillTopic         = "willtopic"
+	WillPayload       = "willpayload"
+	WillQos           = "willqos"
+	WillRetain        = "willretain"
+	ContentType       = "contenttype"
+	MessageExpiry     = "messageexpiry"
+	UserProperties    = "userproperties"
+	ConnectOnStart    = "connectonstart"
+
+	Brokers         = "brokers"
+	Topic           = "topic"
+	MessageKey      = "messagekey"
+	Acks            = "acks"
+	CompressionType = "compression"
+	ClientID        = "clientid"
+	SASLMechanism   = "saslmechanism"
+	UseTLS          = "usetls"
+	BatchTimeout    = "batchtimeout"
+
+	Exchange       = "exchange"
+	RoutingKey     = "routingkey"
+	Persistent     = "persistent"
+	ConfirmTimeout = "confirmtimeout"
+
+	Subject         = "subject"
+	JetStream       = "jetstream"
+	CredentialsFile = "credentialsfile"
+
+	Address = "address"
 )
 
 // AppFunctionsSDKConfigurable contains the helper functions that return the function pointers for building the configurable function pipeline.
@@ -50,6 +143,13 @@ type AppFunctionsSDKConfigurable struct {
 	Sdk *AppFunctionsSDK
 }
 
+// configurableBatch holds a batch created for the configurable pipeline along with the parameters it was created with,
+// so it can be reused when the pipeline is reloaded.
+type configurableBatch struct {
+	parameters string
+	batch      *transforms.BatchConfig
+}
+
 // FilterByDeviceName - Specify the devices of interest to  filter for data coming from certain sensors.
 // The Filter by Device transform looks at the Event in the message and looks at the devices of interest list,
 // provided by this function, and filters out those messages whose Event is for devices not on the
@@ -95,6 +195,118 @@ func (dynamic AppFunctionsSDKConfigurable) FilterByValueDescriptor(parameters ma
 	return transform.FilterByValueDescriptor
 }
 
+// FilterByReadingValue - Specify the condition a reading's value must meet to be kept. Supported operators are
+// >, >=, <, <=, ==, !=, between, outside and deadband. The between and outside operators take a lower and upper value
+// separated by a comma, all others take a single value. The deadband operator only keeps a reading when its value has
+// moved by more than the specified amount from the last value kept for the same device and reading.
+// The optional reading names limit which readings the condition is applied to.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) FilterByReadingValue(parameters map[string]string) appcontext.AppFunction {
+	operator, ok := parameters[Operator]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Operator)
+		return nil
+	}
+	values, ok := parameters[Values]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Values)
+		return nil
+	}
+	valuesCleaned := util.DeleteEmptyAndTrim(strings.FieldsFunc(values, util.SplitComma))
+	readingNamesCleaned := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[ReadingNames], util.SplitComma))
+
+	transform, err := transforms.NewValueFilter(readingNamesCleaned, operator, valuesCleaned)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid FilterByReadingValue parameters", "error", err)
+		return nil
+	}
+	dynamic.Sdk.LoggingClient.Debug("Reading Value Filter", Operator, transform.Operator, Values, strings.Join(valuesCleaned, ","),
+		ReadingNames, strings.Join(readingNamesCleaned, ","))
+
+	return transform.FilterByReadingValue
+}
+
+// AggregateByTime - Specify the window type (tumbling or sliding) and window duration (i.e. 1m) used to aggregate
+// numeric readings grouped by device and reading name. The optional aggregates limit which of min, max, mean, sum,
+// count, first, last and stddev are emitted. All are emitted if not specified.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) AggregateByTime(parameters map[string]string) appcontext.AppFunction {
+	windowType, ok := dynamic.parseWindowType(parameters)
+	if !ok {
+		return nil
+	}
+	windowSize, ok := parameters[WindowSize]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + WindowSize)
+		return nil
+	}
+
+	aggregates := util.DeleteEmptyAndTrim(strings.FieldsFunc(strings.ToLower(parameters[Aggregates]), util.SplitComma))
+
+	transform, err := transforms.NewAggregateByTime(windowType, strings.TrimSpace(windowSize), aggregates...)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid AggregateByTime parameters", "error", err)
+		return nil
+	}
+	dynamic.Sdk.LoggingClient.Debug("Aggregate By Time Parameters", WindowType, parameters[WindowType], WindowSize, windowSize,
+		Aggregates, strings.Join(transform.Aggregates, ","))
+
+	return transform.Aggregate
+}
+
+// AggregateByCount - Specify the window type (tumbling or sliding) and number of readings in a window used to
+// aggregate numeric readings grouped by device and reading name. The optional aggregates limit which of min, max, mean,
+// sum, count, first, last and stddev are emitted. All are emitted if not specified.
+// This function will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) AggregateByCount(parameters map[string]string) appcontext.AppFunction {
+	windowType, ok := dynamic.parseWindowType(parameters)
+	if !ok {
+		return nil
+	}
+	windowSizeVal, ok := parameters[WindowSize]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + WindowSize)
+		return nil
+	}
+	windowSize, err := strconv.Atoi(strings.TrimSpace(windowSizeVal))
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to an int for '%s' parameter", windowSizeVal, WindowSize), "error", err)
+		return nil
+	}
+
+	aggregates := util.DeleteEmptyAndTrim(strings.FieldsFunc(strings.ToLower(parameters[Aggregates]), util.SplitComma))
+
+	transform, err := transforms.NewAggregateByCount(windowType, windowSize, aggregates...)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid AggregateByCount parameters", "error", err)
+		return nil
+	}
+	dynamic.Sdk.LoggingClient.Debug("Aggregate By Count Parameters", WindowType, parameters[WindowType], WindowSize, windowSizeVal,
+		Aggregates, strings.Join(transform.Aggregates, ","))
+
+	return transform.Aggregate
+}
+
+func (dynamic AppFunctionsSDKConfigurable) parseWindowType(parameters map[string]string) (transforms.WindowType, bool) {
+	value, ok := parameters[WindowType]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + WindowType)
+		return transforms.TumblingWindow, false
+	}
+	windowType, err := transforms.ParseWindowType(value)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(err.Error())
+		return transforms.TumblingWindow, false
+	}
+	return windowType, true
+}
+
 // TransformToXML transforms an EdgeX event to XML.
 // It will return an error and stop the pipeline if a non-edgex
 // event is received or if no data is recieved.
@@ -113,6 +325,198 @@ func (dynamic AppFunctionsSDKConfigurable) TransformToJSON() appcontext.AppFunct
 	return transform.TransformToJSON
 }
 
+// TransformToCSV transforms an EdgeX event to CSV with a row for each reading. The optional csvcolumns parameter
+// selects the columns, header set to false omits the header row and delimiter sets the single character, or tab,
+// that separates the columns.
+// It will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) TransformToCSV(parameters map[string]string) appcontext.AppFunction {
+	columns := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[CSVColumns], util.SplitComma))
+
+	header := true
+	value, ok := parameters[Header]
+	if ok {
+		var err error
+		header, err = strconv.ParseBool(value)
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, Header), "error", err)
+			return nil
+		}
+	}
+
+	delimiter := ','
+	value, ok = parameters[Delimiter]
+	if ok {
+		switch {
+		case strings.EqualFold(value, "tab") || value == `\t`:
+			delimiter = '\t'
+		case utf8.RuneCountInString(value) == 1:
+			delimiter, _ = utf8.DecodeRuneInString(value)
+		default:
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("'%s' parameter must be a single character or tab", Delimiter))
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewCSVConversion(columns, header, delimiter)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid TransformToCSV parameters", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("TransformToCSV Parameters", CSVColumns, strings.Join(transform.CSVColumns, ","),
+		Header, strconv.FormatBool(header), Delimiter, string(delimiter))
+	return transform.TransformToCSV
+}
+
+// TransformToInfluxLineProtocol transforms an EdgeX event to InfluxDB line protocol with the readings as fields.
+// The optional measurement parameter replaces the device name as the measurement and the optional tags parameter
+// adds tags to the device tag as comma separated key=value pairs, i.e. profile=Thermostat,site=plant1.
+// It will return an error and stop the pipeline if a non-edgex
+// event is received or if no data is recieved.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) TransformToInfluxLineProtocol(parameters map[string]string) appcontext.AppFunction {
+	measurement := parameters[Measurement]
+
+	tags := make(map[string]string)
+	for _, tag := range util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[Tags], util.SplitComma)) {
+		keyValue := strings.SplitN(tag, "=", 2)
+		if len(keyValue) != 2 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid tag '%s', must be of the form key=value", tag))
+			return nil
+		}
+		tags[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+	}
+
+	transform, err := transforms.NewInfluxLineProtocolConversion(measurement, tags)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid TransformToInfluxLineProtocol parameters", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("TransformToInfluxLineProtocol Parameters", Measurement, measurement, Tags, parameters[Tags])
+	return transform.TransformToInfluxLineProtocol
+}
+
+// TransformWithTemplate renders a Go text/template against the data from the previous function, allowing the payload
+// to be reshaped without code. Specify either the template inline or a templatefile to read it from.
+// It will return an error and stop the pipeline if no data is received or the template fails to render.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) TransformWithTemplate(parameters map[string]string) appcontext.AppFunction {
+	text, hasTemplate := parameters[Template]
+	templateFile, hasTemplateFile := parameters[TemplateFile]
+	if hasTemplate == hasTemplateFile {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Exactly one of '%s' or '%s' must be specified", Template, TemplateFile))
+		return nil
+	}
+
+	var transform *transforms.Template
+	var err error
+	if hasTemplate {
+		transform, err = transforms.NewTemplate(text)
+	} else {
+		templateFile = strings.TrimSpace(templateFile)
+		transform, err = transforms.NewTemplateFromFile(templateFile)
+	}
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid TransformWithTemplate parameters", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("Transform With Template Parameters", Template, text, TemplateFile, templateFile)
+	return transform.TransformWithTemplate
+}
+
+// MapJSON builds a new JSON document from the JSON data received using the `outputField = expression` rules in the
+// mappings parameter, separated by semicolons since the expressions may contain commas. The expressions are JSONPath
+// unless the language parameter is set to jmespath. Set keepunmapped to true to keep the fields that are not mapped.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) MapJSON(parameters map[string]string) appcontext.AppFunction {
+	mappings, ok := parameters[Mappings]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Mappings)
+		return nil
+	}
+	rules := util.DeleteEmptyAndTrim(strings.Split(mappings, ";"))
+
+	language, ok := parameters[Language]
+	if !ok {
+		language = transforms.JSONPath
+	}
+
+	keepUnmapped := false
+	value, ok := parameters[KeepUnmapped]
+	if ok {
+		var err error
+		keepUnmapped, err = strconv.ParseBool(value)
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, KeepUnmapped), "error", err)
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewJSONMapping(language, rules, keepUnmapped)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid MapJSON parameters", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("MapJSON Parameters", Language, language, Mappings, mappings, KeepUnmapped, strconv.FormatBool(keepUnmapped))
+	return transform.MapJSON
+}
+
+// TransformToEvent converts JSON data, such as from a non-EdgeX source, into an EdgeX Event. The devicepath and the
+// optional originpath parameters are expressions for the device name and origin. The readings parameter holds the
+// readings as `name:valueType = expression`, separated by semicolons, where the value type is optional and defaults to
+// String. The expressions are JSONPath unless the language parameter is set to jmespath.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) TransformToEvent(parameters map[string]string) appcontext.AppFunction {
+	devicePath, ok := parameters[DevicePath]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + DevicePath)
+		return nil
+	}
+	readingsValue, ok := parameters[Readings]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Readings)
+		return nil
+	}
+
+	var readings []transforms.ReadingMapping
+	for _, rule := range util.DeleteEmptyAndTrim(strings.Split(readingsValue, ";")) {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid reading '%s', must be of the form 'name:valueType = expression'", rule))
+			return nil
+		}
+		nameAndType := strings.SplitN(parts[0], ":", 2)
+		reading := transforms.ReadingMapping{
+			Name:       nameAndType[0],
+			Expression: parts[1],
+		}
+		if len(nameAndType) == 2 {
+			reading.ValueType = nameAndType[1]
+		}
+		readings = append(readings, reading)
+	}
+
+	language, ok := parameters[Language]
+	if !ok {
+		language = transforms.JSONPath
+	}
+	originPath := parameters[OriginPath]
+
+	transform, err := transforms.NewEventMapping(language, devicePath, originPath, readings)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Invalid TransformToEvent parameters", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("TransformToEvent Parameters", Language, language, DevicePath, devicePath, OriginPath, originPath, Readings, readingsValue)
+	return transform.TransformToEvent
+}
+
 // MarkAsPushed will make a request to CoreData to mark the event that triggered the pipeline as pushed.
 // This function is a configuration function and returns a function pointer.
 func (dynamic AppFunctionsSDKConfigurable) MarkAsPushed() appcontext.AppFunction {
@@ -144,141 +548,678 @@ func (dynamic AppFunctionsSDKConfigurable) PushToCore(parameters map[string]stri
 	return transform.PushToCoreData
 }
 
+// PushEventToCore pushes the EdgeX Event received from the previous function, i.e. from TransformToEvent, to CoreData
+// as a new event with all of its readings.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) PushEventToCore() appcontext.AppFunction {
+	transform := transforms.CoreData{}
+	return transform.PushEventToCoreData
+}
+
 // CompressWithGZIP compresses data received as either a string,[]byte, or json.Marshaler using gzip algorithm and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) CompressWithGZIP() appcontext.AppFunction {
-	transform := transforms.Compression{}
+func (dynamic AppFunctionsSDKConfigurable) CompressWithGZIP(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithGZIP", parameters)
+	if transform == nil {
+		return nil
+	}
 	return transform.CompressWithGZIP
 }
 
 // CompressWithZLIB compresses data received as either a string,[]byte, or json.Marshaler using zlib algorithm and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) CompressWithZLIB() appcontext.AppFunction {
-	transform := transforms.Compression{}
+func (dynamic AppFunctionsSDKConfigurable) CompressWithZLIB(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithZLIB", parameters)
+	if transform == nil {
+		return nil
+	}
 	return transform.CompressWithZLIB
 }
 
-// EncryptWithAES encrypts either a string, []byte, or json.Marshaller type using AES encryption.
-// It will return a byte[] of the encrypted data.
+// CompressWithZSTD compresses data received as either a string,[]byte, or json.Marshaler using the Zstandard algorithm and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) EncryptWithAES(parameters map[string]string) appcontext.AppFunction {
-	key, ok := parameters[Key]
-	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + Key)
+func (dynamic AppFunctionsSDKConfigurable) CompressWithZSTD(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithZSTD", parameters)
+	if transform == nil {
 		return nil
 	}
-	initVector, ok := parameters[InitVector]
-	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + InitVector)
+	return transform.CompressWithZSTD
+}
+
+// CompressWithSNAPPY compresses data received as either a string,[]byte, or json.Marshaler using the Snappy block format and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) CompressWithSNAPPY(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithSNAPPY", parameters)
+	if transform == nil {
 		return nil
 	}
-	transforms := transforms.Encryption{
-		Key:                  key,
-		InitializationVector: initVector,
-	}
-	return transforms.EncryptWithAES
+	return transform.CompressWithSNAPPY
 }
 
-// HTTPPost will send data from the previous function to the specified Endpoint via http POST. If no previous function exists,
-// then the event that triggered the pipeline will be used. Passing an empty string to the mimetype
-// method will default to application/json.
+// CompressWithLZ4 compresses data received as either a string,[]byte, or json.Marshaler using the LZ4 frame format and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) HTTPPost(parameters map[string]string) appcontext.AppFunction {
-	var err error
-
-	url, ok := parameters[Url]
-	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
+func (dynamic AppFunctionsSDKConfigurable) CompressWithLZ4(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithLZ4", parameters)
+	if transform == nil {
 		return nil
 	}
-	mimeType, ok := parameters[MimeType]
-	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + MimeType)
+	return transform.CompressWithLZ4
+}
+
+// CompressWithBrotli compresses data received as either a string,[]byte, or json.Marshaler using the Brotli algorithm and returns a base64 encoded string as a []byte.
+// The optional compressionlevel sets the level of the algorithm and rawoutput returns the compressed []byte as is, setting the Content-Encoding of HTTPPost.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) CompressWithBrotli(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.compression("CompressWithBrotli", parameters)
+	if transform == nil {
 		return nil
 	}
+	return transform.CompressWithBrotli
+}
 
-	// PersistOnError is optional and is false by default.
-	persistOnError := false
-	value, ok := parameters[PersistOnError]
+func (dynamic AppFunctionsSDKConfigurable) compression(functionName string, parameters map[string]string) *transforms.Compression {
+	level := transforms.DefaultCompressionLevel
+	value, ok := parameters[CompressionLevel]
+	if ok && strings.TrimSpace(value) != "" {
+		var err error
+		level, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to an int for '%s' parameter", value, CompressionLevel), "error", err)
+			return nil
+		}
+	}
+
+	rawOutput := false
+	value, ok = parameters[RawOutput]
 	if ok {
-		persistOnError, err = strconv.ParseBool(value)
+		var err error
+		rawOutput, err = strconv.ParseBool(value)
 		if err != nil {
-			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, PersistOnError), "error", err)
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, RawOutput), "error", err)
 			return nil
 		}
 	}
 
-	url = strings.TrimSpace(url)
-	mimeType = strings.TrimSpace(mimeType)
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters: %s=%d, %s=%t", functionName, CompressionLevel, level, RawOutput, rawOutput))
+	transform := transforms.NewCompressionWithOptions(level, rawOutput)
+	return &transform
+}
 
-	transform := transforms.HTTPSender{
-		URL:            url,
-		MimeType:       mimeType,
-		PersistOnError: persistOnError,
+// DecompressWithGZIP decompresses gzip compressed data received as either a string or []byte, raw or base64 encoded,
+// and returns the decompressed data as a []byte. The optional maxbytes sets the maximum size of the decompressed data.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) DecompressWithGZIP(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.decompression("DecompressWithGZIP", parameters)
+	if transform == nil {
+		return nil
 	}
-	dynamic.Sdk.LoggingClient.Debug("HTTP Post Parameters", Url, transform.URL, MimeType, transform.MimeType)
-	return transform.HTTPPost
+	return transform.DecompressWithGZIP
 }
 
-// HTTPPostJSON sends data from the previous function to the specified Endpoint via http POST with a mime type of application/json.
-// If no previous function exists, then the event that triggered the pipeline will be used.
+// DecompressWithZLIB decompresses zlib compressed data received as either a string or []byte, raw or base64 encoded,
+// and returns the decompressed data as a []byte. The optional maxbytes sets the maximum size of the decompressed data.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) HTTPPostJSON(parameters map[string]string) appcontext.AppFunction {
-	url, ok := parameters[Url]
-	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
+func (dynamic AppFunctionsSDKConfigurable) DecompressWithZLIB(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.decompression("DecompressWithZLIB", parameters)
+	if transform == nil {
 		return nil
 	}
+	return transform.DecompressWithZLIB
+}
 
-	// PersistOnError is optional and is false by default.
-	persistOnError := false
-	value, ok := parameters[PersistOnError]
-	if ok {
-		var err error
-		persistOnError, err = strconv.ParseBool(value)
-		if err != nil {
-			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, PersistOnError), "error", err)
-			return nil
-		}
+// DecompressWithLZ4 decompresses data in the LZ4 frame format received as either a string or []byte, raw or base64
+// encoded, and returns the decompressed data as a []byte. The optional maxbytes sets the maximum size of the
+// decompressed data.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) DecompressWithLZ4(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.decompression("DecompressWithLZ4", parameters)
+	if transform == nil {
+		return nil
 	}
-
-	url = strings.TrimSpace(url)
-	dynamic.Sdk.LoggingClient.Debug("HTTP Post JSON Parameters", Url, url)
-	return transforms.NewHTTPSender(url, "application/json", persistOnError).HTTPPost
+	return transform.DecompressWithLZ4
 }
 
-// HTTPPostXML sends data from the previous function to the specified Endpoint via http POST with a mime type of application/xml.
-// If no previous function exists, then the event that triggered the pipeline will be used.
-// This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) HTTPPostXML(parameters map[string]string) appcontext.AppFunction {
-	url, ok := parameters[Url]
+func (dynamic AppFunctionsSDKConfigurable) decompression(functionName string, parameters map[string]string) *transforms.Compression {
+	maxBytes, ok := dynamic.parseMaxBytes(parameters)
 	if !ok {
-		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
 		return nil
 	}
 
-	// PersistOnError is optional and is false by default.
-	persistOnError := false
-	value, ok := parameters[PersistOnError]
-	if ok {
-		var err error
-		persistOnError, err = strconv.ParseBool(value)
-		if err != nil {
-			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, PersistOnError), "error", err)
-			return nil
-		}
-	}
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters: %s=%d", functionName, MaxBytes, maxBytes))
+	return &transforms.Compression{MaxDecompressedSize: maxBytes}
+}
 
-	url = strings.TrimSpace(url)
-	dynamic.Sdk.LoggingClient.Debug("HTTP Post XML Parameters", Url, url)
-	return transforms.NewHTTPSender(url, "application/xml", persistOnError).HTTPPost
+// EncryptWithAES encrypts either a string, []byte, or json.Marshaller type using AES encryption.
+// It will return a byte[] of the encrypted data.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) EncryptWithAES(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.encryption(parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.EncryptWithAES
 }
 
-// MQTTSend sends data from the previous function to the specified MQTT broker.
-// If no previous function exists, then the event that triggered the pipeline will be used.
+// DecryptWithAES decrypts either a string or []byte, raw or base64 encoded, that was encrypted using AES encryption
+// with the same key and initvector. It will return a byte[] of the decrypted data.
 // This function is a configuration function and returns a function pointer.
-func (dynamic AppFunctionsSDKConfigurable) MQTTSend(parameters map[string]string, addr models.Addressable) appcontext.AppFunction {
-	var err error
+func (dynamic AppFunctionsSDKConfigurable) DecryptWithAES(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.encryption(parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.DecryptWithAES
+}
+
+func (dynamic AppFunctionsSDKConfigurable) encryption(parameters map[string]string) *transforms.Encryption {
+	key, ok := parameters[Key]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Key)
+		return nil
+	}
+	initVector, ok := parameters[InitVector]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + InitVector)
+		return nil
+	}
+	return &transforms.Encryption{
+		Key:                  key,
+		InitializationVector: initVector,
+	}
+}
+
+// EncryptWithAESGCM encrypts either a string, []byte, or json.Marshaller type using AES-256-GCM authenticated
+// encryption with a random nonce, which is prepended to the encrypted data. The 32 byte key, hex or base64 encoded, is
+// retrieved from the secret store using secretpath and secretname, "key" by default, or specified by key. The
+// optional additionaldata is authenticated along with the data. It will return a base64 encoded byte[] of the nonce
+// followed by the encrypted data.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) EncryptWithAESGCM(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.gcmEncryption("EncryptWithAESGCM", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.EncryptWithAESGCM
+}
+
+// DecryptWithAESGCM decrypts either a string or []byte, raw or base64 encoded, that was encrypted using
+// EncryptWithAESGCM with the same key and additionaldata. It will return a byte[] of the decrypted data.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) DecryptWithAESGCM(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.gcmEncryption("DecryptWithAESGCM", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.DecryptWithAESGCM
+}
+
+func (dynamic AppFunctionsSDKConfigurable) gcmEncryption(functionName string, parameters map[string]string) *transforms.Encryption {
+	transform := &transforms.Encryption{
+		Key:            parameters[Key],
+		SecretPath:     strings.TrimSpace(parameters[SecretPath]),
+		SecretName:     strings.TrimSpace(parameters[SecretName]),
+		AdditionalData: parameters[AdditionalData],
+	}
+	if transform.Key == "" && transform.SecretPath == "" {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not find %s or %s for %s", SecretPath, Key, functionName))
+		return nil
+	}
+	if transform.Key != "" && transform.SecretPath != "" {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Only one of %s or %s can be specified for %s", SecretPath, Key, functionName))
+		return nil
+	}
+	if transform.Key != "" {
+		dynamic.Sdk.LoggingClient.Warn(fmt.Sprintf("%s key is specified in plaintext, consider using %s to retrieve it from the secret store", functionName, SecretPath))
+	}
+
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters: %s=%s, %s=%s, %s=%s", functionName,
+		SecretPath, transform.SecretPath, SecretName, transform.SecretName, AdditionalData, transform.AdditionalData))
+	return transform
+}
+
+// Sign signs either a string, []byte, or json.Marshaller type using the algorithm, HMAC-SHA256, ECDSA-SHA256 or
+// Ed25519, with the key retrieved from the secret store using secretpath and secretname, "key" by default, or specified
+// by key. The base64 encoded signature is sent as the headername header, X-Signature by default, by HTTPPost. When
+// envelope is true the data and signature are returned as a JSON envelope instead.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) Sign(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.signature("Sign", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.Sign
+}
+
+// VerifySignature verifies the data signed by Sign using the algorithm with the public key, or HMAC secret,
+// retrieved from the secret store using secretpath and secretname, "key" by default, or specified by key. The
+// signature is taken from the headername header, X-Signature by default, received by the trigger along with the data.
+// When envelope is true the data is the JSON envelope created by Sign instead and its payload is returned.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) VerifySignature(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.signature("VerifySignature", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.VerifySignature
+}
+
+func (dynamic AppFunctionsSDKConfigurable) signature(functionName string, parameters map[string]string) *transforms.Signature {
+	algorithm, ok := parameters[Algorithm]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Algorithm)
+		return nil
+	}
+
+	key := parameters[Key]
+	secretPath := strings.TrimSpace(parameters[SecretPath])
+	if key == "" && secretPath == "" {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not find %s or %s for %s", SecretPath, Key, functionName))
+		return nil
+	}
+	if key != "" && secretPath != "" {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Only one of %s or %s can be specified for %s", SecretPath, Key, functionName))
+		return nil
+	}
+
+	var transform *transforms.Signature
+	var err error
+	if secretPath != "" {
+		transform, err = transforms.NewSignatureWithSecret(algorithm, secretPath, strings.TrimSpace(parameters[SecretName]))
+	} else {
+		transform, err = transforms.NewSignature(algorithm, key)
+	}
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid %s parameters", functionName), "error", err)
+		return nil
+	}
+
+	value, ok := parameters[Envelope]
+	if ok {
+		transform.Envelope, err = strconv.ParseBool(value)
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, Envelope), "error", err)
+			return nil
+		}
+	}
+	transform.HeaderName = strings.TrimSpace(parameters[HeaderName])
+
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters: %s=%s, %s=%s, %s=%s, %s=%t, %s=%s", functionName,
+		Algorithm, transform.Algorithm, SecretPath, transform.SecretPath, SecretName, transform.SecretName,
+		Envelope, transform.Envelope, HeaderName, transform.HeaderName))
+	return transform
+}
+
+// EncodeWithProtobuf encodes an EdgeX event, or any data that can be converted to JSON, as the Protocol Buffers
+// message with the messagename from the descriptorfile created by protoc and returns the binary encoding as a []byte.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) EncodeWithProtobuf(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.protobuf("EncodeWithProtobuf", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.EncodeWithProtobuf
+}
+
+// DecodeWithProtobuf decodes the binary Protocol Buffers message with the messagename from the descriptorfile created
+// by protoc and returns it as JSON in a []byte, or as an EdgeX event when outputevent is true.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) DecodeWithProtobuf(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.protobuf("DecodeWithProtobuf", parameters)
+	if transform == nil {
+		return nil
+	}
+	outputEvent, ok := dynamic.outputEvent(parameters)
+	if !ok {
+		return nil
+	}
+	transform.OutputEvent = outputEvent
+	return transform.DecodeWithProtobuf
+}
+
+func (dynamic AppFunctionsSDKConfigurable) protobuf(functionName string, parameters map[string]string) *transforms.Protobuf {
+	descriptorFile, ok := parameters[DescriptorFile]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + DescriptorFile)
+		return nil
+	}
+	messageName, ok := parameters[MessageName]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + MessageName)
+		return nil
+	}
+	descriptorFile = strings.TrimSpace(descriptorFile)
+	messageName = strings.TrimSpace(messageName)
+
+	transform, err := transforms.NewProtobuf(descriptorFile, messageName)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid %s parameters", functionName), "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters", functionName), DescriptorFile, descriptorFile, MessageName, messageName)
+	return transform
+}
+
+// EncodeWithAvro encodes an EdgeX event, or any data that can be converted to JSON, with the Avro schema specified
+// inline or in the schemafile and returns the Avro binary encoding as a []byte.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) EncodeWithAvro(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.avro("EncodeWithAvro", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.EncodeWithAvro
+}
+
+// DecodeWithAvro decodes the Avro binary encoding with the Avro schema specified inline or in the schemafile and
+// returns it as JSON in a []byte, or as an EdgeX event when outputevent is true.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) DecodeWithAvro(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.avro("DecodeWithAvro", parameters)
+	if transform == nil {
+		return nil
+	}
+	outputEvent, ok := dynamic.outputEvent(parameters)
+	if !ok {
+		return nil
+	}
+	transform.OutputEvent = outputEvent
+	return transform.DecodeWithAvro
+}
+
+func (dynamic AppFunctionsSDKConfigurable) avro(functionName string, parameters map[string]string) *transforms.Avro {
+	schema, hasSchema := parameters[Schema]
+	schemaFile, hasSchemaFile := parameters[SchemaFile]
+	if hasSchema == hasSchemaFile {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Exactly one of '%s' or '%s' must be specified", Schema, SchemaFile))
+		return nil
+	}
+
+	var transform *transforms.Avro
+	var err error
+	if hasSchema {
+		transform, err = transforms.NewAvro(schema)
+	} else {
+		schemaFile = strings.TrimSpace(schemaFile)
+		transform, err = transforms.NewAvroFromFile(schemaFile)
+	}
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid %s parameters", functionName), "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters", functionName), Schema, schema, SchemaFile, schemaFile)
+	return transform
+}
+
+// outputEvent parses the optional outputevent parameter, which is false by default
+func (dynamic AppFunctionsSDKConfigurable) outputEvent(parameters map[string]string) (bool, bool) {
+	value, ok := parameters[OutputEvent]
+	if !ok {
+		return false, true
+	}
+	outputEvent, err := strconv.ParseBool(value)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, OutputEvent), "error", err)
+		return false, false
+	}
+	return outputEvent, true
+}
+
+// HTTPPost will send data from the previous function to the specified Endpoint via http POST. If no previous function exists,
+// then the event that triggered the pipeline will be used. Passing an empty string to the mimetype
+// method will default to application/json.
+// The optional headers, authmode, secretpath, apikeyheader, tokenurl, scopes, cert, key, cacert, skipverify, timeout,
+// successcodes, retrycodes, maxidleconns, maxidleconnsperhost, idleconntimeout, disablekeepalives, disablehttp2, proxy
+// and maxresponsebytes parameters configure the request, see httpSender.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) HTTPPost(parameters map[string]string) appcontext.AppFunction {
+	mimeType, ok := parameters[MimeType]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + MimeType)
+		return nil
+	}
+
+	transform := dynamic.httpSender("HTTPPost", mimeType, parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.HTTPPost
+}
+
+// HTTPPostJSON sends data from the previous function to the specified Endpoint via http POST with a mime type of application/json.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) HTTPPostJSON(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.httpSender("HTTPPostJSON", "application/json", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.HTTPPost
+}
+
+// HTTPPostXML sends data from the previous function to the specified Endpoint via http POST with a mime type of application/xml.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) HTTPPostXML(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.httpSender("HTTPPostXML", "application/xml", parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.HTTPPost
+}
+
+// HTTPPut sends data from the previous function to the specified Endpoint via http PUT. The optional mimetype
+// defaults to application/json. It accepts the same optional parameters as HTTPPost.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) HTTPPut(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.httpSender("HTTPPut", parameters[MimeType], parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.HTTPPut
+}
+
+// HTTPPatch sends data from the previous function to the specified Endpoint via http PATCH. The optional mimetype
+// defaults to application/json. It accepts the same optional parameters as HTTPPost.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) HTTPPatch(parameters map[string]string) appcontext.AppFunction {
+	transform := dynamic.httpSender("HTTPPatch", parameters[MimeType], parameters)
+	if transform == nil {
+		return nil
+	}
+	return transform.HTTPPatch
+}
+
+// httpSender creates the HTTPSender for the url and the optional parameters. The headers are `Name: value` pairs
+// separated by semicolons, where the values may be Go templates using .Data and .CorrelationID. The authmode, bearer,
+// basic, apikey or oauth2, retrieves the credentials from the secretpath and the API key is sent in the apikeyheader,
+// X-API-Key by default. The oauth2 access tokens are requested from the tokenurl with the optional comma separated
+// scopes. The cert, key and cacert PEM files are used for client authentication and to verify the server, unless
+// skipverify is set. The timeout is the duration allowed for each request and the successcodes and retrycodes are
+// comma separated status codes or ranges, i.e. 200-299,304. The maxidleconns, maxidleconnsperhost and idleconntimeout
+// tune the pooling of connections, which can be turned off by disablekeepalives, and disablehttp2 prevents HTTP/2 from
+// being negotiated. The proxy URL overrides the HTTP_PROXY and HTTPS_PROXY environment variables and maxresponsebytes
+// limits the size of the response read.
+func (dynamic AppFunctionsSDKConfigurable) httpSender(functionName string, mimeType string, parameters map[string]string) *transforms.HTTPSender {
+	var err error
+
+	url, ok := parameters[Url]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
+		return nil
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	value, ok := parameters[PersistOnError]
+	if ok {
+		persistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, PersistOnError), "error", err)
+			return nil
+		}
+	}
+
+	options := transforms.HTTPSenderOptions{
+		AuthMode:       strings.ToLower(strings.TrimSpace(parameters[AuthMode])),
+		SecretPath:     strings.TrimSpace(parameters[SecretPath]),
+		APIKeyHeader:   strings.TrimSpace(parameters[APIKeyHeader]),
+		ClientCertFile: strings.TrimSpace(parameters[Cert]),
+		ClientKeyFile:  strings.TrimSpace(parameters[Key]),
+		CACertFile:     strings.TrimSpace(parameters[CACert]),
+		TokenURL:       strings.TrimSpace(parameters[TokenURL]),
+		Scopes:         util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[Scopes], util.SplitComma)),
+		ProxyURL:       strings.TrimSpace(parameters[Proxy]),
+	}
+
+	headers := util.DeleteEmptyAndTrim(strings.Split(parameters[Headers], ";"))
+	if len(headers) > 0 {
+		options.Headers = make(map[string]string, len(headers))
+		for _, header := range headers {
+			nameValue := strings.SplitN(header, ":", 2)
+			if len(nameValue) != 2 || strings.TrimSpace(nameValue[0]) == "" {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid header '%s', must be of the form Name: value", header))
+				return nil
+			}
+			options.Headers[strings.TrimSpace(nameValue[0])] = strings.TrimSpace(nameValue[1])
+		}
+	}
+
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{SkipVerify, &options.SkipCertVerify},
+		{DisableKeepAlives, &options.DisableKeepAlives},
+		{DisableHTTP2, &options.DisableHTTP2},
+	}
+	for _, parameter := range bools {
+		value, ok = parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{Timeout, &options.Timeout},
+		{IdleConnTimeout, &options.IdleConnTimeout},
+	}
+	for _, parameter := range durations {
+		value, ok = parameters[parameter.name]
+		if ok {
+			*parameter.value, err = time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || *parameter.value < 0 {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, parameter.name))
+				return nil
+			}
+		}
+	}
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{MaxIdleConns, &options.MaxIdleConns},
+		{MaxIdleConnsPerHost, &options.MaxIdleConnsPerHost},
+	}
+	for _, parameter := range ints {
+		value, ok = parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || *parameter.value < 0 {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive int for '%s' parameter", value, parameter.name))
+				return nil
+			}
+		}
+	}
+
+	value, ok = parameters[MaxResponseBytes]
+	if ok {
+		options.MaxResponseBytes, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || options.MaxResponseBytes < 0 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive int for '%s' parameter", value, MaxResponseBytes))
+			return nil
+		}
+	}
+
+	if options.SuccessStatusCodes, ok = dynamic.parseStatusCodes(SuccessCodes, parameters); !ok {
+		return nil
+	}
+	if options.RetryStatusCodes, ok = dynamic.parseStatusCodes(RetryCodes, parameters); !ok {
+		return nil
+	}
+
+	url = strings.TrimSpace(url)
+	mimeType = strings.TrimSpace(mimeType)
+	transform, err := transforms.NewHTTPSenderWithOptions(url, mimeType, persistOnError, options)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid %s parameters", functionName), "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("%s Parameters", functionName), Url, transform.URL, MimeType, transform.MimeType,
+		PersistOnError, strconv.FormatBool(persistOnError), Headers, parameters[Headers], AuthMode, options.AuthMode,
+		SecretPath, options.SecretPath, Timeout, options.Timeout.String(), Proxy, proxyHost(options.ProxyURL))
+	return &transform
+}
+
+// proxyHost returns the scheme and host of the proxy URL for logging, leaving out the credentials it may hold
+func proxyHost(proxyURL string) string {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// parseStatusCodes parses the optional comma separated status codes and ranges, i.e. 200-299,304, of the parameter
+func (dynamic AppFunctionsSDKConfigurable) parseStatusCodes(name string, parameters map[string]string) ([]int, bool) {
+	value, ok := parameters[name]
+	if !ok {
+		return nil, true
+	}
+
+	var statusCodes []int
+	for _, item := range util.DeleteEmptyAndTrim(strings.FieldsFunc(value, util.SplitComma)) {
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		last := first
+		if err == nil && len(bounds) == 2 {
+			last, err = strconv.Atoi(strings.TrimSpace(bounds[1]))
+		}
+		if err != nil || first < 100 || last > 599 || first > last {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to status codes for '%s' parameter", item, name))
+			return nil, false
+		}
+		for statusCode := first; statusCode <= last; statusCode++ {
+			statusCodes = append(statusCodes, statusCode)
+		}
+	}
+	return statusCodes, true
+}
+
+// MQTTSend sends data from the previous function to the specified MQTT broker.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// The optional protocolversion, 3, 4 or 5, persistentsession, sessionexpiry, keepalive, connecttimeout, willtopic,
+// willpayload, willqos, willretain, cacert, secretpath, contenttype, messageexpiry and userproperties, as comma
+// separated key=value pairs, parameters set the corresponding MqttConfig fields. When connectonstart is true the
+// sender connects to the broker when the pipeline is loaded rather than when the first data is sent.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) MQTTSend(parameters map[string]string, addr models.Addressable) appcontext.AppFunction {
+	var err error
 	qos := 0
 	retain := false
 	autoReconnect := false
@@ -311,7 +1252,8 @@ func (dynamic AppFunctionsSDKConfigurable) MQTTSend(parameters map[string]string
 			return nil
 		}
 	}
-	dynamic.Sdk.LoggingClient.Debug("MQTT Send Parameters", "Address", addr, Qos, qosVal, Retain, retainVal, AutoReconnect, autoreconnectVal, Cert, cert, Key, key)
+	dynamic.Sdk.LoggingClient.Debug("MQTT Send Parameters", "Address", addr, Qos, qosVal, Retain, retainVal, AutoReconnect, autoreconnectVal, Cert, cert, Key, key,
+		ProtocolVersion, parameters[ProtocolVersion], PersistentSession, parameters[PersistentSession], SecretPath, parameters[SecretPath])
 
 	var pair *transforms.KeyCertPair
 
@@ -337,6 +1279,11 @@ func (dynamic AppFunctionsSDKConfigurable) MQTTSend(parameters map[string]string
 	mqttConfig.Qos = byte(qos)
 	mqttConfig.Retain = retain
 	mqttConfig.AutoReconnect = autoReconnect
+	mqttConfig.WillTopic = strings.TrimSpace(parameters[WillTopic])
+	mqttConfig.WillPayload = []byte(parameters[WillPayload])
+	mqttConfig.CACertFile = strings.TrimSpace(parameters[CACert])
+	mqttConfig.SecretPath = strings.TrimSpace(parameters[SecretPath])
+	mqttConfig.ContentType = strings.TrimSpace(parameters[ContentType])
 
 	if skipVerify != "" {
 		skipCertVerify, err := strconv.ParseBool(skipVerify)
@@ -348,10 +1295,599 @@ func (dynamic AppFunctionsSDKConfigurable) MQTTSend(parameters map[string]string
 		mqttConfig.SkipCertVerify = skipCertVerify
 	}
 
+	connectOnStart := false
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{PersistentSession, &mqttConfig.PersistentSession},
+		{WillRetain, &mqttConfig.WillRetain},
+		{ConnectOnStart, &connectOnStart},
+	}
+	for _, parameter := range bools {
+		value, ok = parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{SessionExpiry, &mqttConfig.SessionExpiry},
+		{KeepAlive, &mqttConfig.KeepAlive},
+		{ConnectTimeout, &mqttConfig.ConnectTimeout},
+		{MessageExpiry, &mqttConfig.MessageExpiry},
+	}
+	for _, parameter := range durations {
+		value, ok = parameters[parameter.name]
+		if ok {
+			*parameter.value, err = time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || *parameter.value < 0 {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, parameter.name))
+				return nil
+			}
+		}
+	}
+
+	value, ok = parameters[ProtocolVersion]
+	if ok {
+		version, err := strconv.ParseUint(strings.TrimSpace(value), 10, 8)
+		if err != nil {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to an int for '%s' parameter", value, ProtocolVersion), "error", err)
+			return nil
+		}
+		mqttConfig.ProtocolVersion = uint(version)
+	}
+
+	value, ok = parameters[WillQos]
+	if ok {
+		willQos, err := strconv.ParseUint(strings.TrimSpace(value), 10, 8)
+		if err != nil || willQos > 2 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a QoS of 0, 1 or 2 for '%s' parameter", value, WillQos))
+			return nil
+		}
+		mqttConfig.WillQos = byte(willQos)
+	}
+
+	for _, property := range util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[UserProperties], util.SplitComma)) {
+		keyValue := strings.SplitN(property, "=", 2)
+		if len(keyValue) != 2 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid user property '%s', must be of the form key=value", property))
+			return nil
+		}
+		if mqttConfig.UserProperties == nil {
+			mqttConfig.UserProperties = make(map[string]string)
+		}
+		mqttConfig.UserProperties[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+	}
+
 	sender := transforms.NewMQTTSender(dynamic.Sdk.LoggingClient, addr, pair, mqttConfig, persistOnError)
+	if sender == nil {
+		return nil
+	}
+
+	if connectOnStart {
+		edgexcontext := &appcontext.Context{
+			LoggingClient:  dynamic.Sdk.LoggingClient,
+			SecretProvider: dynamic.Sdk.secretProvider,
+		}
+		go func() {
+			if err := sender.Connect(edgexcontext); err != nil {
+				dynamic.Sdk.LoggingClient.Warn(fmt.Sprintf("Could not connect to mqtt server on start, connecting when data is sent: %s", err.Error()))
+			}
+		}()
+	}
+
 	return sender.MQTTSend
 }
 
+// KafkaSend sends data from the previous function to the Kafka brokers, as a comma separated list of host:port, on the
+// topic. The topic and optional messagekey can hold placeholders, i.e. {device}, which are resolved for each message.
+// The optional acks (all, one or none), compression (gzip, snappy, lz4 or zstd), clientid, batchtimeout and timeout
+// tune the sending, while saslmechanism (plain, scram-sha-256 or scram-sha-512) authenticates with the username and
+// password secrets from the secretpath. When usetls is true the optional cert, key, cacert and skipverify parameters
+// configure TLS. If the send fails and persistOnError is true and Store and Forward is enabled, the data will be
+// stored for later retry.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) KafkaSend(parameters map[string]string) appcontext.AppFunction {
+	var err error
+
+	brokers := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[Brokers], util.SplitComma))
+	if len(brokers) == 0 {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Brokers)
+		return nil
+	}
+	topic, ok := parameters[Topic]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Topic)
+		return nil
+	}
+
+	config := transforms.KafkaConfig{
+		Brokers:        brokers,
+		Topic:          strings.TrimSpace(topic),
+		Key:            strings.TrimSpace(parameters[MessageKey]),
+		Acks:           strings.TrimSpace(parameters[Acks]),
+		Compression:    strings.TrimSpace(parameters[CompressionType]),
+		ClientID:       strings.TrimSpace(parameters[ClientID]),
+		SASLMechanism:  strings.TrimSpace(parameters[SASLMechanism]),
+		SecretPath:     strings.TrimSpace(parameters[SecretPath]),
+		ClientCertFile: strings.TrimSpace(parameters[Cert]),
+		ClientKeyFile:  strings.TrimSpace(parameters[Key]),
+		CACertFile:     strings.TrimSpace(parameters[CACert]),
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{PersistOnError, &persistOnError},
+		{UseTLS, &config.UseTLS},
+		{SkipVerify, &config.SkipCertVerify},
+	}
+	for _, parameter := range bools {
+		value, ok := parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{BatchTimeout, &config.BatchTimeout},
+		{Timeout, &config.Timeout},
+	}
+	for _, parameter := range durations {
+		value, ok := parameters[parameter.name]
+		if ok {
+			*parameter.value, err = time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || *parameter.value < 0 {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, parameter.name))
+				return nil
+			}
+		}
+	}
+
+	sender, err := transforms.NewKafkaSender(config, persistOnError)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Unable to create Kafka sender", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("Kafka Send Parameters", Brokers, parameters[Brokers], Topic, topic, MessageKey, config.Key,
+		Acks, config.Acks, CompressionType, config.Compression, SASLMechanism, config.SASLMechanism, UseTLS, config.UseTLS)
+
+	return sender.KafkaSend
+}
+
+// AMQPSend publishes data from the previous function to an AMQP 0.9.1 broker, such as RabbitMQ, at the url, i.e.
+// amqp://rabbitmq:5672/edgex, waiting for the broker to confirm each message. The optional exchange is the exchange the
+// data is published to, the default exchange when not set, and the optional routingkey can hold placeholders, i.e.
+// site1.{device}, which are resolved for each message. When persistent is true the messages survive a broker restart.
+// The optional contenttype sets the content type of the messages and confirmtimeout how long to wait for the broker's
+// confirmation. The username and password secrets from the optional secretpath override those in the url, while cert,
+// key, cacert and skipverify configure TLS with amqps:// urls. If the message isn't confirmed and persistOnError is
+// true and Store and Forward is enabled, the data will be stored for later retry.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) AMQPSend(parameters map[string]string) appcontext.AppFunction {
+	var err error
+
+	url, ok := parameters[Url]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
+		return nil
+	}
+
+	config := transforms.AMQPConfig{
+		URL:            strings.TrimSpace(url),
+		Exchange:       strings.TrimSpace(parameters[Exchange]),
+		RoutingKey:     strings.TrimSpace(parameters[RoutingKey]),
+		ContentType:    strings.TrimSpace(parameters[ContentType]),
+		SecretPath:     strings.TrimSpace(parameters[SecretPath]),
+		ClientCertFile: strings.TrimSpace(parameters[Cert]),
+		ClientKeyFile:  strings.TrimSpace(parameters[Key]),
+		CACertFile:     strings.TrimSpace(parameters[CACert]),
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{PersistOnError, &persistOnError},
+		{Persistent, &config.Persistent},
+		{SkipVerify, &config.SkipCertVerify},
+	}
+	for _, parameter := range bools {
+		value, ok := parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	if value, ok := parameters[ConfirmTimeout]; ok {
+		config.ConfirmTimeout, err = time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || config.ConfirmTimeout < 0 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, ConfirmTimeout))
+			return nil
+		}
+	}
+
+	sender, err := transforms.NewAMQPSender(config, persistOnError)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Unable to create AMQP sender", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("AMQP Send Parameters", Exchange, config.Exchange, RoutingKey, config.RoutingKey,
+		Persistent, config.Persistent, ContentType, config.ContentType, PersistOnError, persistOnError)
+
+	return sender.AMQPSend
+}
+
+// NATSSend publishes data from the previous function to the NATS server at the url, or the comma separated urls of a
+// cluster, on the subject, which can hold placeholders, i.e. site1.{device}, that are resolved for each message. When
+// jetstream is true the data is published to the stream holding the subject, waiting for the stream to store it.
+// The username and password, or token, secrets from the optional secretpath, or the optional credentialsfile,
+// authenticate with the server, while usetls along with the optional cert, key, cacert and skipverify parameters
+// configure TLS. The optional timeout is how long to wait for the server to receive the data. If the send fails and
+// persistOnError is true and Store and Forward is enabled, the data will be stored for later retry.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) NATSSend(parameters map[string]string) appcontext.AppFunction {
+	var err error
+
+	url, ok := parameters[Url]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Url)
+		return nil
+	}
+	subject, ok := parameters[Subject]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Subject)
+		return nil
+	}
+
+	config := transforms.NATSConfig{
+		URL:             strings.TrimSpace(url),
+		Subject:         strings.TrimSpace(subject),
+		SecretPath:      strings.TrimSpace(parameters[SecretPath]),
+		CredentialsFile: strings.TrimSpace(parameters[CredentialsFile]),
+		ClientCertFile:  strings.TrimSpace(parameters[Cert]),
+		ClientKeyFile:   strings.TrimSpace(parameters[Key]),
+		CACertFile:      strings.TrimSpace(parameters[CACert]),
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{PersistOnError, &persistOnError},
+		{JetStream, &config.JetStream},
+		{UseTLS, &config.UseTLS},
+		{SkipVerify, &config.SkipCertVerify},
+	}
+	for _, parameter := range bools {
+		value, ok := parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	if value, ok := parameters[Timeout]; ok {
+		config.Timeout, err = time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || config.Timeout < 0 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, Timeout))
+			return nil
+		}
+	}
+
+	sender, err := transforms.NewNATSSender(config, persistOnError)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Unable to create NATS sender", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("NATS Send Parameters", Subject, config.Subject, JetStream, config.JetStream,
+		UseTLS, config.UseTLS, PersistOnError, persistOnError)
+
+	return sender.NATSSend
+}
+
+// GRPCSend streams data from the previous function to the gRPC endpoint at the address, i.e. host:50051, which serves
+// the AppService of the grpcapi package, and returns the output data the endpoint responds with. The optional
+// contenttype is the content type of the data, while usetls along with the optional cert, key, cacert and skipverify
+// parameters configure TLS. The optional timeout is how long to wait for the endpoint to respond. If the send fails
+// and persistOnError is true and Store and Forward is enabled, the data will be stored for later retry.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) GRPCSend(parameters map[string]string) appcontext.AppFunction {
+	var err error
+
+	address, ok := parameters[Address]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Address)
+		return nil
+	}
+
+	config := transforms.GRPCConfig{
+		Address:        strings.TrimSpace(address),
+		ContentType:    strings.TrimSpace(parameters[ContentType]),
+		ClientCertFile: strings.TrimSpace(parameters[Cert]),
+		ClientKeyFile:  strings.TrimSpace(parameters[Key]),
+		CACertFile:     strings.TrimSpace(parameters[CACert]),
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	bools := []struct {
+		name  string
+		value *bool
+	}{
+		{PersistOnError, &persistOnError},
+		{UseTLS, &config.UseTLS},
+		{SkipVerify, &config.SkipCertVerify},
+	}
+	for _, parameter := range bools {
+		value, ok := parameters[parameter.name]
+		if ok {
+			*parameter.value, err = strconv.ParseBool(value)
+			if err != nil {
+				dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a bool for '%s' parameter", value, parameter.name), "error", err)
+				return nil
+			}
+		}
+	}
+
+	if value, ok := parameters[Timeout]; ok {
+		config.Timeout, err = time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || config.Timeout < 0 {
+			dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive duration for '%s' parameter", value, Timeout))
+			return nil
+		}
+	}
+
+	sender, err := transforms.NewGRPCSender(config, persistOnError)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error("Unable to create gRPC sender", "error", err)
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("gRPC Send Parameters", Address, config.Address, UseTLS, config.UseTLS,
+		PersistOnError, persistOnError)
+
+	return sender.GRPCSend
+}
+
+// WebSocketBroadcast pushes data from the previous function to the clients connected to the WebSocket route, which is
+// added when WebSocket.Route is set in the configuration. Clients can choose the devices whose data they receive with
+// the device query parameter. This function takes no parameters.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) WebSocketBroadcast(parameters map[string]string) appcontext.AppFunction {
+	if dynamic.Sdk.webSocket == nil {
+		dynamic.Sdk.LoggingClient.Error("The WebSocket route isn't enabled, WebSocket.Route must be set in the configuration")
+		return nil
+	}
+
+	return dynamic.Sdk.webSocket.Broadcast
+}
+
+// SSEBroadcast pushes data from the previous function to the clients connected to the Server-Sent Events route.
+// Clients can choose the devices and readings whose data they receive with the device and reading query parameters.
+// This function takes no parameters.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) SSEBroadcast(parameters map[string]string) appcontext.AppFunction {
+	if dynamic.Sdk.sse == nil {
+		dynamic.Sdk.LoggingClient.Error("The SSE route hasn't been added, the SDK must be initialized first")
+		return nil
+	}
+
+	return dynamic.Sdk.sse.Broadcast
+}
+
+// BatchByCount - Specify the batchthreshold as the number of items to batch before releasing the batched data and
+// continuing the pipeline. The optional maxbytes releases the batch early once the combined size of the batched data
+// reaches it. The optional outputformat (raw, json, ndjson or csv) and csvcolumns set the format of the released data.
+// The batched data is retained when the pipeline is reloaded with unchanged parameters.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) BatchByCount(parameters map[string]string) appcontext.AppFunction {
+	batchThreshold, ok := dynamic.parseBatchThreshold(parameters)
+	if !ok {
+		return nil
+	}
+	maxBytes, ok := dynamic.parseMaxBytes(parameters)
+	if !ok {
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("Batch By Count Parameters", BatchThreshold, batchThreshold, MaxBytes, maxBytes)
+	return dynamic.batch("BatchByCount", fmt.Sprintf("%d:%d", batchThreshold, maxBytes), maxBytes, parameters, func() (*transforms.BatchConfig, error) {
+		return transforms.NewBatchByCount(batchThreshold)
+	})
+}
+
+// BatchByTime - Specify the timeinterval (i.e. 10s) to batch data for before releasing the batched data and
+// continuing the pipeline. The optional maxbytes releases the batch early once the combined size of the batched data
+// reaches it. The optional outputformat (raw, json, ndjson or csv) and csvcolumns set the format of the released data.
+// The batched data is retained when the pipeline is reloaded with unchanged parameters.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) BatchByTime(parameters map[string]string) appcontext.AppFunction {
+	timeInterval, ok := dynamic.parseTimeInterval(parameters)
+	if !ok {
+		return nil
+	}
+	maxBytes, ok := dynamic.parseMaxBytes(parameters)
+	if !ok {
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("Batch By Time Parameters", TimeInterval, timeInterval, MaxBytes, maxBytes)
+	return dynamic.batch("BatchByTime", fmt.Sprintf("%s:%d", timeInterval, maxBytes), maxBytes, parameters, func() (*transforms.BatchConfig, error) {
+		return transforms.NewBatchByTime(timeInterval)
+	})
+}
+
+// BatchByTimeAndCount - Specify the timeinterval (i.e. 10s) and batchthreshold, whichever occurs first releases the
+// batched data and continues the pipeline. The optional maxbytes releases the batch early once the combined size of the
+// batched data reaches it. The optional outputformat (raw, json, ndjson or csv) and csvcolumns set the format of the
+// released data. The batched data is retained when the pipeline is reloaded with unchanged parameters.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) BatchByTimeAndCount(parameters map[string]string) appcontext.AppFunction {
+	timeInterval, ok := dynamic.parseTimeInterval(parameters)
+	if !ok {
+		return nil
+	}
+	batchThreshold, ok := dynamic.parseBatchThreshold(parameters)
+	if !ok {
+		return nil
+	}
+	maxBytes, ok := dynamic.parseMaxBytes(parameters)
+	if !ok {
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("Batch By Time and Count Parameters", TimeInterval, timeInterval,
+		BatchThreshold, batchThreshold, MaxBytes, maxBytes)
+	return dynamic.batch("BatchByTimeAndCount", fmt.Sprintf("%s:%d:%d", timeInterval, batchThreshold, maxBytes), maxBytes,
+		parameters, func() (*transforms.BatchConfig, error) {
+			return transforms.NewBatchByTimeAndCount(timeInterval, batchThreshold)
+		})
+}
+
+// JSONLogic - Specify the JSONLogic rule that the data must meet for the pipeline to continue.
+// See http://jsonlogic.com/operations.html for the rule format.
+// This function is a configuration function and returns a function pointer.
+func (dynamic AppFunctionsSDKConfigurable) JSONLogic(parameters map[string]string) appcontext.AppFunction {
+	rule, ok := parameters[Rule]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + Rule)
+		return nil
+	}
+	rule = strings.TrimSpace(rule)
+	if !json.Valid([]byte(rule)) {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("'%s' parameter is not valid JSON", Rule))
+		return nil
+	}
+
+	dynamic.Sdk.LoggingClient.Debug("JSONLogic Parameters", Rule, rule)
+	transform := transforms.NewJSONLogic(rule)
+	return transform.Evaluate
+}
+
+// batch returns the Batch function of the existing batch for functionName if its parameters are unchanged, so that
+// batched data survives reloading the pipeline. Otherwise a new batch is created. It is called while
+// LoadConfigurablePipeline holds the batchersMutex.
+func (dynamic AppFunctionsSDKConfigurable) batch(functionName string, batchParameters string, maxBytes int,
+	parameters map[string]string, create func() (*transforms.BatchConfig, error)) appcontext.AppFunction {
+	outputFormat, err := transforms.ParseBatchOutputFormat(parameters[OutputFormat])
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(err.Error())
+		return nil
+	}
+	csvColumns := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[CSVColumns], util.SplitComma))
+	batchParameters = fmt.Sprintf("%s:%d:%s", batchParameters, outputFormat, strings.Join(csvColumns, ","))
+
+	if existing, ok := dynamic.Sdk.batchers[functionName]; ok && existing.parameters == batchParameters {
+		dynamic.Sdk.LoggingClient.Debug(fmt.Sprintf("Reusing existing batch for %s", functionName))
+		return existing.batch.Batch
+	}
+
+	batch, err := create()
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Unable to create batch for %s", functionName), "error", err)
+		return nil
+	}
+	batch.MaxBytes = maxBytes
+	if err := batch.SetOutputFormat(outputFormat, csvColumns...); err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Invalid output format for %s", functionName), "error", err)
+		return nil
+	}
+
+	if dynamic.Sdk.batchers == nil {
+		dynamic.Sdk.batchers = make(map[string]*configurableBatch)
+	}
+	dynamic.Sdk.batchers[functionName] = &configurableBatch{
+		parameters: batchParameters,
+		batch:      batch,
+	}
+
+	return batch.Batch
+}
+
+func (dynamic AppFunctionsSDKConfigurable) parseBatchThreshold(parameters map[string]string) (int, bool) {
+	value, ok := parameters[BatchThreshold]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + BatchThreshold)
+		return 0, false
+	}
+	batchThreshold, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to an int for '%s' parameter", value, BatchThreshold), "error", err)
+		return 0, false
+	}
+	if batchThreshold <= 0 {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("'%s' parameter must be greater than zero", BatchThreshold))
+		return 0, false
+	}
+	return batchThreshold, true
+}
+
+func (dynamic AppFunctionsSDKConfigurable) parseTimeInterval(parameters map[string]string) (string, bool) {
+	value, ok := parameters[TimeInterval]
+	if !ok {
+		dynamic.Sdk.LoggingClient.Error("Could not find " + TimeInterval)
+		return "", false
+	}
+	value = strings.TrimSpace(value)
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a duration for '%s' parameter", value, TimeInterval), "error", err)
+		return "", false
+	}
+	if duration <= 0 {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("'%s' parameter must be greater than zero", TimeInterval))
+		return "", false
+	}
+	return value, true
+}
+
+func (dynamic AppFunctionsSDKConfigurable) parseMaxBytes(parameters map[string]string) (int, bool) {
+	// MaxBytes is optional and is disabled by default.
+	value, ok := parameters[MaxBytes]
+	if !ok {
+		return 0, true
+	}
+	maxBytes, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || maxBytes < 0 {
+		dynamic.Sdk.LoggingClient.Error(fmt.Sprintf("Could not parse '%s' to a positive int for '%s' parameter", value, MaxBytes))
+		return 0, false
+	}
+	return maxBytes, true
+}
+
 // SetOutputData sets the output data to that passed in from the previous function.
 // It will return an error and stop the pipeline if data passed in is not of type []byte, string or json.Mashaler
 // This function is a configuration function and returns a function pointer.
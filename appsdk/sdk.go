@@ -56,9 +56,15 @@ import (
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/store/db/interfaces"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/telemetry"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/amqp"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/grpc"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/http"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/kafka"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/messagebus"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/nats"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/websocket"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/webserver"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/transforms"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/urlclient"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
 )
@@ -100,9 +106,13 @@ type AppFunctionsSDK struct {
 	skipVersionCheck          bool
 	overwriteConfig           bool
 	usingConfigurablePipeline bool
+	batchers                  map[string]*configurableBatch
+	batchersMutex             sync.Mutex
 	httpErrors                chan error
 	runtime                   *runtime.GolangRuntime
 	webserver                 *webserver.WebServer
+	webSocket                 *transforms.WebSocketBroadcaster
+	sse                       *transforms.SSEBroadcaster
 	edgexClients              common.EdgeXClients
 	registryClient            registry.Client
 	config                    common.ConfigurationStruct
@@ -179,13 +189,14 @@ func (sdk *AppFunctionsSDK) MakeItRun() error {
 
 	sdk.appCancelCtx() // Cancel all long running go funcs
 	sdk.appWg.Wait()
+	sdk.batchersMutex.Lock()
+	sdk.closeBatchers(sdk.batchers, nil)
+	sdk.batchersMutex.Unlock()
 	return err
 }
 
 // LoadConfigurablePipeline ...
 func (sdk *AppFunctionsSDK) LoadConfigurablePipeline() ([]appcontext.AppFunction, error) {
-	var pipeline []appcontext.AppFunction
-
 	sdk.usingConfigurablePipeline = true
 
 	sdk.TargetType = nil
@@ -193,6 +204,31 @@ func (sdk *AppFunctionsSDK) LoadConfigurablePipeline() ([]appcontext.AppFunction
 		sdk.TargetType = &[]byte{}
 	}
 
+	// The pipeline is reloaded on configuration changes while the service is running, so the batches are guarded
+	// for the whole load. Batches are kept across reloads when their parameters are unchanged. Those replaced or no
+	// longer in the pipeline are closed once the new pipeline has been loaded successfully.
+	sdk.batchersMutex.Lock()
+	defer sdk.batchersMutex.Unlock()
+	previousBatchers := make(map[string]*configurableBatch, len(sdk.batchers))
+	for name, batcher := range sdk.batchers {
+		previousBatchers[name] = batcher
+	}
+
+	pipeline, err := sdk.loadConfigurablePipeline()
+	if err != nil {
+		sdk.batchers = previousBatchers
+		return nil, err
+	}
+
+	sdk.closeBatchers(previousBatchers, sdk.batchers)
+	return pipeline, nil
+}
+
+func (sdk *AppFunctionsSDK) loadConfigurablePipeline() ([]appcontext.AppFunction, error) {
+	var pipeline []appcontext.AppFunction
+	batchers := make(map[string]*configurableBatch)
+	batchPositions := make(map[int]*configurableBatch)
+
 	configurable := AppFunctionsSDKConfigurable{
 		Sdk: sdk,
 	}
@@ -249,13 +285,44 @@ func (sdk *AppFunctionsSDK) LoadConfigurablePipeline() ([]appcontext.AppFunction
 		if !ok {
 			return nil, fmt.Errorf("failed to cast function %s as AppFunction type", functionName)
 		}
+		if batcher, ok := sdk.batchers[functionName]; ok {
+			batchers[functionName] = batcher
+			batchPositions[len(pipeline)] = batcher
+		}
 		pipeline = append(pipeline, function)
 		configurable.Sdk.LoggingClient.Debug(fmt.Sprintf("%s function added to configurable pipeline", functionName))
 	}
 
+	// Batched data released by the timer continues thru the remainder of the pipeline using the runtime, so data
+	// whose export fails is stored for later retry.
+	for position, batcher := range batchPositions {
+		if position+1 == len(pipeline) {
+			batcher.batch.ContinueWith()
+			continue
+		}
+		startPosition := position + 1
+		batcher.batch.ContinueWithExecutor(func(edgexcontext *appcontext.Context, data interface{}) {
+			sdk.runtime.ExecutePipeline(data, "", edgexcontext, pipeline, startPosition, false)
+		})
+	}
+	sdk.batchers = batchers
+
 	return pipeline, nil
 }
 
+// closeBatchers closes the batches created for the configurable pipeline that are not in keep,
+// passing any batched data thru the remainder of the pipeline they were part of.
+func (sdk *AppFunctionsSDK) closeBatchers(batchers map[string]*configurableBatch, keep map[string]*configurableBatch) {
+	for name, batcher := range batchers {
+		if kept, ok := keep[name]; ok && kept.batch == batcher.batch {
+			continue
+		}
+		if err := batcher.batch.Close(); err != nil {
+			sdk.LoggingClient.Error(fmt.Sprintf("Unable to close batch for %s", name), "error", err)
+		}
+	}
+}
+
 // SetFunctionsPipeline allows you to define each fgitunction to execute and the order in which each function
 // will be called as each event comes in.
 func (sdk *AppFunctionsSDK) SetFunctionsPipeline(transforms ...appcontext.AppFunction) error {
@@ -457,10 +524,65 @@ func (sdk *AppFunctionsSDK) Initialize() error {
 
 	sdk.webserver = webserver.NewWebServer(&sdk.config, sdk.secretProvider, sdk.LoggingClient, mux.NewRouter())
 	sdk.webserver.ConfigureStandardRoutes()
+	if err := sdk.setupWebSocket(); err != nil {
+		return err
+	}
+	if err := sdk.setupSSE(); err != nil {
+		return err
+	}
 
 	return nil
 }
 
+// setupWebSocket adds the WebSocket route when it is configured or used by the trigger. Routes can't be added once
+// the web server is running, so this is done on start up, before the pipeline is loaded.
+func (sdk *AppFunctionsSDK) setupWebSocket() error {
+	route := sdk.config.WebSocket.Route
+	if route == "" {
+		if !strings.EqualFold(sdk.config.Binding.Type, "websocket") {
+			return nil
+		}
+		route = internal.ApiWebSocketRoute
+	}
+
+	sdk.webSocket = transforms.NewWebSocketBroadcaster(transforms.WebSocketConfig{
+		AllowedOrigins: sdk.config.WebSocket.AllowedOrigins,
+		SendBufferSize: sdk.config.WebSocket.SendBufferSize,
+	}, sdk.LoggingClient)
+
+	sdk.LoggingClient.Info("Adding WebSocket route " + route)
+	return sdk.webserver.AddStreamingRoute(route, sdk.webSocket.ServeHTTP, nethttp.MethodGet)
+}
+
+// WebSocketBroadcaster returns the broadcaster of the WebSocket route, whose Broadcast function pushes the pipeline's
+// data to the clients connected to the route. It is nil unless the WebSocket route is configured.
+func (sdk *AppFunctionsSDK) WebSocketBroadcaster() *transforms.WebSocketBroadcaster {
+	return sdk.webSocket
+}
+
+// setupSSE adds the Server-Sent Events route, on start up since routes can't be added once the web server is running
+func (sdk *AppFunctionsSDK) setupSSE() error {
+	route := sdk.config.SSE.Route
+	if route == "" {
+		route = internal.ApiStreamRoute
+	}
+
+	sdk.sse = transforms.NewSSEBroadcaster(transforms.SSEConfig{
+		AllowedOrigins:   sdk.config.SSE.AllowedOrigins,
+		ReplayBufferSize: sdk.config.SSE.ReplayBufferSize,
+		SendBufferSize:   sdk.config.SSE.SendBufferSize,
+	}, sdk.LoggingClient)
+
+	sdk.LoggingClient.Info("Adding SSE route " + route)
+	return sdk.webserver.AddStreamingRoute(route, sdk.sse.ServeHTTP, nethttp.MethodGet)
+}
+
+// SSEBroadcaster returns the broadcaster of the Server-Sent Events route, whose Broadcast function pushes the
+// pipeline's data to the clients connected to the route
+func (sdk *AppFunctionsSDK) SSEBroadcaster() *transforms.SSEBroadcaster {
+	return sdk.sse
+}
+
 func (sdk *AppFunctionsSDK) initializeSecretProvider() error {
 
 	sdk.secretProvider = security.NewSecretProvider(sdk.LoggingClient, &sdk.config)
@@ -565,6 +687,21 @@ func (sdk *AppFunctionsSDK) setupTrigger(configuration common.ConfigurationStruc
 	case "MESSAGEBUS":
 		sdk.LoggingClient.Info("MessageBus trigger selected")
 		t = &messagebus.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients}
+	case "KAFKA":
+		sdk.LoggingClient.Info("Kafka trigger selected")
+		t = &kafka.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients, SecretProvider: sdk.secretProvider}
+	case "AMQP":
+		sdk.LoggingClient.Info("AMQP trigger selected")
+		t = &amqp.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients, SecretProvider: sdk.secretProvider}
+	case "NATS":
+		sdk.LoggingClient.Info("NATS trigger selected")
+		t = &nats.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients, SecretProvider: sdk.secretProvider}
+	case "WEBSOCKET":
+		sdk.LoggingClient.Info("WebSocket trigger selected")
+		t = &websocket.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients, Broadcaster: sdk.webSocket}
+	case "GRPC":
+		sdk.LoggingClient.Info("gRPC trigger selected")
+		t = &grpc.Trigger{Configuration: configuration, Runtime: runtime, EdgeXClients: sdk.edgexClients}
 	}
 
 	return t
@@ -33,8 +33,13 @@ import (
 	"github.com/tuanldchainos/app-functions-sdk-go/internal"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/amqp"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/grpc"
 	triggerHttp "github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/http"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/kafka"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/messagebus"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/nats"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/trigger/websocket"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/webserver"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
@@ -105,6 +110,122 @@ func TestSetupMessageBusTrigger(t *testing.T) {
 	assert.True(t, result, "Expected Instance of Message Bus Trigger")
 }
 
+func TestSetupKafkaTrigger(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "Kafka",
+			},
+		},
+	}
+	testRuntime := &runtime.GolangRuntime{}
+	testRuntime.Initialize(nil, nil)
+	testRuntime.SetTransforms(sdk.transforms)
+	trigger := sdk.setupTrigger(sdk.config, testRuntime)
+	result := IsInstanceOf(trigger, (*kafka.Trigger)(nil))
+	assert.True(t, result, "Expected Instance of Kafka Trigger")
+}
+
+func TestSetupAMQPTrigger(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "AMQP",
+			},
+		},
+	}
+	testRuntime := &runtime.GolangRuntime{}
+	testRuntime.Initialize(nil, nil)
+	testRuntime.SetTransforms(sdk.transforms)
+	trigger := sdk.setupTrigger(sdk.config, testRuntime)
+	result := IsInstanceOf(trigger, (*amqp.Trigger)(nil))
+	assert.True(t, result, "Expected Instance of AMQP Trigger")
+}
+
+func TestSetupNATSTrigger(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "nats",
+			},
+		},
+	}
+	testRuntime := &runtime.GolangRuntime{}
+	testRuntime.Initialize(nil, nil)
+	testRuntime.SetTransforms(sdk.transforms)
+	trigger := sdk.setupTrigger(sdk.config, testRuntime)
+	result := IsInstanceOf(trigger, (*nats.Trigger)(nil))
+	assert.True(t, result, "Expected Instance of NATS Trigger")
+}
+
+func TestSetupGRPCTrigger(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "grpc",
+			},
+		},
+	}
+	testRuntime := &runtime.GolangRuntime{}
+	testRuntime.Initialize(nil, nil)
+	testRuntime.SetTransforms(sdk.transforms)
+	trigger := sdk.setupTrigger(sdk.config, testRuntime)
+	result := IsInstanceOf(trigger, (*grpc.Trigger)(nil))
+	assert.True(t, result, "Expected Instance of gRPC Trigger")
+}
+
+func TestSetupWebSocketTrigger(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "WebSocket",
+			},
+		},
+	}
+	sdk.webserver = webserver.NewWebServer(&sdk.config, nil, lc, mux.NewRouter())
+	require.NoError(t, sdk.setupWebSocket())
+	require.NotNil(t, sdk.WebSocketBroadcaster(), "The WebSocket route should be added for the trigger")
+
+	testRuntime := &runtime.GolangRuntime{}
+	testRuntime.Initialize(nil, nil)
+	testRuntime.SetTransforms(sdk.transforms)
+	trigger := sdk.setupTrigger(sdk.config, testRuntime)
+	result := IsInstanceOf(trigger, (*websocket.Trigger)(nil))
+	assert.True(t, result, "Expected Instance of WebSocket Trigger")
+}
+
+func TestSetupWebSocketNotConfigured(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Binding: common.BindingInfo{
+				Type: "messagebus",
+			},
+		},
+	}
+	sdk.webserver = webserver.NewWebServer(&sdk.config, nil, lc, mux.NewRouter())
+	require.NoError(t, sdk.setupWebSocket())
+	assert.Nil(t, sdk.WebSocketBroadcaster())
+
+	sdk.config.WebSocket.Route = "/api/v1/dashboard"
+	require.NoError(t, sdk.setupWebSocket())
+	assert.NotNil(t, sdk.WebSocketBroadcaster())
+}
+
+func TestSetupSSE(t *testing.T) {
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+	}
+	sdk.webserver = webserver.NewWebServer(&sdk.config, nil, lc, mux.NewRouter())
+	require.NoError(t, sdk.setupSSE())
+	assert.NotNil(t, sdk.SSEBroadcaster())
+}
+
 func TestSetFunctionsPipelineNoTransforms(t *testing.T) {
 	sdk := AppFunctionsSDK{
 		LoggingClient: lc,
@@ -311,6 +432,58 @@ func TestLoadConfigurablePipelineNumFunctions(t *testing.T) {
 	assert.Equal(t, 3, len(appFunctions))
 }
 
+func TestLoadConfigurablePipelineRetainsBatch(t *testing.T) {
+	functions := make(map[string]common.PipelineFunction)
+	functions["BatchByTimeAndCount"] = common.PipelineFunction{
+		Parameters: map[string]string{"TimeInterval": "60s", "BatchThreshold": "3"},
+	}
+	functions["SetOutputData"] = common.PipelineFunction{}
+
+	sdk := AppFunctionsSDK{
+		LoggingClient: lc,
+		config: common.ConfigurationStruct{
+			Writable: common.WritableInfo{
+				Pipeline: common.PipelineInfo{
+					ExecutionOrder: "BatchByTimeAndCount, SetOutputData",
+					Functions:      functions,
+				},
+			},
+		},
+	}
+
+	_, err := sdk.LoadConfigurablePipeline()
+	require.NoError(t, err)
+	require.Contains(t, sdk.batchers, "BatchByTimeAndCount")
+	original := sdk.batchers["BatchByTimeAndCount"].batch
+
+	// Reload with unchanged parameters keeps the batch
+	_, err = sdk.LoadConfigurablePipeline()
+	require.NoError(t, err)
+	assert.Same(t, original, sdk.batchers["BatchByTimeAndCount"].batch, "batch should survive reload")
+
+	// Failed reload keeps the batch
+	sdk.config.Writable.Pipeline.ExecutionOrder = "BatchByTimeAndCount, SetOutputData, Missing"
+	_, err = sdk.LoadConfigurablePipeline()
+	require.Error(t, err)
+	assert.Same(t, original, sdk.batchers["BatchByTimeAndCount"].batch, "batch should survive failed reload")
+
+	// Reload with changed parameters replaces the batch and closes the original
+	sdk.config.Writable.Pipeline.ExecutionOrder = "BatchByTimeAndCount, SetOutputData"
+	functions["BatchByTimeAndCount"].Parameters["BatchThreshold"] = "5"
+	_, err = sdk.LoadConfigurablePipeline()
+	require.NoError(t, err)
+	assert.False(t, original == sdk.batchers["BatchByTimeAndCount"].batch, "batch should be replaced")
+	continuePipeline, result := original.Batch(&appcontext.Context{LoggingClient: lc}, "data")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "batch has been closed")
+
+	// Removing the function from the pipeline closes the batch
+	sdk.config.Writable.Pipeline.ExecutionOrder = "SetOutputData"
+	_, err = sdk.LoadConfigurablePipeline()
+	require.NoError(t, err)
+	assert.Empty(t, sdk.batchers)
+}
+
 func TestUseTargetTypeOfByteArrayTrue(t *testing.T) {
 	functions := make(map[string]common.PipelineFunction)
 	functions["CompressWithGZIP"] = common.PipelineFunction{}
@@ -5,26 +5,38 @@ go 1.13
 require (
 	bitbucket.org/bertimus9/systemstat v0.0.0-20180207000608-0eeff89b0690
 	github.com/BurntSushi/toml v0.3.1
+	github.com/PaesslerAG/gval v1.0.0 // indirect
+	github.com/PaesslerAG/jsonpath v0.1.1
+	github.com/andybalholm/brotli v1.0.2
 	github.com/diegoholiveira/jsonlogic v1.0.1-0.20200220175622-ab7989be08b9
+	github.com/eclipse/paho.golang v0.10.0
 	github.com/eclipse/paho.mqtt.golang v1.2.0
 	github.com/edgexfoundry/go-mod-core-contracts v0.1.57
 	github.com/edgexfoundry/go-mod-messaging v0.1.16
 	github.com/edgexfoundry/go-mod-registry v0.1.11
 	github.com/edgexfoundry/go-mod-secrets v0.0.17
-	github.com/golang/snappy v0.0.1 // indirect
+	github.com/golang/snappy v0.0.4
 	github.com/gomodule/redigo v2.0.0+incompatible
-	github.com/google/go-cmp v0.3.1 // indirect
-	github.com/google/uuid v1.1.0
+	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.7.2
+	github.com/gorilla/websocket v1.4.2
+	github.com/jmespath/go-jmespath v0.3.0
+	github.com/klauspost/compress v1.9.8
 	github.com/kr/pretty v0.2.0 // indirect
+	github.com/linkedin/goavro/v2 v2.9.8
+	github.com/nats-io/nats.go v1.11.0
 	github.com/pelletier/go-toml v1.2.0
+	github.com/pierrec/lz4 v2.0.5+incompatible
+	github.com/segmentio/kafka-go v0.4.10
+	github.com/streadway/amqp v1.0.0
 	github.com/stretchr/objx v0.2.0 // indirect
-	github.com/stretchr/testify v1.4.0
+	github.com/stretchr/testify v1.7.0
 	github.com/tidwall/pretty v1.0.0 // indirect
 	github.com/ugorji/go v1.1.4
 	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c // indirect
 	github.com/xdg/stringprep v1.0.0 // indirect
 	go.mongodb.org/mongo-driver v1.1.1
-	golang.org/x/text v0.3.2 // indirect
+	google.golang.org/grpc v1.43.0
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 )
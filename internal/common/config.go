@@ -68,6 +68,18 @@ type ConfigurationStruct struct {
 	MessageBus types.MessageBusConfig
 	// Binding
 	Binding BindingInfo
+	// Kafka
+	Kafka KafkaInfo
+	// AMQP
+	AMQP AMQPInfo
+	// NATS
+	NATS NATSInfo
+	// WebSocket
+	WebSocket WebSocketInfo
+	// SSE
+	SSE SSEInfo
+	// GRPC
+	GRPC GRPCInfo
 	// ApplicationSettings
 	ApplicationSettings map[string]string
 	// Clients
@@ -112,12 +124,125 @@ type BindingInfo struct {
 	//
 	// example: messagebus
 	// required: true
-	// enum: messagebus,http
+	// enum: messagebus,http,kafka,amqp,nats,websocket,grpc
 	Type           string
 	SubscribeTopic string
 	PublishTopic   string
 }
 
+// KafkaInfo contains the settings of the Kafka trigger, which consumes the Binding's SubscribeTopic and publishes the
+// output data to its PublishTopic
+type KafkaInfo struct {
+	// Brokers are the host:port addresses used to discover the Kafka cluster
+	Brokers []string
+	// GroupID is the consumer group, whose offsets are committed after each message is processed successfully
+	GroupID string
+	// StartOffset is where a new consumer group starts consuming the topic, first or last, last when not set
+	StartOffset string
+	ClientID    string
+	// SASLMechanism is plain, scram-sha-256 or scram-sha-512, none when not set. The username and password are
+	// retrieved from the SecretPath in the secret store.
+	SASLMechanism string
+	SecretPath    string
+	// UseTLS connects to the brokers using TLS, with the optional client certificate and CA certificate
+	UseTLS         bool
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+}
+
+// AMQPInfo contains the settings of the AMQP trigger, which consumes the queue named by the Binding's SubscribeTopic
+// and publishes the output data to the PublishExchange using the Binding's PublishTopic as the routing key
+type AMQPInfo struct {
+	// URL is the amqp:// or amqps:// URL of the broker, including the virtual host
+	URL string
+	// SecretPath is the path in the secret store holding the username and password, which override those in the
+	// URL, when set
+	SecretPath string
+	// PrefetchCount is the number of messages processed at the same time, 10 when not set
+	PrefetchCount int
+	// RequeueOnError returns the messages the pipeline fails to process to the queue, otherwise they are discarded or
+	// dead-lettered. Messages that can't be decoded are never requeued.
+	RequeueOnError  bool
+	PublishExchange string
+	// ClientCertFile, ClientKeyFile and CACertFile are the certificates used with amqps:// URLs
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+}
+
+// NATSInfo contains the settings of the NATS trigger, which subscribes to the Binding's SubscribeTopic, which can hold
+// wildcards, and publishes the output data to the reply subject of requests or to the Binding's PublishTopic
+type NATSInfo struct {
+	// URL is the nats:// URL of the server, or a comma separated list of the URLs of a cluster
+	URL string
+	// QueueGroup spreads the messages between the subscribers in the same group, all subscribers get every message
+	// when not set
+	QueueGroup string
+	// JetStream consumes the stream holding the subject with a durable consumer, acknowledging each message once the
+	// pipeline has processed it successfully
+	JetStream bool
+	// Durable is the name of the JetStream consumer, which keeps its position across restarts
+	Durable string
+	// DeliverPolicy is where a new JetStream consumer starts consuming, all, last or new, all when not set
+	DeliverPolicy string
+	// MaxDeliver is the number of times JetStream delivers a message the pipeline fails to process, unlimited when
+	// not set
+	MaxDeliver int
+	// SecretPath is the path in the secret store holding the username and password, or the token, when set
+	SecretPath string
+	// CredentialsFile is the NATS credentials file holding the user JWT and NKey seed, when set
+	CredentialsFile string
+	// UseTLS connects to the server using TLS, with the optional client certificate and CA certificate
+	UseTLS         bool
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+}
+
+// WebSocketInfo contains the settings of the WebSocket route of the web server, which the WebSocket trigger receives
+// messages from and the WebSocketBroadcast function pushes data to
+type WebSocketInfo struct {
+	// Route is the path of the WebSocket route, /api/v1/ws when not set. The route is only added when it is set or the
+	// Binding's Type is websocket.
+	Route string
+	// AllowedOrigins are the origins of the pages browsers can connect from, in addition to the service's own. All
+	// origins are allowed when it holds "*".
+	AllowedOrigins []string
+	// SendBufferSize is the number of messages queued for each client, 16 when not set
+	SendBufferSize int
+}
+
+// SSEInfo contains the settings of the Server-Sent Events route of the web server, which the SSEBroadcast function
+// pushes data to
+type SSEInfo struct {
+	// Route is the path of the SSE route, /api/v1/stream when not set
+	Route string
+	// AllowedOrigins are the origins of the pages browsers can connect from, in addition to the service's own. All
+	// origins are allowed when it holds "*".
+	AllowedOrigins []string
+	// ReplayBufferSize is the number of events kept to be replayed to the clients reconnecting, 50 when not set
+	ReplayBufferSize int
+	// SendBufferSize is the number of events queued for each client, 16 when not set
+	SendBufferSize int
+}
+
+// GRPCInfo contains the settings of the gRPC server the gRPC trigger receives messages from
+type GRPCInfo struct {
+	// Port is the port the server listens on, 50051 when not set
+	Port int
+	// MaxMessageSize is the largest message accepted in bytes, 4MB when not set
+	MaxMessageSize int
+	// ServerCertFile and ServerKeyFile are the certificate and key of the server, which uses TLS when they are set
+	ServerCertFile string
+	ServerKeyFile  string
+	// ClientCACertFile is the CA certificate the certificates the clients must present are verified with, when set
+	ClientCACertFile string
+}
+
 type PipelineInfo struct {
 	ExecutionOrder           string
 	UseTargetTypeOfByteArray bool
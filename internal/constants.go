@@ -29,6 +29,8 @@ const (
 	ConfigRegistryStem   = "edgex/appservices/1.0/"
 	WritableKey          = "/Writable"
 	ApiTriggerRoute      = "/api/v1/trigger"
+	ApiWebSocketRoute    = "/api/v1/ws"
+	ApiStreamRoute       = "/api/v1/stream"
 	DatabaseName         = "application-service"
 )
 
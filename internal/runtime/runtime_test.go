@@ -416,11 +416,14 @@ func TestExecutePipelinePersist(t *testing.T) {
 	}
 
 	ctx := appcontext.Context{
-		Configuration: config,
-		LoggingClient: lc,
-		CorrelationID: "CorrelationID",
-		EventChecksum: "EventChecksum",
-		EventID:       "EventID",
+		Configuration:   config,
+		LoggingClient:   lc,
+		CorrelationID:   "CorrelationID",
+		EventChecksum:   "EventChecksum",
+		EventID:         "EventID",
+		ContentEncoding: "gzip",
+		ExportHeaders:   map[string]string{"X-Signature": "c2lnbmVk"},
+		ExportTopics:    map[string]string{"site1/{device}": "site1/thermostat"},
 	}
 
 	transformPassthru := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
@@ -445,4 +448,7 @@ func TestExecutePipelinePersist(t *testing.T) {
 	assert.Equal(t, ctx.CorrelationID, storedObjects[0].CorrelationID, "CorrelationID not as expected")
 	assert.Equal(t, ctx.EventID, storedObjects[0].EventID, "EventID not as expected")
 	assert.Equal(t, ctx.EventChecksum, storedObjects[0].EventChecksum, "EventChecksum not as expected")
+	assert.Equal(t, ctx.ContentEncoding, storedObjects[0].ContentEncoding, "ContentEncoding not as expected")
+	assert.Equal(t, ctx.ExportHeaders, storedObjects[0].ExportHeaders, "ExportHeaders not as expected")
+	assert.Equal(t, ctx.ExportTopics, storedObjects[0].ExportTopics, "ExportTopics not as expected")
 }
@@ -109,6 +109,9 @@ func (sf *storeForwardInfo) storeForLaterRetry(payload []byte,
 	item.CorrelationID = edgexcontext.CorrelationID
 	item.EventID = edgexcontext.EventID
 	item.EventChecksum = edgexcontext.EventChecksum
+	item.ContentEncoding = edgexcontext.ContentEncoding
+	item.ExportHeaders = edgexcontext.ExportHeaders
+	item.ExportTopics = edgexcontext.ExportTopics
 
 	edgexcontext.LoggingClient.Trace("Storing data for later retry",
 		clients.CorrelationHeader, edgexcontext.CorrelationID)
@@ -226,6 +229,9 @@ func (sf *storeForwardInfo) retryExportFunction(item contracts.StoredObject, con
 		CorrelationID:         item.CorrelationID,
 		EventChecksum:         item.EventChecksum,
 		EventID:               item.EventID,
+		ContentEncoding:       item.ContentEncoding,
+		ExportHeaders:         item.ExportHeaders,
+		ExportTopics:          item.ExportTopics,
 		Configuration:         *config,
 		LoggingClient:         edgeXClients.LoggingClient,
 		EventClient:           edgeXClients.EventClient,
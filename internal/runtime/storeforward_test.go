@@ -57,6 +57,9 @@ func TestProcessRetryItems(t *testing.T) {
 
 		require.True(t, ok, "Expected []byte payload")
 		require.Equal(t, expectedPayload, string(actualPayload))
+		require.Equal(t, "gzip", edgexcontext.ContentEncoding, "Expected ContentEncoding restored from stored item")
+		require.Equal(t, "c2lnbmVk", edgexcontext.ExportHeaders["X-Signature"], "Expected ExportHeaders restored from stored item")
+		require.Equal(t, "site1/thermostat", edgexcontext.ExportTopics["site1/{device}"], "Expected ExportTopics restored from stored item")
 
 		return false, nil
 	}
@@ -97,6 +100,9 @@ func TestProcessRetryItems(t *testing.T) {
 			}
 			storedObject := contracts.NewStoredObject("dummy", []byte(test.ExpectedPayload), 2, version)
 			storedObject.RetryCount = test.RetryCount
+			storedObject.ContentEncoding = "gzip"
+			storedObject.ExportHeaders = map[string]string{"X-Signature": "c2lnbmVk"}
+			storedObject.ExportTopics = map[string]string{"site1/{device}": "site1/thermostat"}
 
 			removes, updates := runtime.storeForward.processRetryItems([]contracts.StoredObject{storedObject}, &config, common.EdgeXClients{LoggingClient: lc})
 			assert.Equal(t, test.TargetTransformWasCalled, targetTransformWasCalled, "Target transform not called")
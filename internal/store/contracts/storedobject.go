@@ -49,6 +49,15 @@ type StoredObject struct {
 
 	// EventChecksum is used to identify CBOR encoded data from the core services and mark it as pushed.
 	EventChecksum string
+
+	// ContentEncoding is the encoding, such as gzip, applied to the payload by a previous function in the pipeline.
+	ContentEncoding string
+
+	// ExportHeaders holds headers, such as a signature, added by a previous function in the pipeline.
+	ExportHeaders map[string]string
+
+	// ExportTopics holds the topics, keys and subjects resolved by the export function, keyed by their configured value.
+	ExportTopics map[string]string
 }
 
 // NewStoredObject creates a new instance of StoredObject and is the preferred way to create one.
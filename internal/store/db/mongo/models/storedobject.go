@@ -55,6 +55,15 @@ type StoredObject struct {
 
 	// EventChecksum is used to identify CBOR encoded data from the core services and mark it as pushed.
 	EventChecksum string `bson:"eventChecksum"`
+
+	// ContentEncoding is the encoding, such as gzip, applied to the payload by a previous function in the pipeline.
+	ContentEncoding string `bson:"contentEncoding"`
+
+	// ExportHeaders holds headers, such as a signature, added by a previous function in the pipeline.
+	ExportHeaders map[string]string `bson:"exportHeaders"`
+
+	// ExportTopics holds the topics, keys and subjects resolved by the export function, keyed by their configured value.
+	ExportTopics map[string]string `bson:"exportTopics"`
 }
 
 // FromContract builds a model object out of the supplied contract.
@@ -73,6 +82,9 @@ func (o *StoredObject) FromContract(c contracts.StoredObject) error {
 	o.CorrelationID = c.CorrelationID
 	o.EventID = c.EventID
 	o.EventChecksum = c.EventChecksum
+	o.ContentEncoding = c.ContentEncoding
+	o.ExportHeaders = c.ExportHeaders
+	o.ExportTopics = c.ExportTopics
 
 	return nil
 }
@@ -90,6 +102,9 @@ func (o StoredObject) ToContract() contracts.StoredObject {
 	contract.CorrelationID = o.CorrelationID
 	contract.EventID = o.EventID
 	contract.EventChecksum = o.EventChecksum
+	contract.ContentEncoding = o.ContentEncoding
+	contract.ExportHeaders = o.ExportHeaders
+	contract.ExportTopics = o.ExportTopics
 
 	return contract
 }
@@ -41,8 +41,12 @@ const (
 	TestCorrelationID    = "test"
 	TestEventID          = "probably"
 	TestEventChecksum    = "failed :("
+	TestContentEncoding  = "gzip"
 )
 
+var TestExportHeaders = map[string]string{"X-Signature": "c2lnbmVk"}
+var TestExportTopics = map[string]string{"site1/{device}": "site1/thermostat"}
+
 var TestModelNoID = StoredObject{
 	AppServiceKey:    TestAppServiceKey,
 	Payload:          TestPayload,
@@ -52,6 +56,9 @@ var TestModelNoID = StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestModelUUID = StoredObject{
@@ -65,6 +72,9 @@ var TestModelUUID = StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestContractUUID = contracts.StoredObject{
@@ -77,6 +87,9 @@ var TestContractUUID = contracts.StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestContractBadID = contracts.StoredObject{
@@ -89,6 +102,9 @@ var TestContractBadID = contracts.StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestContractNilID = contracts.StoredObject{
@@ -101,6 +117,9 @@ var TestContractNilID = contracts.StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 func TestFromContract(t *testing.T) {
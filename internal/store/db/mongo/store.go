@@ -83,6 +83,9 @@ func (c Client) Store(o contracts.StoredObject) (string, error) {
 		"correlationID":    o.CorrelationID,
 		"eventID":          o.EventID,
 		"eventChecksum":    o.EventChecksum,
+		"contentEncoding":  o.ContentEncoding,
+		"exportHeaders":    o.ExportHeaders,
+		"exportTopics":     o.ExportTopics,
 	}
 
 	_, err = c.Client.Collection(mongoCollection).InsertOne(ctx, doc)
@@ -160,6 +163,9 @@ func (c Client) Update(o contracts.StoredObject) error {
 		"correlationID":    o.CorrelationID,
 		"eventID":          o.EventID,
 		"eventChecksum":    o.EventChecksum,
+		"contentEncoding":  o.ContentEncoding,
+		"exportHeaders":    o.ExportHeaders,
+		"exportTopics":     o.ExportTopics,
 	}}
 
 	_, err = c.Client.Collection(mongoCollection).UpdateOne(ctx, filter, update)
@@ -49,6 +49,15 @@ type StoredObject struct {
 
 	// EventChecksum is used to identify CBOR encoded data from the core services and mark it as pushed.
 	EventChecksum string `json:"eventChecksum"`
+
+	// ContentEncoding is the encoding, such as gzip, applied to the payload by a previous function in the pipeline.
+	ContentEncoding string `json:"contentEncoding"`
+
+	// ExportHeaders holds headers, such as a signature, added by a previous function in the pipeline.
+	ExportHeaders map[string]string `json:"exportHeaders"`
+
+	// ExportTopics holds the topics, keys and subjects resolved by the export function, keyed by their configured value.
+	ExportTopics map[string]string `json:"exportTopics"`
 }
 
 // ToContract builds a contract out of the supplied model.
@@ -63,6 +72,9 @@ func (o StoredObject) ToContract() contracts.StoredObject {
 		CorrelationID:    o.CorrelationID,
 		EventID:          o.EventID,
 		EventChecksum:    o.EventChecksum,
+		ContentEncoding:  o.ContentEncoding,
+		ExportHeaders:    o.ExportHeaders,
+		ExportTopics:     o.ExportTopics,
 	}
 }
 
@@ -77,24 +89,32 @@ func (o *StoredObject) FromContract(c contracts.StoredObject) {
 	o.CorrelationID = c.CorrelationID
 	o.EventID = c.EventID
 	o.EventChecksum = c.EventChecksum
+	o.ContentEncoding = c.ContentEncoding
+	o.ExportHeaders = c.ExportHeaders
+	o.ExportTopics = c.ExportTopics
 }
 
 // MarshalJSON returns the object as a JSON encoded byte array.
 func (o StoredObject) MarshalJSON() ([]byte, error) {
 	test := struct {
-		ID               *string `json:"id,omitempty"`
-		AppServiceKey    *string `json:"appServiceKey,omitempty"`
-		Payload          []byte  `json:"payload,omitempty"`
-		RetryCount       int     `json:"retryCount,omitempty"`
-		PipelinePosition int     `json:"pipelinePosition,omitempty"`
-		Version          *string `json:"version,omitempty"`
-		CorrelationID    *string `json:"correlationID,omitempty"`
-		EventID          *string `json:"eventID,omitempty"`
-		EventChecksum    *string `json:"eventChecksum,omitempty"`
+		ID               *string           `json:"id,omitempty"`
+		AppServiceKey    *string           `json:"appServiceKey,omitempty"`
+		Payload          []byte            `json:"payload,omitempty"`
+		RetryCount       int               `json:"retryCount,omitempty"`
+		PipelinePosition int               `json:"pipelinePosition,omitempty"`
+		Version          *string           `json:"version,omitempty"`
+		CorrelationID    *string           `json:"correlationID,omitempty"`
+		EventID          *string           `json:"eventID,omitempty"`
+		EventChecksum    *string           `json:"eventChecksum,omitempty"`
+		ContentEncoding  *string           `json:"contentEncoding,omitempty"`
+		ExportHeaders    map[string]string `json:"exportHeaders,omitempty"`
+		ExportTopics     map[string]string `json:"exportTopics,omitempty"`
 	}{
 		Payload:          o.Payload,
 		RetryCount:       o.RetryCount,
 		PipelinePosition: o.PipelinePosition,
+		ExportHeaders:    o.ExportHeaders,
+		ExportTopics:     o.ExportTopics,
 	}
 
 	// Empty strings are null
@@ -116,6 +136,9 @@ func (o StoredObject) MarshalJSON() ([]byte, error) {
 	if o.EventChecksum != "" {
 		test.EventChecksum = &o.EventChecksum
 	}
+	if o.ContentEncoding != "" {
+		test.ContentEncoding = &o.ContentEncoding
+	}
 
 	return json.Marshal(test)
 }
@@ -123,15 +146,18 @@ func (o StoredObject) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON returns an object from JSON.
 func (o *StoredObject) UnmarshalJSON(data []byte) error {
 	alias := new(struct {
-		ID               *string `json:"id"`
-		AppServiceKey    *string `json:"appServiceKey"`
-		Payload          []byte  `json:"payload"`
-		RetryCount       int     `json:"retryCount"`
-		PipelinePosition int     `json:"pipelinePosition"`
-		Version          *string `json:"version"`
-		CorrelationID    *string `json:"correlationID"`
-		EventID          *string `json:"eventID"`
-		EventChecksum    *string `json:"eventChecksum"`
+		ID               *string           `json:"id"`
+		AppServiceKey    *string           `json:"appServiceKey"`
+		Payload          []byte            `json:"payload"`
+		RetryCount       int               `json:"retryCount"`
+		PipelinePosition int               `json:"pipelinePosition"`
+		Version          *string           `json:"version"`
+		CorrelationID    *string           `json:"correlationID"`
+		EventID          *string           `json:"eventID"`
+		EventChecksum    *string           `json:"eventChecksum"`
+		ContentEncoding  *string           `json:"contentEncoding"`
+		ExportHeaders    map[string]string `json:"exportHeaders"`
+		ExportTopics     map[string]string `json:"exportTopics"`
 	})
 
 	// Error with unmarshaling
@@ -158,10 +184,15 @@ func (o *StoredObject) UnmarshalJSON(data []byte) error {
 	if alias.EventChecksum != nil {
 		o.EventChecksum = *alias.EventChecksum
 	}
+	if alias.ContentEncoding != nil {
+		o.ContentEncoding = *alias.ContentEncoding
+	}
 
 	o.Payload = alias.Payload
 	o.RetryCount = alias.RetryCount
 	o.PipelinePosition = alias.PipelinePosition
+	o.ExportHeaders = alias.ExportHeaders
+	o.ExportTopics = alias.ExportTopics
 
 	return nil
 }
@@ -33,8 +33,12 @@ const (
 	TestCorrelationID    = "test"
 	TestEventID          = "probably"
 	TestEventChecksum    = "failed :("
+	TestContentEncoding  = "gzip"
 )
 
+var TestExportHeaders = map[string]string{"X-Signature": "c2lnbmVk"}
+var TestExportTopics = map[string]string{"site1/{device}": "site1/thermostat"}
+
 var TestContractValid = contracts.StoredObject{
 	ID:               TestUUIDValid,
 	AppServiceKey:    TestAppServiceKey,
@@ -45,6 +49,9 @@ var TestContractValid = contracts.StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestModelValid = StoredObject{
@@ -57,6 +64,9 @@ var TestModelValid = StoredObject{
 	CorrelationID:    TestCorrelationID,
 	EventID:          TestEventID,
 	EventChecksum:    TestEventChecksum,
+	ContentEncoding:  TestContentEncoding,
+	ExportHeaders:    TestExportHeaders,
+	ExportTopics:     TestExportTopics,
 }
 
 var TestModelEmpty = StoredObject{}
@@ -121,7 +131,7 @@ func TestStoredObject_MarshalJSON(t *testing.T) {
 			"Successful marshalling",
 			TestModelValid,
 			false,
-			`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":"YnJhbmRvbiB3cm90ZSB0aGlz","retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :("}`,
+			`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":"YnJhbmRvbiB3cm90ZSB0aGlz","retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :(","contentEncoding":"gzip","exportHeaders":{"X-Signature":"c2lnbmVk"},"exportTopics":{"site1/{device}":"site1/thermostat"}}`,
 		},
 		{
 			"Successful, empty",
@@ -158,7 +168,7 @@ func TestStoredObject_UnmarshalJSON(t *testing.T) {
 		{
 			"Valid",
 			TestModelValid,
-			args{[]byte(`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":[98,114,97,110,100,111,110,32,119,114,111,116,101,32,116,104,105,115],"retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :("}`)},
+			args{[]byte(`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":[98,114,97,110,100,111,110,32,119,114,111,116,101,32,116,104,105,115],"retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :(","contentEncoding":"gzip","exportHeaders":{"X-Signature":"c2lnbmVk"},"exportTopics":{"site1/{device}":"site1/thermostat"}}`)},
 			false,
 		},
 		{
@@ -0,0 +1,318 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-messaging/pkg/types"
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	defaultPrefetchCount = 10
+	reconnectInterval    = 5 * time.Second
+)
+
+// session is a connection to the broker consuming the queue and publishing the output data
+type session interface {
+	deliveries() <-chan amqp.Delivery
+	publish(exchange string, key string, message amqp.Publishing) error
+	close() error
+}
+
+// Trigger implements Trigger to support consuming from an AMQP 0.9.1 broker, such as RabbitMQ. Each message is
+// acknowledged once the pipeline has processed it successfully and rejected otherwise.
+type Trigger struct {
+	Configuration  common.ConfigurationStruct
+	Runtime        *runtime.GolangRuntime
+	EdgeXClients   common.EdgeXClients
+	SecretProvider *security.SecretProvider
+	// connect opens a new session, it is replaced by the tests
+	connect func() (session, error)
+}
+
+// Initialize connects to the broker and starts consuming the queue, reconnecting when the connection is lost
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) error {
+	logger := trigger.EdgeXClients.LoggingClient
+	config := trigger.Configuration.AMQP
+	binding := trigger.Configuration.Binding
+
+	logger.Info(fmt.Sprintf("Initializing AMQP Trigger. Consuming queue: %s, Publish Exchange: %s, Publish Topic: %s", binding.SubscribeTopic, config.PublishExchange, binding.PublishTopic))
+
+	if binding.SubscribeTopic == "" {
+		return errors.New("the AMQP queue must be set as the subscribe topic")
+	}
+
+	if trigger.connect == nil {
+		dialConfig, err := trigger.dialConfig()
+		if err != nil {
+			return err
+		}
+		trigger.connect = func() (session, error) {
+			return trigger.dial(dialConfig)
+		}
+	}
+
+	current, err := trigger.connect()
+	if err != nil {
+		return err
+	}
+
+	appWg.Add(1)
+
+	go func() {
+		defer appWg.Done()
+
+		for {
+			trigger.consume(appCtx, current)
+			if appCtx.Err() != nil {
+				return
+			}
+
+			logger.Error("AMQP connection lost, reconnecting")
+			for current = nil; current == nil; {
+				select {
+				case <-appCtx.Done():
+					return
+				case <-time.After(reconnectInterval):
+				}
+
+				if current, err = trigger.connect(); err != nil {
+					logger.Error(fmt.Sprintf("Failed to reconnect to AMQP broker, %v", err))
+				}
+			}
+			logger.Info("Reconnected to AMQP broker")
+		}
+	}()
+
+	return nil
+}
+
+// consume processes the deliveries until the session is closed or the context is done
+func (trigger *Trigger) consume(appCtx context.Context, current session) {
+	defer current.close()
+
+	processing := &sync.WaitGroup{}
+	defer processing.Wait()
+
+	for {
+		select {
+		case <-appCtx.Done():
+			return
+
+		case delivery, ok := <-current.deliveries():
+			if !ok {
+				return
+			}
+
+			processing.Add(1)
+			go func() {
+				defer processing.Done()
+				trigger.processMessage(current, delivery)
+			}()
+		}
+	}
+}
+
+func (trigger *Trigger) processMessage(current session, delivery amqp.Delivery) {
+	logger := trigger.EdgeXClients.LoggingClient
+	config := trigger.Configuration.AMQP
+
+	correlationID := delivery.CorrelationId
+	if value, ok := delivery.Headers[clients.CorrelationHeader].(string); ok && correlationID == "" {
+		correlationID = value
+	}
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+	contentType := delivery.ContentType
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+
+	logger.Trace("Received message from AMQP", "queue", trigger.Configuration.Binding.SubscribeTopic, clients.CorrelationHeader, correlationID)
+
+	edgexContext := &appcontext.Context{
+		CorrelationID:         correlationID,
+		Configuration:         trigger.Configuration,
+		LoggingClient:         trigger.EdgeXClients.LoggingClient,
+		EventClient:           trigger.EdgeXClients.EventClient,
+		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
+		CommandClient:         trigger.EdgeXClients.CommandClient,
+		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+		ReceivedHeaders:       receivedHeaders(delivery.Headers),
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		Payload:       delivery.Body,
+		ContentType:   contentType,
+	}
+
+	messageError := trigger.Runtime.ProcessMessage(edgexContext, envelope)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		if edgexContext.RetryData != nil && trigger.Configuration.Writable.StoreAndForward.Enabled {
+			// Redelivering the message would export the data again, on top of the stored retry
+			logger.Warn("AMQP Message stored for later retry", clients.CorrelationHeader, correlationID)
+			if err := delivery.Ack(false); err != nil {
+				logger.Error(fmt.Sprintf("Failed to acknowledge AMQP Message, %v", err), clients.CorrelationHeader, correlationID)
+			}
+			return
+		}
+		requeue := config.RequeueOnError && messageError.ErrorCode != http.StatusBadRequest
+		if err := delivery.Nack(false, requeue); err != nil {
+			logger.Error(fmt.Sprintf("Failed to reject AMQP Message, %v", err), clients.CorrelationHeader, correlationID)
+		}
+		return
+	}
+
+	if edgexContext.OutputData != nil && trigger.Configuration.Binding.PublishTopic != "" {
+		output := amqp.Publishing{
+			ContentType:   clients.ContentTypeJSON,
+			CorrelationId: correlationID,
+			Timestamp:     time.Now(),
+			Body:          edgexContext.OutputData,
+		}
+		if err := current.publish(config.PublishExchange, trigger.Configuration.Binding.PublishTopic, output); err != nil {
+			logger.Error(fmt.Sprintf("Failed to publish Message to AMQP broker, %v", err), clients.CorrelationHeader, correlationID)
+			if err := delivery.Nack(false, config.RequeueOnError); err != nil {
+				logger.Error(fmt.Sprintf("Failed to reject AMQP Message, %v", err), clients.CorrelationHeader, correlationID)
+			}
+			return
+		}
+
+		logger.Trace("Published message to AMQP broker", "routing key", trigger.Configuration.Binding.PublishTopic, clients.CorrelationHeader, correlationID)
+	}
+
+	if err := delivery.Ack(false); err != nil {
+		logger.Error(fmt.Sprintf("Failed to acknowledge AMQP Message, %v", err), clients.CorrelationHeader, correlationID)
+	}
+}
+
+// dialConfig returns the connection settings, with the credentials from the secret store when the SecretPath is set
+func (trigger *Trigger) dialConfig() (amqp.Config, error) {
+	config := trigger.Configuration.AMQP
+	dialConfig := amqp.Config{Heartbeat: 10 * time.Second, Locale: "en_US"}
+
+	uri, err := amqp.ParseURI(config.URL)
+	if err != nil {
+		return dialConfig, fmt.Errorf("invalid AMQP URL: %s", err.Error())
+	}
+
+	if uri.Scheme == "amqps" {
+		dialConfig.TLSClientConfig, err = util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+		if err != nil {
+			return dialConfig, err
+		}
+	}
+
+	if config.SecretPath != "" {
+		if trigger.SecretProvider == nil {
+			return dialConfig, errors.New("the secret provider is not available")
+		}
+		secrets, err := trigger.SecretProvider.GetSecrets(config.SecretPath, "username", "password")
+		if err != nil {
+			return dialConfig, fmt.Errorf("unable to get AMQP credentials from secret path '%s': %s", config.SecretPath, err.Error())
+		}
+		dialConfig.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: secrets["username"], Password: secrets["password"]}}
+	}
+
+	return dialConfig, nil
+}
+
+// amqpSession consumes the queue on one channel and publishes the output data on another, since publishing from the
+// consuming channel could block the deliveries
+type amqpSession struct {
+	connection *amqp.Connection
+	publisher  *amqp.Channel
+	messages   <-chan amqp.Delivery
+}
+
+func (trigger *Trigger) dial(dialConfig amqp.Config) (session, error) {
+	config := trigger.Configuration.AMQP
+	if dialConfig.TLSClientConfig != nil {
+		// The server name is set on the TLS configuration when dialing, so each connection gets its own copy
+		dialConfig.TLSClientConfig = dialConfig.TLSClientConfig.Clone()
+	}
+
+	connection, err := amqp.DialConfig(config.URL, dialConfig)
+	if err != nil {
+		return nil, err
+	}
+	current := &amqpSession{connection: connection}
+
+	consumer, err := connection.Channel()
+	if err == nil {
+		prefetchCount := config.PrefetchCount
+		if prefetchCount <= 0 {
+			prefetchCount = defaultPrefetchCount
+		}
+		err = consumer.Qos(prefetchCount, 0, false)
+	}
+	if err == nil {
+		current.messages, err = consumer.Consume(trigger.Configuration.Binding.SubscribeTopic, "", false, false, false, false, nil)
+	}
+	if err == nil {
+		current.publisher, err = connection.Channel()
+	}
+	if err != nil {
+		connection.Close()
+		return nil, err
+	}
+
+	return current, nil
+}
+
+func (current *amqpSession) deliveries() <-chan amqp.Delivery {
+	return current.messages
+}
+
+func (current *amqpSession) publish(exchange string, key string, message amqp.Publishing) error {
+	return current.publisher.Publish(exchange, key, false, false, message)
+}
+
+func (current *amqpSession) close() error {
+	return current.connection.Close()
+}
+
+// receivedHeaders returns the message headers holding a string, such as those sent by AMQPSend
+func receivedHeaders(table amqp.Table) map[string]string {
+	headers := make(map[string]string, len(table))
+	for name, value := range table {
+		switch typed := value.(type) {
+		case string:
+			headers[name] = typed
+		case []byte:
+			headers[name] = string(typed)
+		}
+	}
+	return headers
+}
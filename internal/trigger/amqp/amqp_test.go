@@ -0,0 +1,340 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package amqp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/store/db/interfaces/mocks"
+)
+
+var logClient logger.LoggingClient
+
+func init() {
+	logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+const eventPayload = `{"id":"5888dea1bd36573f4681d6f9","device":"livingroomthermostat","readings":[{"name":"temperature","value":"38","device":"livingroomthermostat"}]}`
+
+// fakeAcknowledger records how the delivery was settled
+type fakeAcknowledger struct {
+	acked   chan bool
+	requeue bool
+}
+
+func (acknowledger *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	acknowledger.acked <- true
+	return nil
+}
+
+func (acknowledger *fakeAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	acknowledger.requeue = requeue
+	acknowledger.acked <- false
+	return nil
+}
+
+func (acknowledger *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return acknowledger.Nack(tag, false, requeue)
+}
+
+type fakeSession struct {
+	messages   chan amqp.Delivery
+	published  chan amqp.Publishing
+	publishErr error
+	exchange   string
+	key        string
+	mutex      sync.Mutex
+	closed     bool
+}
+
+func (current *fakeSession) deliveries() <-chan amqp.Delivery {
+	return current.messages
+}
+
+func (current *fakeSession) publish(exchange string, key string, message amqp.Publishing) error {
+	if current.publishErr != nil {
+		return current.publishErr
+	}
+	current.exchange = exchange
+	current.key = key
+	current.published <- message
+	return nil
+}
+
+func (current *fakeSession) close() error {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	current.closed = true
+	return nil
+}
+
+func (current *fakeSession) isClosed() bool {
+	current.mutex.Lock()
+	defer current.mutex.Unlock()
+	return current.closed
+}
+
+func newTestTrigger(transform appcontext.AppFunction, current *fakeSession) *Trigger {
+	golangRuntime := &runtime.GolangRuntime{}
+	golangRuntime.Initialize(nil, nil)
+	golangRuntime.SetTransforms([]appcontext.AppFunction{transform})
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "amqp", SubscribeTopic: "events", PublishTopic: "results"},
+		AMQP:    common.AMQPInfo{PublishExchange: "edgex", RequeueOnError: true},
+	}
+	return &Trigger{
+		Configuration: config,
+		Runtime:       golangRuntime,
+		EdgeXClients:  common.EdgeXClients{LoggingClient: logClient},
+		connect: func() (session, error) {
+			return current, nil
+		},
+	}
+}
+
+func newDelivery(payload string) (amqp.Delivery, *fakeAcknowledger) {
+	acknowledger := &fakeAcknowledger{acked: make(chan bool, 1)}
+	return amqp.Delivery{Acknowledger: acknowledger, Body: []byte(payload)}, acknowledger
+}
+
+func TestProcessMessageAcksOnSuccess(t *testing.T) {
+	events := make(chan models.Event, 1)
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		assert.Equal(t, "123", edgexcontext.CorrelationID)
+		assert.Equal(t, map[string]string{"X-Signature": "c2lnbmF0dXJl"}, edgexcontext.ReceivedHeaders)
+		events <- params[0].(models.Event)
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}
+	current := &fakeSession{messages: make(chan amqp.Delivery, 1), published: make(chan amqp.Publishing, 1)}
+	trigger := newTestTrigger(transform, current)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	require.NoError(t, trigger.Initialize(wg, ctx))
+
+	delivery, acknowledger := newDelivery(eventPayload)
+	delivery.CorrelationId = "123"
+	delivery.Headers = amqp.Table{"X-Signature": "c2lnbmF0dXJl", "X-Retries": int32(1)}
+	current.messages <- delivery
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "livingroomthermostat", event.Device)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Transform never called")
+	}
+
+	output := <-current.published
+	assert.Equal(t, "output", string(output.Body))
+	assert.Equal(t, "123", output.CorrelationId)
+	assert.Equal(t, "edgex", current.exchange)
+	assert.Equal(t, "results", current.key)
+	assert.True(t, <-acknowledger.acked, "The message should be acknowledged")
+
+	cancel()
+	wg.Wait()
+	assert.True(t, current.isClosed())
+}
+
+func TestProcessMessageRejectedOnError(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Payload         string
+		Transform       appcontext.AppFunction
+		PublishErr      error
+		ExpectedRequeue bool
+	}{
+		{
+			"Pipeline Error",
+			eventPayload,
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				return false, errors.New("export failed")
+			},
+			nil,
+			true,
+		},
+		{
+			"Publish Error",
+			eventPayload,
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				edgexcontext.Complete([]byte("output"))
+				return true, nil
+			},
+			errors.New("channel/connection is not open"),
+			true,
+		},
+		{
+			"Bad Payload",
+			"not json",
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				require.Fail(t, "Transform should not be called")
+				return true, nil
+			},
+			nil,
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			current := &fakeSession{published: make(chan amqp.Publishing, 1), publishErr: test.PublishErr}
+			trigger := newTestTrigger(test.Transform, current)
+
+			delivery, acknowledger := newDelivery(test.Payload)
+			trigger.processMessage(current, delivery)
+			assert.False(t, <-acknowledger.acked, "The message should be rejected")
+			assert.Equal(t, test.ExpectedRequeue, acknowledger.requeue)
+		})
+	}
+}
+
+func TestProcessMessageStoredForRetry(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		edgexcontext.SetRetryData([]byte("retry"))
+		return false, errors.New("export failed")
+	}
+	current := &fakeSession{}
+	trigger := newTestTrigger(transform, current)
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("Store", mock.Anything).Return(nil)
+	trigger.Runtime.Initialize(storeClient, nil)
+	trigger.Configuration.Writable.StoreAndForward.Enabled = true
+
+	delivery, acknowledger := newDelivery(eventPayload)
+	trigger.processMessage(current, delivery)
+	assert.True(t, <-acknowledger.acked, "Message stored for retry should not be redelivered")
+	storeClient.AssertNumberOfCalls(t, "Store", 1)
+
+	// Without store and forward the retry data is dropped, so the message is requeued instead
+	trigger.Configuration.Writable.StoreAndForward.Enabled = false
+	delivery, acknowledger = newDelivery(eventPayload)
+	trigger.processMessage(current, delivery)
+	assert.False(t, <-acknowledger.acked, "The message should be rejected")
+	assert.True(t, acknowledger.requeue)
+}
+
+func TestInitializeReconnects(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		return true, nil
+	}
+	first := &fakeSession{messages: make(chan amqp.Delivery)}
+	trigger := newTestTrigger(transform, first)
+
+	connections := make(chan int, 2)
+	count := 0
+	connect := trigger.connect
+	trigger.connect = func() (session, error) {
+		count++
+		connections <- count
+		return connect()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	require.NoError(t, trigger.Initialize(wg, ctx))
+	assert.Equal(t, 1, <-connections)
+
+	// Closing the deliveries is how a lost connection is seen, the reconnect waits for the interval
+	close(first.messages)
+	cancel()
+	wg.Wait()
+	assert.True(t, first.isClosed())
+}
+
+func TestDialConfig(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "amqp", SubscribeTopic: "events"},
+		AMQP:    common.AMQPInfo{URL: "amqp://localhost:5672/"},
+	}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"AMQP": common.InsecureSecretsInfo{
+			Path:    "amqp",
+			Secrets: map[string]string{"username": "amqpuser", "password": "amqppassword"},
+		},
+	}
+
+	tests := []struct {
+		Name      string
+		Update    func(config *common.ConfigurationStruct)
+		ExpectErr bool
+	}{
+		{"Valid", func(config *common.ConfigurationStruct) {}, false},
+		{"Credentials", func(config *common.ConfigurationStruct) { config.AMQP.SecretPath = "amqp" }, false},
+		{"Valid TLS", func(config *common.ConfigurationStruct) {
+			config.AMQP.URL = "amqps://localhost:5671/"
+			config.AMQP.SkipCertVerify = true
+		}, false},
+		{"Invalid URL", func(config *common.ConfigurationStruct) { config.AMQP.URL = "http://localhost:5672/" }, true},
+		{"Missing Secrets", func(config *common.ConfigurationStruct) { config.AMQP.SecretPath = "missing" }, true},
+		{"Missing CA", func(config *common.ConfigurationStruct) {
+			config.AMQP.URL = "amqps://localhost:5671/"
+			config.AMQP.CACertFile = "/no/such/ca.pem"
+		}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testConfig := config
+			test.Update(&testConfig)
+			trigger := Trigger{
+				Configuration:  testConfig,
+				EdgeXClients:   common.EdgeXClients{LoggingClient: logClient},
+				SecretProvider: security.NewSecretProvider(logClient, &testConfig),
+			}
+
+			dialConfig, err := trigger.dialConfig()
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if testConfig.AMQP.SecretPath != "" {
+				assert.Equal(t, []amqp.Authentication{&amqp.PlainAuth{Username: "amqpuser", Password: "amqppassword"}}, dialConfig.SASL)
+			}
+			assert.Equal(t, testConfig.AMQP.SkipCertVerify, dialConfig.TLSClientConfig != nil)
+		})
+	}
+}
+
+func TestInitializeNoQueue(t *testing.T) {
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "amqp"},
+		AMQP:    common.AMQPInfo{URL: "amqp://localhost:5672/"},
+	}
+	trigger := Trigger{Configuration: config, EdgeXClients: common.EdgeXClients{LoggingClient: logClient}}
+	assert.Error(t, trigger.Initialize(&sync.WaitGroup{}, context.Background()))
+}
@@ -0,0 +1,239 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-messaging/pkg/types"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/grpcapi"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	defaultPort = 50051
+	// stopTimeout is how long to wait for the clients to end their streams when stopping, before closing them
+	stopTimeout = 5 * time.Second
+)
+
+// Trigger implements Trigger to support messages received by the AppService gRPC service. Like the HTTP Trigger, the
+// output data is sent back in the response to the message.
+type Trigger struct {
+	Configuration common.ConfigurationStruct
+	Runtime       *runtime.GolangRuntime
+	EdgeXClients  common.EdgeXClients
+}
+
+// service implements the AppService gRPC service using the trigger
+type service struct {
+	grpcapi.UnimplementedAppServiceServer
+	trigger *Trigger
+}
+
+// Initialize starts the gRPC server, which serves the AppService until the application is stopped
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) error {
+	logger := trigger.EdgeXClients.LoggingClient
+	config := trigger.Configuration.GRPC
+
+	port := config.Port
+	if port == 0 {
+		port = defaultPort
+	}
+
+	logger.Info(fmt.Sprintf("Initializing gRPC Trigger on port %d, TLS: %t", port, config.ServerCertFile != ""))
+
+	options, err := serverOptions(config)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("unable to listen on port %d: %s", port, err.Error())
+	}
+
+	server := grpc.NewServer(options...)
+	grpcapi.RegisterAppServiceServer(server, &service{trigger: trigger})
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error(fmt.Sprintf("gRPC server failed: %v", err))
+		}
+	}()
+
+	appWg.Add(1)
+
+	go func() {
+		defer appWg.Done()
+
+		<-appCtx.Done()
+		// Stopping gracefully lets the messages being processed complete, but waits for the clients to end their
+		// streams, so they are closed if they don't end in time
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(stopTimeout):
+			server.Stop()
+		}
+		logger.Info("gRPC server stopped")
+	}()
+
+	logger.Info("gRPC Trigger Initialized")
+
+	return nil
+}
+
+// Trigger processes a single message, returning the pipeline errors as the status of the call
+func (service *service) Trigger(_ context.Context, message *grpcapi.Message) (*grpcapi.Response, error) {
+	response := service.trigger.processMessage(message)
+	if response.Code != int32(codes.OK) {
+		return nil, status.Error(codes.Code(response.Code), response.Error)
+	}
+	return response, nil
+}
+
+// TriggerStream processes the messages of the stream in the order they are received, sending a response for each of
+// them, until the client ends the stream
+func (service *service) TriggerStream(stream grpcapi.AppService_TriggerStreamServer) error {
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(service.trigger.processMessage(message)); err != nil {
+			return err
+		}
+	}
+}
+
+func (trigger *Trigger) processMessage(message *grpcapi.Message) *grpcapi.Response {
+	logger := trigger.EdgeXClients.LoggingClient
+
+	correlationID := message.GetCorrelationId()
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+	contentType := message.GetContentType()
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+
+	logger.Trace("Received message from gRPC", clients.CorrelationHeader, correlationID)
+
+	edgexContext := &appcontext.Context{
+		CorrelationID:         correlationID,
+		Configuration:         trigger.Configuration,
+		LoggingClient:         trigger.EdgeXClients.LoggingClient,
+		EventClient:           trigger.EdgeXClients.EventClient,
+		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
+		CommandClient:         trigger.EdgeXClients.CommandClient,
+		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+		ReceivedHeaders:       message.GetHeaders(),
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		Payload:       message.GetPayload(),
+		ContentType:   contentType,
+	}
+
+	response := &grpcapi.Response{CorrelationId: correlationID}
+
+	messageError := trigger.Runtime.ProcessMessage(edgexContext, envelope)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		response.Code = int32(codes.Internal)
+		if messageError.ErrorCode == http.StatusBadRequest {
+			response.Code = int32(codes.InvalidArgument)
+		}
+		response.Error = messageError.Err.Error()
+		return response
+	}
+
+	if edgexContext.OutputData != nil {
+		response.Output = edgexContext.OutputData
+		logger.Trace("Sent gRPC response message", clients.CorrelationHeader, correlationID)
+	}
+
+	return response
+}
+
+// serverOptions returns the options of the server, with TLS when the server certificate is set
+func serverOptions(config common.GRPCInfo) ([]grpc.ServerOption, error) {
+	var options []grpc.ServerOption
+	if config.MaxMessageSize > 0 {
+		options = append(options, grpc.MaxRecvMsgSize(config.MaxMessageSize))
+	}
+
+	if config.ServerCertFile == "" && config.ServerKeyFile == "" {
+		if config.ClientCACertFile != "" {
+			return nil, errors.New("the gRPC server certificate must be set to verify client certificates")
+		}
+		return options, nil
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, grpc.Creds(credentials.NewTLS(tlsConfig))), nil
+}
+
+func newTLSConfig(config common.GRPCInfo) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(config.ServerCertFile, config.ServerKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %s", err.Error())
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if config.ClientCACertFile != "" {
+		tlsConfig.ClientCAs, err = util.NewCertPool(config.ClientCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client CA certificate: %s", err.Error())
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
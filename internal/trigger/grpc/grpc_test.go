@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/grpcapi"
+)
+
+var logClient logger.LoggingClient
+
+func init() {
+	logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+const eventPayload = `{"id":"5888dea1bd36573f4681d6f9","device":"livingroomthermostat","readings":[{"name":"temperature","value":"38","device":"livingroomthermostat"}]}`
+
+func newTestTrigger(transform appcontext.AppFunction) *Trigger {
+	golangRuntime := &runtime.GolangRuntime{}
+	golangRuntime.Initialize(nil, nil)
+	golangRuntime.SetTransforms([]appcontext.AppFunction{transform})
+
+	return &Trigger{
+		Runtime:      golangRuntime,
+		EdgeXClients: common.EdgeXClients{LoggingClient: logClient},
+	}
+}
+
+// newTestClient returns a client of the trigger's service through an in-memory connection
+func newTestClient(t *testing.T, trigger *Trigger) (grpcapi.AppServiceClient, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	grpcapi.RegisterAppServiceServer(server, &service{trigger: trigger})
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	require.NoError(t, err)
+
+	return grpcapi.NewAppServiceClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+// echoTransform outputs the device of the event
+func echoTransform(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	event := params[0].(models.Event)
+	if event.Device == "fail" {
+		return false, errors.New("export failed")
+	}
+	edgexcontext.Complete([]byte(event.Device))
+	return false, nil
+}
+
+func TestTrigger(t *testing.T) {
+	client, stop := newTestClient(t, newTestTrigger(func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		assert.Equal(t, "correlation", edgexcontext.CorrelationID)
+		assert.Equal(t, "livingroomthermostat", params[0].(models.Event).Device)
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}))
+	defer stop()
+
+	response, err := client.Trigger(context.Background(), &grpcapi.Message{Payload: []byte(eventPayload), CorrelationId: "correlation"})
+	require.NoError(t, err)
+	assert.Equal(t, "correlation", response.CorrelationId)
+	assert.Equal(t, "output", string(response.Output))
+}
+
+func TestTriggerErrors(t *testing.T) {
+	client, stop := newTestClient(t, newTestTrigger(echoTransform))
+	defer stop()
+
+	_, err := client.Trigger(context.Background(), &grpcapi.Message{Payload: []byte("not json")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Trigger(context.Background(), &grpcapi.Message{Payload: []byte(`{"device":"fail"}`)})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "export failed")
+}
+
+func TestTriggerStream(t *testing.T) {
+	client, stop := newTestClient(t, newTestTrigger(echoTransform))
+	defer stop()
+
+	stream, err := client.TriggerStream(context.Background())
+	require.NoError(t, err)
+
+	payloads := []string{`{"device":"thermostat1"}`, "not json", `{"device":"fail"}`, `{"device":"thermostat2"}`}
+	for _, payload := range payloads {
+		require.NoError(t, stream.Send(&grpcapi.Message{Payload: []byte(payload)}))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	var responses []*grpcapi.Response
+	for range payloads {
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		responses = append(responses, response)
+	}
+
+	assert.Equal(t, "thermostat1", string(responses[0].Output))
+	assert.NotEmpty(t, responses[0].CorrelationId, "A correlation ID should be created when not set")
+	assert.Equal(t, int32(codes.InvalidArgument), responses[1].Code, "Errors shouldn't end the stream")
+	assert.Equal(t, int32(codes.Internal), responses[2].Code)
+	assert.Contains(t, responses[2].Error, "export failed")
+	assert.Equal(t, "thermostat2", string(responses[3].Output), "Responses should be in the order of the messages")
+}
+
+func TestInitialize(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	trigger := newTestTrigger(echoTransform)
+	trigger.Configuration.GRPC.Port = port
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	require.NoError(t, trigger.Initialize(wg, ctx))
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	response, err := grpcapi.NewAppServiceClient(conn).Trigger(context.Background(), &grpcapi.Message{Payload: []byte(`{"device":"thermostat1"}`)})
+	require.NoError(t, err)
+	assert.Equal(t, "thermostat1", string(response.Output))
+
+	cancel()
+	wg.Wait()
+}
+
+func TestServerOptions(t *testing.T) {
+	options, err := serverOptions(common.GRPCInfo{})
+	require.NoError(t, err)
+	assert.Empty(t, options)
+
+	options, err = serverOptions(common.GRPCInfo{MaxMessageSize: 1024})
+	require.NoError(t, err)
+	assert.Len(t, options, 1)
+
+	_, err = serverOptions(common.GRPCInfo{ClientCACertFile: "ca.pem"})
+	assert.EqualError(t, err, "the gRPC server certificate must be set to verify client certificates")
+
+	_, err = serverOptions(common.GRPCInfo{ServerCertFile: "missing.pem", ServerKeyFile: "missing.key"})
+	assert.Error(t, err)
+}
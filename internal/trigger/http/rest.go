@@ -76,6 +76,7 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
 		CommandClient:         trigger.EdgeXClients.CommandClient,
 		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+		ReceivedHeaders:       receivedHeaders(r.Header),
 	}
 
 	logger.Trace("Received message from http", clients.CorrelationHeader, correlationID)
@@ -103,3 +104,14 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 
 	trigger.outputData = nil
 }
+
+// receivedHeaders returns the first value of each request header
+func receivedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+	return headers
+}
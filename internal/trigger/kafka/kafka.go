@@ -0,0 +1,299 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-messaging/pkg/types"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// reader is the part of kafka.Reader used by the Trigger
+type reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// retryInterval is the time waited before processing a message whose processing failed again
+var retryInterval = 5 * time.Second
+
+// writer is the part of kafka.Writer used by the Trigger
+type writer interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+// Trigger implements Trigger to support consuming from Kafka. The messages are processed in order and the offset of
+// each message is committed once the pipeline has processed it successfully. A message whose processing fails is
+// processed again, holding back the following messages, until it succeeds, since committing a later offset would
+// also commit the failed message.
+type Trigger struct {
+	Configuration  common.ConfigurationStruct
+	Runtime        *runtime.GolangRuntime
+	EdgeXClients   common.EdgeXClients
+	SecretProvider *security.SecretProvider
+	reader         reader
+	writer         writer
+}
+
+// Initialize connects to the Kafka cluster and starts consuming the SubscribeTopic
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) error {
+	logger := trigger.EdgeXClients.LoggingClient
+	config := trigger.Configuration.Kafka
+	binding := trigger.Configuration.Binding
+
+	logger.Info(fmt.Sprintf("Initializing Kafka Trigger. Subscribing to topic: %s with group: %s, Publish Topic: %s", binding.SubscribeTopic, config.GroupID, binding.PublishTopic))
+
+	if trigger.reader == nil {
+		dialer, transport, err := trigger.connectionSettings()
+		if err != nil {
+			return err
+		}
+
+		startOffset := kafka.LastOffset
+		switch strings.ToLower(config.StartOffset) {
+		case "", "last":
+		case "first":
+			startOffset = kafka.FirstOffset
+		default:
+			return fmt.Errorf("unsupported Kafka start offset '%s', must be first or last", config.StartOffset)
+		}
+
+		trigger.reader = kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     config.Brokers,
+			GroupID:     config.GroupID,
+			Topic:       binding.SubscribeTopic,
+			Dialer:      dialer,
+			StartOffset: startOffset,
+		})
+
+		if binding.PublishTopic != "" {
+			trigger.writer = &kafka.Writer{
+				Addr:      kafka.TCP(config.Brokers...),
+				Topic:     binding.PublishTopic,
+				Balancer:  &kafka.Hash{},
+				Transport: transport,
+			}
+		}
+	}
+
+	appWg.Add(1)
+
+	go func() {
+		defer appWg.Done()
+		defer trigger.close()
+
+		for {
+			message, err := trigger.reader.FetchMessage(appCtx)
+			if err != nil {
+				if appCtx.Err() == nil {
+					logger.Error(fmt.Sprintf("Failed to receive Kafka Message, %v", err))
+				}
+				return
+			}
+
+			for !trigger.processMessage(appCtx, message) {
+				select {
+				case <-appCtx.Done():
+					return
+				case <-time.After(retryInterval):
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// processMessage processes the message and commits its offset, returning false if the message must be processed again
+func (trigger *Trigger) processMessage(appCtx context.Context, message kafka.Message) bool {
+	logger := trigger.EdgeXClients.LoggingClient
+
+	correlationID := header(message, clients.CorrelationHeader)
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+	contentType := header(message, clients.ContentType)
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+
+	logger.Trace("Received message from Kafka", "topic", message.Topic, "partition", message.Partition, "offset", message.Offset, clients.CorrelationHeader, correlationID)
+
+	edgexContext := &appcontext.Context{
+		CorrelationID:         correlationID,
+		Configuration:         trigger.Configuration,
+		LoggingClient:         trigger.EdgeXClients.LoggingClient,
+		EventClient:           trigger.EdgeXClients.EventClient,
+		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
+		CommandClient:         trigger.EdgeXClients.CommandClient,
+		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+		ReceivedHeaders:       receivedHeaders(message),
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		Payload:       message.Value,
+		ContentType:   contentType,
+	}
+
+	messageError := trigger.Runtime.ProcessMessage(edgexContext, envelope)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		switch {
+		case messageError.ErrorCode == http.StatusBadRequest:
+			// Processing a message that can't be decoded again would never succeed
+			logger.Error(fmt.Sprintf("Kafka Message at offset %d of partition %d can't be decoded and is dropped", message.Offset, message.Partition), clients.CorrelationHeader, correlationID)
+		case edgexContext.RetryData != nil && trigger.Configuration.Writable.StoreAndForward.Enabled:
+			logger.Warn(fmt.Sprintf("Kafka Message at offset %d of partition %d stored for later retry", message.Offset, message.Partition), clients.CorrelationHeader, correlationID)
+		default:
+			logger.Warn(fmt.Sprintf("Kafka Message at offset %d of partition %d not committed, retrying in %s", message.Offset, message.Partition, retryInterval), clients.CorrelationHeader, correlationID)
+			return false
+		}
+		return trigger.commit(appCtx, message)
+	}
+
+	if edgexContext.OutputData != nil && trigger.writer != nil {
+		output := kafka.Message{
+			Key:   message.Key,
+			Value: edgexContext.OutputData,
+			Headers: []kafka.Header{
+				{Key: clients.CorrelationHeader, Value: []byte(correlationID)},
+				{Key: clients.ContentType, Value: []byte(clients.ContentTypeJSON)},
+			},
+		}
+		if err := trigger.writer.WriteMessages(appCtx, output); err != nil {
+			logger.Error(fmt.Sprintf("Failed to publish Message to Kafka, %v", err))
+			return false
+		}
+
+		logger.Trace("Published message to Kafka", "topic", trigger.Configuration.Binding.PublishTopic, clients.CorrelationHeader, correlationID)
+	}
+
+	return trigger.commit(appCtx, message)
+}
+
+// commit commits the offset of the message. A failed commit is covered by the commit of the next message.
+func (trigger *Trigger) commit(appCtx context.Context, message kafka.Message) bool {
+	if err := trigger.reader.CommitMessages(appCtx, message); err != nil {
+		trigger.EdgeXClients.LoggingClient.Error(fmt.Sprintf("Failed to commit Kafka Message, %v", err))
+	}
+	return true
+}
+
+func (trigger *Trigger) close() {
+	if err := trigger.reader.Close(); err != nil {
+		trigger.EdgeXClients.LoggingClient.Warn(fmt.Sprintf("Failed to close Kafka reader, %v", err))
+	}
+	if trigger.writer != nil {
+		if err := trigger.writer.Close(); err != nil {
+			trigger.EdgeXClients.LoggingClient.Warn(fmt.Sprintf("Failed to close Kafka writer, %v", err))
+		}
+	}
+}
+
+// connectionSettings returns the dialer used by the reader and the transport used by the writer, which share the
+// TLS and SASL settings
+func (trigger *Trigger) connectionSettings() (*kafka.Dialer, *kafka.Transport, error) {
+	config := trigger.Configuration.Kafka
+
+	if len(config.Brokers) == 0 {
+		return nil, nil, errors.New("at least one Kafka broker must be set")
+	}
+	if trigger.Configuration.Binding.SubscribeTopic == "" {
+		return nil, nil, errors.New("the Kafka subscribe topic must be set")
+	}
+	if config.GroupID == "" {
+		return nil, nil, errors.New("the Kafka consumer group must be set")
+	}
+
+	var tlsConfig *tls.Config
+	if config.UseTLS {
+		var err error
+		tlsConfig, err = util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var mechanism sasl.Mechanism
+	if config.SASLMechanism != "" {
+		if trigger.SecretProvider == nil {
+			return nil, nil, errors.New("the secret provider is not available")
+		}
+		secrets, err := trigger.SecretProvider.GetSecrets(config.SecretPath, "username", "password")
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to get Kafka credentials from secret path '%s': %s", config.SecretPath, err.Error())
+		}
+		mechanism, err = util.NewKafkaSASLMechanism(config.SASLMechanism, secrets["username"], secrets["password"])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	dialer := &kafka.Dialer{
+		ClientID:      config.ClientID,
+		Timeout:       kafka.DefaultDialer.Timeout,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}
+	transport := &kafka.Transport{
+		ClientID: config.ClientID,
+		TLS:      tlsConfig,
+		SASL:     mechanism,
+	}
+	return dialer, transport, nil
+}
+
+// receivedHeaders returns the value of the first message header with each key
+func receivedHeaders(message kafka.Message) map[string]string {
+	headers := make(map[string]string, len(message.Headers))
+	for _, header := range message.Headers {
+		if _, ok := headers[header.Key]; !ok {
+			headers[header.Key] = string(header.Value)
+		}
+	}
+	return headers
+}
+
+// header returns the value of the first message header with the key, ignoring case
+func header(message kafka.Message, key string) string {
+	for _, header := range message.Headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
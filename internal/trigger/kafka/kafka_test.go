@@ -0,0 +1,334 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/store/db/interfaces/mocks"
+)
+
+var logClient logger.LoggingClient
+
+func init() {
+	logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+const eventPayload = `{"id":"5888dea1bd36573f4681d6f9","device":"livingroomthermostat","readings":[{"name":"temperature","value":"38","device":"livingroomthermostat"}]}`
+
+// fakeReader returns the messages and then blocks until the context is done
+type fakeReader struct {
+	messages  chan kafka.Message
+	mutex     sync.Mutex
+	committed []kafka.Message
+	closed    bool
+}
+
+func (reader *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case message := <-reader.messages:
+		return message, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (reader *fakeReader) CommitMessages(_ context.Context, messages ...kafka.Message) error {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+	reader.committed = append(reader.committed, messages...)
+	return nil
+}
+
+func (reader *fakeReader) Close() error {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+	reader.closed = true
+	return nil
+}
+
+func (reader *fakeReader) getCommitted() []kafka.Message {
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+	return reader.committed
+}
+
+type fakeWriter struct {
+	messages chan kafka.Message
+	err      error
+}
+
+func (writer *fakeWriter) WriteMessages(_ context.Context, messages ...kafka.Message) error {
+	if writer.err != nil {
+		return writer.err
+	}
+	for _, message := range messages {
+		writer.messages <- message
+	}
+	return nil
+}
+
+func (writer *fakeWriter) Close() error {
+	return nil
+}
+
+func newTestTrigger(transform appcontext.AppFunction, writer writer) (*Trigger, *fakeReader) {
+	golangRuntime := &runtime.GolangRuntime{}
+	golangRuntime.Initialize(nil, nil)
+	golangRuntime.SetTransforms([]appcontext.AppFunction{transform})
+
+	reader := &fakeReader{messages: make(chan kafka.Message, 2)}
+	trigger := &Trigger{
+		Runtime:      golangRuntime,
+		EdgeXClients: common.EdgeXClients{LoggingClient: logClient},
+		reader:       reader,
+		writer:       writer,
+	}
+	return trigger, reader
+}
+
+func TestProcessMessageCommitsOnSuccess(t *testing.T) {
+	events := make(chan models.Event, 1)
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		assert.Equal(t, "123", edgexcontext.CorrelationID)
+		assert.Equal(t, "c2lnbmF0dXJl", edgexcontext.ReceivedHeaders["X-Signature"])
+		events <- params[0].(models.Event)
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}
+	writer := &fakeWriter{messages: make(chan kafka.Message, 1)}
+	trigger, reader := newTestTrigger(transform, writer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	require.NoError(t, trigger.Initialize(wg, ctx))
+
+	reader.messages <- kafka.Message{
+		Partition: 1,
+		Offset:    10,
+		Key:       []byte("livingroomthermostat"),
+		Value:     []byte(eventPayload),
+		Headers: []kafka.Header{
+			{Key: clients.CorrelationHeader, Value: []byte("123")},
+			{Key: "X-Signature", Value: []byte("c2lnbmF0dXJl")},
+		},
+	}
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "livingroomthermostat", event.Device)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Transform never called")
+	}
+
+	output := <-writer.messages
+	assert.Equal(t, "output", string(output.Value))
+	assert.Equal(t, "livingroomthermostat", string(output.Key))
+	assert.Contains(t, output.Headers, kafka.Header{Key: clients.CorrelationHeader, Value: []byte("123")})
+
+	cancel()
+	wg.Wait()
+	committed := reader.getCommitted()
+	require.Len(t, committed, 1)
+	assert.Equal(t, int64(10), committed[0].Offset)
+	assert.True(t, reader.closed)
+}
+
+func TestProcessMessageNotCommittedOnError(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Transform appcontext.AppFunction
+		Writer    writer
+	}{
+		{
+			"Pipeline Error",
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				return false, errors.New("export failed")
+			},
+			nil,
+		},
+		{
+			"Publish Error",
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				edgexcontext.Complete([]byte("output"))
+				return true, nil
+			},
+			&fakeWriter{err: errors.New("leader not available")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger, reader := newTestTrigger(test.Transform, test.Writer)
+			done := trigger.processMessage(context.Background(), kafka.Message{Value: []byte(eventPayload)})
+			assert.False(t, done, "Message should be processed again")
+			assert.Empty(t, reader.getCommitted())
+		})
+	}
+}
+
+func TestProcessMessageBadPayload(t *testing.T) {
+	transformWasCalled := false
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		transformWasCalled = true
+		return true, nil
+	}
+	trigger, reader := newTestTrigger(transform, nil)
+
+	done := trigger.processMessage(context.Background(), kafka.Message{Value: []byte("not json")})
+	assert.True(t, done, "Message that can't be decoded should not be processed again")
+	assert.False(t, transformWasCalled)
+	assert.Len(t, reader.getCommitted(), 1, "Message that can't be decoded should be committed")
+}
+
+func TestProcessMessageStoredForRetry(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		edgexcontext.SetRetryData([]byte("retry"))
+		return false, errors.New("export failed")
+	}
+	trigger, reader := newTestTrigger(transform, nil)
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("Store", mock.Anything).Return(nil)
+	trigger.Runtime.Initialize(storeClient, nil)
+	trigger.Configuration.Writable.StoreAndForward.Enabled = true
+
+	done := trigger.processMessage(context.Background(), kafka.Message{Value: []byte(eventPayload)})
+	assert.True(t, done, "Message stored for retry should not be processed again")
+	assert.Len(t, reader.getCommitted(), 1)
+	storeClient.AssertNumberOfCalls(t, "Store", 1)
+}
+
+func TestFailedMessageProcessedAgain(t *testing.T) {
+	defer func(interval time.Duration) { retryInterval = interval }(retryInterval)
+	retryInterval = 10 * time.Millisecond
+
+	var mutex sync.Mutex
+	calls := 0
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		if calls == 1 {
+			return false, errors.New("export failed")
+		}
+		return false, nil
+	}
+	trigger, reader := newTestTrigger(transform, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	require.NoError(t, trigger.Initialize(wg, ctx))
+
+	reader.messages <- kafka.Message{Offset: 10, Value: []byte(eventPayload)}
+	reader.messages <- kafka.Message{Offset: 11, Value: []byte(eventPayload)}
+
+	require.Eventually(t, func() bool { return len(reader.getCommitted()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	committed := reader.getCommitted()
+	assert.Equal(t, int64(10), committed[0].Offset, "Failed message should be committed once it succeeds")
+	assert.Equal(t, int64(11), committed[1].Offset)
+	assert.Equal(t, 3, calls)
+}
+
+func TestConnectionSettings(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "kafka", SubscribeTopic: "events"},
+		Kafka:   common.KafkaInfo{Brokers: []string{"localhost:9092"}, GroupID: "app", ClientID: "app-service"},
+	}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"Kafka": common.InsecureSecretsInfo{
+			Path:    "kafka",
+			Secrets: map[string]string{"username": "kafkauser", "password": "kafkapassword"},
+		},
+	}
+
+	tests := []struct {
+		Name      string
+		Update    func(config *common.ConfigurationStruct)
+		ExpectErr bool
+	}{
+		{"Valid", func(config *common.ConfigurationStruct) {}, false},
+		{"SASL", func(config *common.ConfigurationStruct) {
+			config.Kafka.SASLMechanism = "PLAIN"
+			config.Kafka.SecretPath = "kafka"
+		}, false},
+		{"No Brokers", func(config *common.ConfigurationStruct) { config.Kafka.Brokers = nil }, true},
+		{"No Topic", func(config *common.ConfigurationStruct) { config.Binding.SubscribeTopic = "" }, true},
+		{"No Group", func(config *common.ConfigurationStruct) { config.Kafka.GroupID = "" }, true},
+		{"Bad SASL", func(config *common.ConfigurationStruct) { config.Kafka.SASLMechanism = "gssapi" }, true},
+		{"Missing CA", func(config *common.ConfigurationStruct) {
+			config.Kafka.UseTLS = true
+			config.Kafka.CACertFile = "/no/such/ca.pem"
+		}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testConfig := config
+			test.Update(&testConfig)
+			trigger := Trigger{
+				Configuration:  testConfig,
+				EdgeXClients:   common.EdgeXClients{LoggingClient: logClient},
+				SecretProvider: security.NewSecretProvider(logClient, &testConfig),
+			}
+
+			dialer, transport, err := trigger.connectionSettings()
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "app-service", dialer.ClientID)
+			assert.Equal(t, "app-service", transport.ClientID)
+			if testConfig.Kafka.SASLMechanism != "" {
+				assert.Equal(t, plain.Mechanism{Username: "kafkauser", Password: "kafkapassword"}, transport.SASL)
+				assert.Equal(t, transport.SASL, dialer.SASLMechanism)
+			}
+		})
+	}
+}
+
+func TestInitializeBadStartOffset(t *testing.T) {
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "kafka", SubscribeTopic: "events"},
+		Kafka:   common.KafkaInfo{Brokers: []string{"localhost:9092"}, GroupID: "app", StartOffset: "middle"},
+	}
+	trigger := Trigger{Configuration: config, EdgeXClients: common.EdgeXClients{LoggingClient: logClient}}
+	assert.Error(t, trigger.Initialize(&sync.WaitGroup{}, context.Background()))
+}
@@ -0,0 +1,304 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-messaging/pkg/types"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// publisher is the part of nats.Conn used to publish the output data
+type publisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// acknowledger is the part of nats.Msg used to acknowledge the messages consumed from JetStream
+type acknowledger interface {
+	Ack(opts ...nats.AckOpt) error
+	Nak(opts ...nats.AckOpt) error
+	Term(opts ...nats.AckOpt) error
+}
+
+// Trigger implements Trigger to support subscribing to NATS subjects, or consuming them from JetStream. Like the
+// Message Bus Trigger, the output data is published to the PublishTopic, unless the message is a request, in which
+// case the output data is the reply.
+type Trigger struct {
+	Configuration  common.ConfigurationStruct
+	Runtime        *runtime.GolangRuntime
+	EdgeXClients   common.EdgeXClients
+	SecretProvider *security.SecretProvider
+	publisher      publisher
+	useHeaders     bool
+}
+
+// Initialize connects to the NATS server and subscribes to the SubscribeTopic
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) error {
+	logger := trigger.EdgeXClients.LoggingClient
+	config := trigger.Configuration.NATS
+	binding := trigger.Configuration.Binding
+
+	logger.Info(fmt.Sprintf("Initializing NATS Trigger. Subscribing to subject: %s with queue group: %s, JetStream: %t, Publish Topic: %s", binding.SubscribeTopic, config.QueueGroup, config.JetStream, binding.PublishTopic))
+
+	if binding.SubscribeTopic == "" {
+		return errors.New("the NATS subscribe topic must be set")
+	}
+	subscribeOptions, err := trigger.subscribeOptions()
+	if err != nil {
+		return err
+	}
+	options, err := trigger.connectionOptions()
+	if err != nil {
+		return err
+	}
+
+	closed := make(chan struct{})
+	options = append(options, nats.ClosedHandler(func(*nats.Conn) { close(closed) }))
+
+	connection, err := nats.Connect(config.URL, options...)
+	if err != nil {
+		return fmt.Errorf("unable to connect to NATS server: %s", err.Error())
+	}
+	trigger.publisher = connection
+	trigger.useHeaders = connection.HeadersSupported()
+
+	if config.JetStream {
+		var jetStream nats.JetStreamContext
+		jetStream, err = connection.JetStream()
+		if err == nil {
+			if config.QueueGroup != "" {
+				_, err = jetStream.QueueSubscribe(binding.SubscribeTopic, config.QueueGroup, trigger.handleMessage, subscribeOptions...)
+			} else {
+				_, err = jetStream.Subscribe(binding.SubscribeTopic, trigger.handleMessage, subscribeOptions...)
+			}
+		}
+	} else if config.QueueGroup != "" {
+		_, err = connection.QueueSubscribe(binding.SubscribeTopic, config.QueueGroup, trigger.handleMessage)
+	} else {
+		_, err = connection.Subscribe(binding.SubscribeTopic, trigger.handleMessage)
+	}
+	if err != nil {
+		connection.Close()
+		return fmt.Errorf("unable to subscribe to NATS subject '%s': %s", binding.SubscribeTopic, err.Error())
+	}
+
+	appWg.Add(1)
+
+	go func() {
+		defer appWg.Done()
+
+		<-appCtx.Done()
+		// Draining lets the messages already received be processed, and unlike unsubscribing, keeps the durable
+		// JetStream consumer
+		if err := connection.Drain(); err != nil {
+			logger.Warn(fmt.Sprintf("Failed to drain NATS connection, %v", err))
+			connection.Close()
+		}
+		<-closed
+	}()
+
+	return nil
+}
+
+// handleMessage processes the message and, when consuming from JetStream, acknowledges it
+func (trigger *Trigger) handleMessage(msg *nats.Msg) {
+	messageError, storedForRetry := trigger.processMessage(msg)
+	if trigger.Configuration.NATS.JetStream {
+		trigger.acknowledge(msg, messageError, storedForRetry)
+	}
+}
+
+// processMessage runs the pipeline for the message and publishes its output. It also reports whether the data of a
+// failed export was stored for retry by store and forward.
+func (trigger *Trigger) processMessage(msg *nats.Msg) (*runtime.MessageError, bool) {
+	logger := trigger.EdgeXClients.LoggingClient
+
+	correlationID := msg.Header.Get(clients.CorrelationHeader)
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+	contentType := msg.Header.Get(clients.ContentType)
+	if contentType == "" {
+		contentType = clients.ContentTypeJSON
+	}
+
+	logger.Trace("Received message from NATS", "subject", msg.Subject, clients.CorrelationHeader, correlationID)
+
+	edgexContext := &appcontext.Context{
+		CorrelationID:         correlationID,
+		Configuration:         trigger.Configuration,
+		LoggingClient:         trigger.EdgeXClients.LoggingClient,
+		EventClient:           trigger.EdgeXClients.EventClient,
+		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
+		CommandClient:         trigger.EdgeXClients.CommandClient,
+		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+		ReceivedHeaders:       receivedHeaders(msg.Header),
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		Payload:       msg.Data,
+		ContentType:   contentType,
+	}
+
+	messageError := trigger.Runtime.ProcessMessage(edgexContext, envelope)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		return messageError, edgexContext.RetryData != nil && trigger.Configuration.Writable.StoreAndForward.Enabled
+	}
+
+	if edgexContext.OutputData != nil {
+		// The reply subject of JetStream messages is used to acknowledge them, so only core NATS messages are replied to
+		subject := trigger.Configuration.Binding.PublishTopic
+		if msg.Reply != "" && !trigger.Configuration.NATS.JetStream {
+			subject = msg.Reply
+		}
+		if subject == "" {
+			return nil, false
+		}
+
+		output := &nats.Msg{Subject: subject, Data: edgexContext.OutputData}
+		if trigger.useHeaders {
+			output.Header = nats.Header{}
+			output.Header.Set(clients.CorrelationHeader, correlationID)
+			output.Header.Set(clients.ContentType, clients.ContentTypeJSON)
+		}
+		if err := trigger.publisher.PublishMsg(output); err != nil {
+			logger.Error(fmt.Sprintf("Failed to publish Message to NATS, %v", err), clients.CorrelationHeader, correlationID)
+			return &runtime.MessageError{Err: err, ErrorCode: http.StatusInternalServerError}, false
+		}
+
+		logger.Trace("Published message to NATS", "subject", subject, clients.CorrelationHeader, correlationID)
+	}
+
+	return nil, false
+}
+
+// acknowledge acknowledges the JetStream message once the pipeline has processed it successfully, or once the data of
+// the failed export has been stored for retry, since redelivering it would export the data twice. Otherwise it is
+// redelivered, unless it can't be decoded.
+func (trigger *Trigger) acknowledge(msg acknowledger, messageError *runtime.MessageError, storedForRetry bool) {
+	var err error
+	switch {
+	case messageError == nil, storedForRetry:
+		err = msg.Ack()
+	case messageError.ErrorCode == http.StatusBadRequest:
+		err = msg.Term()
+	default:
+		err = msg.Nak()
+	}
+	if err != nil {
+		trigger.EdgeXClients.LoggingClient.Error(fmt.Sprintf("Failed to acknowledge JetStream Message, %v", err))
+	}
+}
+
+// subscribeOptions returns the settings of the durable JetStream consumer
+func (trigger *Trigger) subscribeOptions() ([]nats.SubOpt, error) {
+	config := trigger.Configuration.NATS
+	if !config.JetStream {
+		return nil, nil
+	}
+
+	if config.Durable == "" {
+		return nil, errors.New("the JetStream durable consumer name must be set")
+	}
+
+	options := []nats.SubOpt{nats.Durable(config.Durable), nats.ManualAck(), nats.AckExplicit()}
+
+	switch strings.ToLower(config.DeliverPolicy) {
+	case "", "all":
+		options = append(options, nats.DeliverAll())
+	case "last":
+		options = append(options, nats.DeliverLast())
+	case "new":
+		options = append(options, nats.DeliverNew())
+	default:
+		return nil, fmt.Errorf("unsupported JetStream deliver policy '%s', must be all, last or new", config.DeliverPolicy)
+	}
+
+	if config.MaxDeliver > 0 {
+		options = append(options, nats.MaxDeliver(config.MaxDeliver))
+	}
+
+	return options, nil
+}
+
+// connectionOptions returns the options used to connect to the server, with the credentials from the secret store
+// when the SecretPath is set
+func (trigger *Trigger) connectionOptions() ([]nats.Option, error) {
+	config := trigger.Configuration.NATS
+
+	if config.URL == "" {
+		return nil, errors.New("the NATS server URL must be set")
+	}
+
+	// The trigger keeps trying to reconnect, rather than stop receiving messages
+	options := []nats.Option{nats.MaxReconnects(-1)}
+
+	if config.UseTLS {
+		tlsConfig, err := util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, nats.Secure(tlsConfig))
+	}
+
+	if config.CredentialsFile != "" {
+		options = append(options, nats.UserCredentials(config.CredentialsFile))
+	}
+
+	if config.SecretPath != "" {
+		if trigger.SecretProvider == nil {
+			return nil, errors.New("the secret provider is not available")
+		}
+		secrets, err := trigger.SecretProvider.GetSecrets(config.SecretPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get NATS credentials from secret path '%s': %s", config.SecretPath, err.Error())
+		}
+		if token, ok := secrets["token"]; ok {
+			options = append(options, nats.Token(token))
+		} else {
+			options = append(options, nats.UserInfo(secrets["username"], secrets["password"]))
+		}
+	}
+
+	return options, nil
+}
+
+// receivedHeaders returns the first value of each message header
+func receivedHeaders(header nats.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+	return headers
+}
@@ -0,0 +1,363 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/store/db/interfaces/mocks"
+)
+
+var logClient logger.LoggingClient
+
+func init() {
+	logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+const eventPayload = `{"id":"5888dea1bd36573f4681d6f9","device":"livingroomthermostat","readings":[{"name":"temperature","value":"38","device":"livingroomthermostat"}]}`
+
+type fakePublisher struct {
+	published []*nats.Msg
+	err       error
+}
+
+func (publisher *fakePublisher) PublishMsg(msg *nats.Msg) error {
+	if publisher.err != nil {
+		return publisher.err
+	}
+	publisher.published = append(publisher.published, msg)
+	return nil
+}
+
+// fakeAcknowledger records how the message was acknowledged
+type fakeAcknowledger struct {
+	acknowledgement string
+}
+
+func (msg *fakeAcknowledger) Ack(opts ...nats.AckOpt) error {
+	msg.acknowledgement = "ack"
+	return nil
+}
+
+func (msg *fakeAcknowledger) Nak(opts ...nats.AckOpt) error {
+	msg.acknowledgement = "nak"
+	return nil
+}
+
+func (msg *fakeAcknowledger) Term(opts ...nats.AckOpt) error {
+	msg.acknowledgement = "term"
+	return nil
+}
+
+func newTestTrigger(transform appcontext.AppFunction, publisher *fakePublisher, jetStream bool) *Trigger {
+	golangRuntime := &runtime.GolangRuntime{}
+	golangRuntime.Initialize(nil, nil)
+	golangRuntime.SetTransforms([]appcontext.AppFunction{transform})
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "nats", SubscribeTopic: "edgex.events.>", PublishTopic: "edgex.results"},
+		NATS:    common.NATSInfo{URL: "nats://localhost:4222", JetStream: jetStream},
+	}
+	return &Trigger{
+		Configuration: config,
+		Runtime:       golangRuntime,
+		EdgeXClients:  common.EdgeXClients{LoggingClient: logClient},
+		publisher:     publisher,
+		useHeaders:    true,
+	}
+}
+
+func TestProcessMessagePublishesOutput(t *testing.T) {
+	output := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		assert.Equal(t, "livingroomthermostat", params[0].(models.Event).Device)
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}
+
+	tests := []struct {
+		Name            string
+		Reply           string
+		JetStream       bool
+		ExpectedSubject string
+	}{
+		{"Publish", "", false, "edgex.results"},
+		{"Request Reply", "_INBOX.reply", false, "_INBOX.reply"},
+		{"JetStream", "$JS.ACK.events.app.1.1.1.1.0", true, "edgex.results"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			trigger := newTestTrigger(output, publisher, test.JetStream)
+
+			msg := &nats.Msg{Subject: "edgex.events.thermostat", Reply: test.Reply, Data: []byte(eventPayload), Header: nats.Header{}}
+			msg.Header.Set(clients.CorrelationHeader, "123")
+			messageError, _ := trigger.processMessage(msg)
+			require.Nil(t, messageError)
+
+			require.Len(t, publisher.published, 1)
+			published := publisher.published[0]
+			assert.Equal(t, test.ExpectedSubject, published.Subject)
+			assert.Equal(t, "output", string(published.Data))
+			assert.Equal(t, "123", published.Header.Get(clients.CorrelationHeader))
+		})
+	}
+}
+
+func TestProcessMessageNoPublishTopic(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}
+	publisher := &fakePublisher{}
+	trigger := newTestTrigger(transform, publisher, false)
+	trigger.Configuration.Binding.PublishTopic = ""
+	trigger.useHeaders = false
+
+	messageError, _ := trigger.processMessage(&nats.Msg{Data: []byte(eventPayload)})
+	require.Nil(t, messageError)
+	assert.Empty(t, publisher.published)
+
+	messageError, _ = trigger.processMessage(&nats.Msg{Reply: "_INBOX.reply", Data: []byte(eventPayload)})
+	require.Nil(t, messageError)
+	require.Len(t, publisher.published, 1)
+	assert.Nil(t, publisher.published[0].Header, "Headers aren't sent to servers that don't support them")
+}
+
+func TestProcessMessageErrors(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Payload      string
+		Transform    appcontext.AppFunction
+		PublishErr   error
+		ExpectedCode int
+	}{
+		{
+			"Pipeline Error",
+			eventPayload,
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				return false, errors.New("export failed")
+			},
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"Publish Error",
+			eventPayload,
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				edgexcontext.Complete([]byte("output"))
+				return true, nil
+			},
+			errors.New("nats: connection closed"),
+			http.StatusInternalServerError,
+		},
+		{
+			"Bad Payload",
+			"not json",
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				require.Fail(t, "Transform should not be called")
+				return true, nil
+			},
+			nil,
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger := newTestTrigger(test.Transform, &fakePublisher{err: test.PublishErr}, true)
+			messageError, storedForRetry := trigger.processMessage(&nats.Msg{Data: []byte(test.Payload)})
+			require.NotNil(t, messageError)
+			assert.Equal(t, test.ExpectedCode, messageError.ErrorCode)
+			assert.False(t, storedForRetry)
+		})
+	}
+}
+
+func TestProcessMessageStoredForRetry(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		edgexcontext.SetRetryData([]byte("retry"))
+		return false, errors.New("export failed")
+	}
+	trigger := newTestTrigger(transform, &fakePublisher{}, true)
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("Store", mock.Anything).Return(nil)
+	trigger.Runtime.Initialize(storeClient, nil)
+	trigger.Configuration.Writable.StoreAndForward.Enabled = true
+
+	messageError, storedForRetry := trigger.processMessage(&nats.Msg{Data: []byte(eventPayload)})
+	require.NotNil(t, messageError)
+	assert.True(t, storedForRetry)
+	storeClient.AssertNumberOfCalls(t, "Store", 1)
+
+	trigger.Configuration.Writable.StoreAndForward.Enabled = false
+	messageError, storedForRetry = trigger.processMessage(&nats.Msg{Data: []byte(eventPayload)})
+	require.NotNil(t, messageError)
+	assert.False(t, storedForRetry, "Retry data is only stored when store and forward is enabled")
+}
+
+func TestAcknowledge(t *testing.T) {
+	tests := []struct {
+		Name           string
+		MessageError   *runtime.MessageError
+		StoredForRetry bool
+		Expected       string
+	}{
+		{"Success", nil, false, "ack"},
+		{"Pipeline Error", &runtime.MessageError{ErrorCode: http.StatusUnprocessableEntity}, false, "nak"},
+		{"Stored For Retry", &runtime.MessageError{ErrorCode: http.StatusUnprocessableEntity}, true, "ack"},
+		{"Bad Payload", &runtime.MessageError{ErrorCode: http.StatusBadRequest}, false, "term"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger := newTestTrigger(nil, &fakePublisher{}, true)
+			msg := &fakeAcknowledger{}
+			trigger.acknowledge(msg, test.MessageError, test.StoredForRetry)
+			assert.Equal(t, test.Expected, msg.acknowledgement)
+		})
+	}
+}
+
+func TestSubscribeOptions(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        common.NATSInfo
+		ExpectedCount int
+		ExpectErr     bool
+	}{
+		{"Core NATS", common.NATSInfo{}, 0, false},
+		{"JetStream", common.NATSInfo{JetStream: true, Durable: "app"}, 4, false},
+		{"JetStream Options", common.NATSInfo{JetStream: true, Durable: "app", DeliverPolicy: "New", MaxDeliver: 5}, 5, false},
+		{"No Durable", common.NATSInfo{JetStream: true}, 0, true},
+		{"Bad Deliver Policy", common.NATSInfo{JetStream: true, Durable: "app", DeliverPolicy: "first"}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger := Trigger{Configuration: common.ConfigurationStruct{NATS: test.Config}}
+			options, err := trigger.subscribeOptions()
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, options, test.ExpectedCount)
+		})
+	}
+}
+
+func TestConnectionOptions(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "nats", SubscribeTopic: "edgex.events.>"},
+		NATS:    common.NATSInfo{URL: "nats://localhost:4222"},
+	}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"NATS": common.InsecureSecretsInfo{
+			Path:    "nats",
+			Secrets: map[string]string{"username": "natsuser", "password": "natspassword"},
+		},
+		"NATSToken": common.InsecureSecretsInfo{
+			Path:    "natstoken",
+			Secrets: map[string]string{"token": "natstoken"},
+		},
+	}
+
+	tests := []struct {
+		Name      string
+		Update    func(config *common.ConfigurationStruct)
+		Check     func(t *testing.T, options nats.Options)
+		ExpectErr bool
+	}{
+		{"Valid", func(config *common.ConfigurationStruct) {}, func(t *testing.T, options nats.Options) {
+			assert.Equal(t, -1, options.MaxReconnect)
+		}, false},
+		{"User Password", func(config *common.ConfigurationStruct) { config.NATS.SecretPath = "nats" }, func(t *testing.T, options nats.Options) {
+			assert.Equal(t, "natsuser", options.User)
+			assert.Equal(t, "natspassword", options.Password)
+		}, false},
+		{"Token", func(config *common.ConfigurationStruct) { config.NATS.SecretPath = "natstoken" }, func(t *testing.T, options nats.Options) {
+			assert.Equal(t, "natstoken", options.Token)
+		}, false},
+		{"TLS", func(config *common.ConfigurationStruct) {
+			config.NATS.UseTLS = true
+			config.NATS.SkipCertVerify = true
+		}, func(t *testing.T, options nats.Options) {
+			require.NotNil(t, options.TLSConfig)
+			assert.True(t, options.TLSConfig.InsecureSkipVerify)
+		}, false},
+		{"No URL", func(config *common.ConfigurationStruct) { config.NATS.URL = "" }, nil, true},
+		{"Missing Secrets", func(config *common.ConfigurationStruct) { config.NATS.SecretPath = "missing" }, nil, true},
+		{"Missing CA", func(config *common.ConfigurationStruct) {
+			config.NATS.UseTLS = true
+			config.NATS.CACertFile = "/no/such/ca.pem"
+		}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testConfig := config
+			test.Update(&testConfig)
+			trigger := Trigger{
+				Configuration:  testConfig,
+				EdgeXClients:   common.EdgeXClients{LoggingClient: logClient},
+				SecretProvider: security.NewSecretProvider(logClient, &testConfig),
+			}
+
+			options, err := trigger.connectionOptions()
+			if test.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			natsOptions := nats.GetDefaultOptions()
+			for _, option := range options {
+				require.NoError(t, option(&natsOptions))
+			}
+			test.Check(t, natsOptions)
+		})
+	}
+}
+
+func TestInitializeNoSubject(t *testing.T) {
+	config := common.ConfigurationStruct{
+		Binding: common.BindingInfo{Type: "nats"},
+		NATS:    common.NATSInfo{URL: "nats://localhost:4222"},
+	}
+	trigger := Trigger{Configuration: config, EdgeXClients: common.EdgeXClients{LoggingClient: logClient}}
+	assert.Error(t, trigger.Initialize(&sync.WaitGroup{}, context.Background()))
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-messaging/pkg/types"
+	"github.com/google/uuid"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/transforms"
+)
+
+// Trigger implements Trigger to support messages received from WebSocket clients. Like the HTTP Trigger, the output
+// data is sent back to the client the message was received from.
+type Trigger struct {
+	Configuration common.ConfigurationStruct
+	Runtime       *runtime.GolangRuntime
+	EdgeXClients  common.EdgeXClients
+	Broadcaster   *transforms.WebSocketBroadcaster
+}
+
+// Initialize passes the messages received from the clients of the WebSocket route to the pipeline
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) error {
+	logger := trigger.EdgeXClients.LoggingClient
+
+	logger.Info("Initializing WebSocket Trigger")
+	if trigger.Broadcaster == nil {
+		return errors.New("the WebSocket route isn't enabled")
+	}
+	trigger.Broadcaster.SetMessageHandler(trigger.processMessage)
+	logger.Info("WebSocket Trigger Initialized")
+
+	return nil
+}
+
+func (trigger *Trigger) processMessage(data []byte) []byte {
+	logger := trigger.EdgeXClients.LoggingClient
+
+	correlationID := uuid.New().String()
+	edgexContext := &appcontext.Context{
+		CorrelationID:         correlationID,
+		Configuration:         trigger.Configuration,
+		LoggingClient:         trigger.EdgeXClients.LoggingClient,
+		EventClient:           trigger.EdgeXClients.EventClient,
+		ValueDescriptorClient: trigger.EdgeXClients.ValueDescriptorClient,
+		CommandClient:         trigger.EdgeXClients.CommandClient,
+		NotificationsClient:   trigger.EdgeXClients.NotificationsClient,
+	}
+
+	logger.Trace("Received message from WebSocket", clients.CorrelationHeader, correlationID)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationID,
+		Payload:       data,
+		ContentType:   clients.ContentTypeJSON,
+	}
+
+	messageError := trigger.Runtime.ProcessMessage(edgexContext, envelope)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		return nil
+	}
+
+	if edgexContext.OutputData != nil {
+		logger.Trace("Sent WebSocket response message", clients.CorrelationHeader, correlationID)
+	}
+
+	return edgexContext.OutputData
+}
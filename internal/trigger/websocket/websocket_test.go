@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/runtime"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/transforms"
+)
+
+var logClient logger.LoggingClient
+
+func init() {
+	logClient = logger.NewClient("app_functions_sdk_go", false, "./test.log", "DEBUG")
+}
+
+const eventPayload = `{"id":"5888dea1bd36573f4681d6f9","device":"livingroomthermostat","readings":[{"name":"temperature","value":"38","device":"livingroomthermostat"}]}`
+
+func newTestTrigger(transform appcontext.AppFunction) *Trigger {
+	golangRuntime := &runtime.GolangRuntime{}
+	golangRuntime.Initialize(nil, nil)
+	golangRuntime.SetTransforms([]appcontext.AppFunction{transform})
+
+	return &Trigger{
+		Runtime:      golangRuntime,
+		EdgeXClients: common.EdgeXClients{LoggingClient: logClient},
+		Broadcaster:  transforms.NewWebSocketBroadcaster(transforms.WebSocketConfig{}, logClient),
+	}
+}
+
+func TestProcessMessage(t *testing.T) {
+	transform := func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+		assert.NotEmpty(t, edgexcontext.CorrelationID)
+		assert.Equal(t, "livingroomthermostat", params[0].(models.Event).Device)
+		edgexcontext.Complete([]byte("output"))
+		return false, nil
+	}
+	trigger := newTestTrigger(transform)
+	require.NoError(t, trigger.Initialize(&sync.WaitGroup{}, context.Background()))
+
+	assert.Equal(t, "output", string(trigger.processMessage([]byte(eventPayload))))
+}
+
+func TestProcessMessageErrors(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Payload   string
+		Transform appcontext.AppFunction
+	}{
+		{
+			"Pipeline Error",
+			eventPayload,
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				edgexcontext.Complete([]byte("output"))
+				return false, errors.New("export failed")
+			},
+		},
+		{
+			"Bad Payload",
+			"not json",
+			func(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+				require.Fail(t, "Transform should not be called")
+				return true, nil
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger := newTestTrigger(test.Transform)
+			assert.Nil(t, trigger.processMessage([]byte(test.Payload)))
+		})
+	}
+}
+
+func TestInitializeNotEnabled(t *testing.T) {
+	trigger := Trigger{EdgeXClients: common.EdgeXClients{LoggingClient: logClient}}
+	assert.Error(t, trigger.Initialize(&sync.WaitGroup{}, context.Background()))
+}
@@ -38,6 +38,7 @@ type WebServer struct {
 	Config         *common.ConfigurationStruct
 	LoggingClient  logger.LoggingClient
 	router         *mux.Router
+	streamRouter   *mux.Router
 	secretProvider *security.SecretProvider
 }
 
@@ -53,6 +54,7 @@ func NewWebServer(config *common.ConfigurationStruct, secretProvider *security.S
 		Config:         config,
 		LoggingClient:  lc,
 		router:         router,
+		streamRouter:   mux.NewRouter(),
 		secretProvider: secretProvider,
 	}
 
@@ -271,6 +273,22 @@ func (webserver *WebServer) AddRoute(routePath string, handler func(http.Respons
 	return nil
 }
 
+// AddStreamingRoute adds a route whose connections stay open, such as a WebSocket or a Server-Sent Events stream.
+// Unlike the routes added by AddRoute, these aren't bound by the Service.Timeout.
+func (webserver *WebServer) AddStreamingRoute(routePath string, handler func(http.ResponseWriter, *http.Request), methods ...string) error {
+	route := webserver.streamRouter.HandleFunc(routePath, handler).Methods(methods...)
+	if routeErr := route.GetError(); routeErr != nil {
+		return routeErr
+	}
+	return nil
+}
+
+// handler returns the handler serving the streaming routes as they are and all other routes with the timeout
+func (webserver *WebServer) handler(serviceTimeout time.Duration) http.Handler {
+	webserver.streamRouter.NotFoundHandler = http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out")
+	return webserver.streamRouter
+}
+
 // ConfigureStandardRoutes loads up some default routes
 func (webserver *WebServer) ConfigureStandardRoutes() {
 	webserver.LoggingClient.Info("Registering standard routes...")
@@ -341,9 +359,9 @@ func listenAndServe(webserver *WebServer, serviceTimeout time.Duration, errChann
 
 	if webserver.Config.Service.Protocol == "https" {
 		webserver.LoggingClient.Info(fmt.Sprintf("Starting HTTPS Web Server on port :%d", webserver.Config.Service.Port))
-		errChannel <- http.ListenAndServeTLS(p, webserver.Config.Service.HTTPSCert, webserver.Config.Service.HTTPSKey, http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out"))
+		errChannel <- http.ListenAndServeTLS(p, webserver.Config.Service.HTTPSCert, webserver.Config.Service.HTTPSKey, webserver.handler(serviceTimeout))
 	} else {
 		webserver.LoggingClient.Info(fmt.Sprintf("Starting HTTP Web Server on port :%d", webserver.Config.Service.Port))
-		errChannel <- http.ListenAndServe(p, http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out"))
+		errChannel <- http.ListenAndServe(p, webserver.handler(serviceTimeout))
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
 	"github.com/tuanldchainos/app-functions-sdk-go/internal/telemetry"
@@ -109,7 +111,7 @@ func TestConfigureAndConfigRoute(t *testing.T) {
 	rr := httptest.NewRecorder()
 	webserver.router.ServeHTTP(rr, req)
 
-	expected := `{"Writable":{"LogLevel":"","Pipeline":{"ExecutionOrder":"","UseTargetTypeOfByteArray":false,"Functions":null},"StoreAndForward":{"Enabled":false,"RetryInterval":"","MaxRetryCount":0},"InsecureSecrets":null},"Logging":{"EnableRemote":false,"File":""},"Registry":{"Host":"","Port":0,"Type":""},"Service":{"BootTimeout":"","CheckInterval":"","ClientMonitor":"","Host":"","HTTPSCert":"","HTTPSKey":"","Port":0,"Protocol":"","StartupMsg":"","ReadMaxLimit":0,"Timeout":""},"MessageBus":{"PublishHost":{"Host":"","Port":0,"Protocol":""},"SubscribeHost":{"Host":"","Port":0,"Protocol":""},"Type":"","Optional":null},"Binding":{"Type":"","SubscribeTopic":"","PublishTopic":""},"ApplicationSettings":null,"Clients":null,"Database":{"Type":"","Host":"","Port":0,"Timeout":"","Username":"","Password":"","MaxIdle":0,"BatchSize":0},"SecretStore":{"Host":"","Port":0,"Path":"","Protocol":"","Namespace":"","RootCaCertPath":"","ServerName":"","Authentication":{"AuthType":"","AuthToken":""},"AdditionalRetryAttempts":0,"RetryWaitPeriod":"","TokenFile":""}}` + "\n"
+	expected := `{"Writable":{"LogLevel":"","Pipeline":{"ExecutionOrder":"","UseTargetTypeOfByteArray":false,"Functions":null},"StoreAndForward":{"Enabled":false,"RetryInterval":"","MaxRetryCount":0},"InsecureSecrets":null},"Logging":{"EnableRemote":false,"File":""},"Registry":{"Host":"","Port":0,"Type":""},"Service":{"BootTimeout":"","CheckInterval":"","ClientMonitor":"","Host":"","HTTPSCert":"","HTTPSKey":"","Port":0,"Protocol":"","StartupMsg":"","ReadMaxLimit":0,"Timeout":""},"MessageBus":{"PublishHost":{"Host":"","Port":0,"Protocol":""},"SubscribeHost":{"Host":"","Port":0,"Protocol":""},"Type":"","Optional":null},"Binding":{"Type":"","SubscribeTopic":"","PublishTopic":""},"Kafka":{"Brokers":null,"GroupID":"","StartOffset":"","ClientID":"","SASLMechanism":"","SecretPath":"","UseTLS":false,"ClientCertFile":"","ClientKeyFile":"","CACertFile":"","SkipCertVerify":false},"AMQP":{"URL":"","SecretPath":"","PrefetchCount":0,"RequeueOnError":false,"PublishExchange":"","ClientCertFile":"","ClientKeyFile":"","CACertFile":"","SkipCertVerify":false},"NATS":{"URL":"","QueueGroup":"","JetStream":false,"Durable":"","DeliverPolicy":"","MaxDeliver":0,"SecretPath":"","CredentialsFile":"","UseTLS":false,"ClientCertFile":"","ClientKeyFile":"","CACertFile":"","SkipCertVerify":false},"WebSocket":{"Route":"","AllowedOrigins":null,"SendBufferSize":0},"SSE":{"Route":"","AllowedOrigins":null,"ReplayBufferSize":0,"SendBufferSize":0},"GRPC":{"Port":0,"MaxMessageSize":0,"ServerCertFile":"","ServerKeyFile":"","ClientCACertFile":""},"ApplicationSettings":null,"Clients":null,"Database":{"Type":"","Host":"","Port":0,"Timeout":"","Username":"","Password":"","MaxIdle":0,"BatchSize":0},"SecretStore":{"Host":"","Port":0,"Path":"","Protocol":"","Namespace":"","RootCaCertPath":"","ServerName":"","Authentication":{"AuthType":"","AuthToken":""},"AdditionalRetryAttempts":0,"RetryWaitPeriod":"","TokenFile":""}}` + "\n"
 
 	body := rr.Body.String()
 	assert.Equal(t, expected, body)
@@ -137,6 +139,32 @@ func TestConfigureAndMetricsRoute(t *testing.T) {
 	assert.NotNil(t, metrics.CpuBusyAvg, "Expected CpuBusyAvg value of metrics to be not nil")
 }
 
+func TestAddStreamingRoute(t *testing.T) {
+	sp := security.NewSecretProvider(logClient, config)
+	webserver := NewWebServer(config, sp, logClient, mux.NewRouter())
+
+	flushed := false
+	streamHandler := func(w http.ResponseWriter, _ *http.Request) {
+		// The timeout handler's writer can't be flushed, so streams must be served without it
+		_, flushed = w.(http.Flusher)
+	}
+	require.NoError(t, webserver.AddStreamingRoute("/stream", streamHandler, http.MethodGet))
+	require.NoError(t, webserver.AddRoute("/other", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("other"))
+	}, http.MethodGet))
+	assert.Error(t, webserver.AddStreamingRoute("stream", streamHandler), "Expecting an error for a malformed path")
+
+	handler := webserver.handler(time.Second)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.True(t, flushed, "expected the streaming route to be served without the timeout")
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, "other", rr.Body.String())
+}
+
 func TestSetupTriggerRoute(t *testing.T) {
 	sp := newMockSecretProvider(logClient, config)
 	webserver := NewWebServer(config, sp, logClient, mux.NewRouter())
@@ -0,0 +1,328 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+// WindowType Enum for choosing how Aggregator windows advance.
+type WindowType int
+
+const (
+	// TumblingWindow windows do not overlap. Aggregates are emitted once per window when the window closes.
+	TumblingWindow WindowType = iota
+	// SlidingWindow windows advance with every reading. Aggregates are emitted for every reading received.
+	SlidingWindow
+)
+
+// Aggregate functions computed by Aggregator. The name is appended to the reading name of the aggregated
+// readings, i.e. Temperature_mean.
+const (
+	AggregateMin    = "min"
+	AggregateMax    = "max"
+	AggregateMean   = "mean"
+	AggregateSum    = "sum"
+	AggregateCount  = "count"
+	AggregateFirst  = "first"
+	AggregateLast   = "last"
+	AggregateStdDev = "stddev"
+)
+
+// AllAggregates lists every aggregate function supported by Aggregator, in the order they are emitted.
+var AllAggregates = []string{
+	AggregateMin,
+	AggregateMax,
+	AggregateMean,
+	AggregateSum,
+	AggregateCount,
+	AggregateFirst,
+	AggregateLast,
+	AggregateStdDev,
+}
+
+// Aggregator computes aggregates over windows of numeric readings grouped by device and reading name.
+type Aggregator struct {
+	// Aggregates limits which aggregate functions are emitted. All are emitted if empty.
+	Aggregates     []string
+	windowType     WindowType
+	windowDuration time.Duration
+	windowCount    int
+	windows        map[string]*aggregateWindow
+	mutex          sync.Mutex
+	now            func() time.Time
+}
+
+type aggregateSample struct {
+	value    float64
+	received time.Time
+}
+
+type aggregateWindow struct {
+	start   time.Time
+	samples []aggregateSample
+}
+
+// NewAggregateByTime creates, initializes and returns a new instance of Aggregator using time based windows.
+// windowSize is the duration of each window (i.e. 1m). The optional aggregates limit which aggregate functions are
+// emitted and must be in AllAggregates.
+func NewAggregateByTime(windowType WindowType, windowSize string, aggregates ...string) (*Aggregator, error) {
+	duration, err := time.ParseDuration(windowSize)
+	if err != nil {
+		return nil, err
+	}
+	if duration <= 0 {
+		return nil, errors.New("window size must be greater than zero")
+	}
+
+	return newAggregator(windowType, duration, 0, aggregates)
+}
+
+// NewAggregateByCount creates, initializes and returns a new instance of Aggregator using count based windows.
+// windowSize is the number of readings in each window. The optional aggregates limit which aggregate functions are
+// emitted and must be in AllAggregates.
+func NewAggregateByCount(windowType WindowType, windowSize int, aggregates ...string) (*Aggregator, error) {
+	if windowSize <= 0 {
+		return nil, errors.New("window size must be greater than zero")
+	}
+
+	return newAggregator(windowType, 0, windowSize, aggregates)
+}
+
+func newAggregator(windowType WindowType, duration time.Duration, count int, aggregates []string) (*Aggregator, error) {
+	if windowType != TumblingWindow && windowType != SlidingWindow {
+		return nil, fmt.Errorf("unsupported window type %d", windowType)
+	}
+	if err := validateAggregates(aggregates); err != nil {
+		return nil, err
+	}
+
+	return &Aggregator{
+		Aggregates:     aggregates,
+		windowType:     windowType,
+		windowDuration: duration,
+		windowCount:    count,
+		windows:        make(map[string]*aggregateWindow),
+		now:            time.Now,
+	}, nil
+}
+
+// validateAggregates returns an error for the first aggregate that is not in AllAggregates
+func validateAggregates(aggregates []string) error {
+	for _, aggregate := range aggregates {
+		supported := false
+		for _, name := range AllAggregates {
+			if aggregate == name {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("unsupported aggregate '%s', must be one of %s", aggregate, strings.Join(AllAggregates, ", "))
+		}
+	}
+	return nil
+}
+
+// ParseWindowType converts "tumbling" or "sliding" into the matching WindowType.
+func ParseWindowType(value string) (WindowType, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "tumbling":
+		return TumblingWindow, nil
+	case "sliding":
+		return SlidingWindow, nil
+	}
+	return TumblingWindow, fmt.Errorf("unsupported window type '%s', must be tumbling or sliding", value)
+}
+
+// Aggregate adds the numeric readings of the received Event to their windows, grouped by device and reading name.
+// When windows are ready a new Event for the device is returned containing a reading for each aggregate, named
+// <reading name>_<aggregate>. Tumbling windows are ready once they close, which for time windows is detected when the
+// next reading for the same device and reading name arrives. Sliding time windows are ready on every reading and sliding
+// count windows once they are full. The pipeline is stopped when no window is ready.
+// Non-numeric readings are ignored. This function will return an error and stop the pipeline if a non-edgex event is
+// received, if no data is received or if Aggregates holds an unsupported aggregate.
+func (aggregator *Aggregator) Aggregate(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("no Event Received")
+	}
+	if err := validateAggregates(aggregator.Aggregates); err != nil {
+		return false, err
+	}
+
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("type received is not an Event")
+	}
+
+	edgexcontext.LoggingClient.Debug("Aggregating readings")
+
+	aggregator.mutex.Lock()
+	defer aggregator.mutex.Unlock()
+
+	now := aggregator.now()
+	result := models.Event{
+		Device:   event.Device,
+		Origin:   now.UnixNano(),
+		Readings: []models.Reading{},
+	}
+
+	for _, reading := range event.Readings {
+		value, err := strconv.ParseFloat(reading.Value, 64)
+		if err != nil {
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("Skipping non-numeric reading '%s'", reading.Name))
+			continue
+		}
+
+		samples := aggregator.add(event.Device+"/"+reading.Name, aggregateSample{value: value, received: now})
+		if len(samples) == 0 {
+			continue
+		}
+		result.Readings = append(result.Readings, aggregator.aggregateReadings(event.Device, reading.Name, now, samples)...)
+	}
+
+	if len(result.Readings) == 0 {
+		return false, nil
+	}
+
+	edgexcontext.LoggingClient.Debug("Forwarding aggregated readings")
+	return true, result
+}
+
+// add appends the sample to the window for key and returns the samples to aggregate, if the window is ready.
+func (aggregator *Aggregator) add(key string, sample aggregateSample) []aggregateSample {
+	if aggregator.windows == nil {
+		aggregator.windows = make(map[string]*aggregateWindow)
+	}
+
+	window, ok := aggregator.windows[key]
+	if !ok {
+		window = &aggregateWindow{start: sample.received}
+		aggregator.windows[key] = window
+	}
+
+	if aggregator.windowType == SlidingWindow {
+		window.samples = append(window.samples, sample)
+		if aggregator.windowDuration > 0 {
+			cutoff := sample.received.Add(-aggregator.windowDuration)
+			index := sort.Search(len(window.samples), func(i int) bool {
+				return window.samples[i].received.After(cutoff)
+			})
+			window.samples = window.samples[index:]
+			return copySamples(window.samples)
+		}
+
+		if len(window.samples) > aggregator.windowCount {
+			window.samples = window.samples[len(window.samples)-aggregator.windowCount:]
+		}
+		if len(window.samples) < aggregator.windowCount {
+			return nil
+		}
+		return copySamples(window.samples)
+	}
+
+	if aggregator.windowDuration > 0 {
+		if sample.received.Sub(window.start) < aggregator.windowDuration {
+			window.samples = append(window.samples, sample)
+			return nil
+		}
+		closed := window.samples
+		window.start = sample.received
+		window.samples = []aggregateSample{sample}
+		return closed
+	}
+
+	window.samples = append(window.samples, sample)
+	if len(window.samples) < aggregator.windowCount {
+		return nil
+	}
+	closed := window.samples
+	window.samples = nil
+	return closed
+}
+
+func (aggregator *Aggregator) aggregateReadings(device string, name string, now time.Time, samples []aggregateSample) []models.Reading {
+	aggregates := aggregator.Aggregates
+	if len(aggregates) == 0 {
+		aggregates = AllAggregates
+	}
+
+	min := samples[0].value
+	max := samples[0].value
+	sum := 0.0
+	for _, sample := range samples {
+		min = math.Min(min, sample.value)
+		max = math.Max(max, sample.value)
+		sum += sample.value
+	}
+	count := float64(len(samples))
+	mean := sum / count
+
+	variance := 0.0
+	for _, sample := range samples {
+		variance += (sample.value - mean) * (sample.value - mean)
+	}
+	stddev := math.Sqrt(variance / count)
+
+	readings := make([]models.Reading, 0, len(aggregates))
+	for _, aggregate := range aggregates {
+		var value float64
+		switch aggregate {
+		case AggregateMin:
+			value = min
+		case AggregateMax:
+			value = max
+		case AggregateMean:
+			value = mean
+		case AggregateSum:
+			value = sum
+		case AggregateCount:
+			value = count
+		case AggregateFirst:
+			value = samples[0].value
+		case AggregateLast:
+			value = samples[len(samples)-1].value
+		case AggregateStdDev:
+			value = stddev
+		}
+
+		readings = append(readings, models.Reading{
+			Device: device,
+			Name:   name + "_" + aggregate,
+			Value:  strconv.FormatFloat(value, 'f', -1, 64),
+			Origin: now.UnixNano(),
+		})
+	}
+
+	return readings
+}
+
+func copySamples(samples []aggregateSample) []aggregateSample {
+	copied := make([]aggregateSample, len(samples))
+	copy(copied, samples)
+	return copied
+}
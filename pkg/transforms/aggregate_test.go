@@ -0,0 +1,194 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAggregateEvent(device string, value string) models.Event {
+	return models.Event{
+		Device:   device,
+		Readings: []models.Reading{{Name: descriptor1, Value: value}},
+	}
+}
+
+func aggregateValues(t *testing.T, result interface{}) map[string]string {
+	event, ok := result.(models.Event)
+	require.True(t, ok, "Expected result to be an Event")
+	values := make(map[string]string)
+	for _, reading := range event.Readings {
+		values[reading.Name] = reading.Value
+	}
+	return values
+}
+
+func TestNewAggregatorValidation(t *testing.T) {
+	_, err := NewAggregateByTime(TumblingWindow, "bogus")
+	assert.Error(t, err)
+	_, err = NewAggregateByTime(TumblingWindow, "0s")
+	assert.Error(t, err)
+	_, err = NewAggregateByCount(SlidingWindow, 0)
+	assert.Error(t, err)
+	_, err = NewAggregateByCount(WindowType(5), 10)
+	assert.Error(t, err)
+	_, err = NewAggregateByCount(TumblingWindow, 10, AggregateMin, "avg")
+	assert.EqualError(t, err, "unsupported aggregate 'avg', must be one of min, max, mean, sum, count, first, last, stddev")
+	_, err = NewAggregateByTime(TumblingWindow, "1m", "Mean")
+	assert.Error(t, err)
+	aggregator, err := NewAggregateByTime(TumblingWindow, "1m", AggregateMean, AggregateMax)
+	require.NoError(t, err)
+	assert.Equal(t, []string{AggregateMean, AggregateMax}, aggregator.Aggregates)
+
+	aggregator.Aggregates = []string{"avg"}
+	continuePipeline, result := aggregator.Aggregate(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	windowType, err := ParseWindowType(" Sliding ")
+	require.NoError(t, err)
+	assert.Equal(t, SlidingWindow, windowType)
+	_, err = ParseWindowType("hopping")
+	assert.Error(t, err)
+}
+
+func TestAggregateTumblingCount(t *testing.T) {
+	aggregator, err := NewAggregateByCount(TumblingWindow, 4)
+	require.NoError(t, err)
+
+	for _, value := range []string{"2", "4", "4"} {
+		continuePipeline, result := aggregator.Aggregate(context, newAggregateEvent(devID1, value))
+		assert.False(t, continuePipeline, "Pipeline should stop until the window is full")
+		assert.Nil(t, result)
+	}
+
+	// A different device does not contribute to the window of devID1
+	continuePipeline, _ := aggregator.Aggregate(context, newAggregateEvent(devID2, "100"))
+	assert.False(t, continuePipeline)
+
+	continuePipeline, result := aggregator.Aggregate(context, newAggregateEvent(devID1, "6"))
+	require.True(t, continuePipeline, "Pipeline should continue once the window is full")
+	assert.Equal(t, devID1, result.(models.Event).Device)
+
+	values := aggregateValues(t, result)
+	assert.Len(t, values, len(AllAggregates))
+	assert.Equal(t, "2", values[descriptor1+"_min"])
+	assert.Equal(t, "6", values[descriptor1+"_max"])
+	assert.Equal(t, "4", values[descriptor1+"_mean"])
+	assert.Equal(t, "16", values[descriptor1+"_sum"])
+	assert.Equal(t, "4", values[descriptor1+"_count"])
+	assert.Equal(t, "2", values[descriptor1+"_first"])
+	assert.Equal(t, "6", values[descriptor1+"_last"])
+	assert.Equal(t, "1.4142135623730951", values[descriptor1+"_stddev"])
+
+	// Window has been reset
+	continuePipeline, _ = aggregator.Aggregate(context, newAggregateEvent(devID1, "1"))
+	assert.False(t, continuePipeline)
+}
+
+func TestAggregateSlidingCount(t *testing.T) {
+	aggregator, err := NewAggregateByCount(SlidingWindow, 2)
+	require.NoError(t, err)
+	aggregator.Aggregates = []string{AggregateSum, AggregateLast}
+
+	continuePipeline, _ := aggregator.Aggregate(context, newAggregateEvent(devID1, "1"))
+	assert.False(t, continuePipeline, "Pipeline should stop until the window is full")
+
+	continuePipeline, result := aggregator.Aggregate(context, newAggregateEvent(devID1, "2"))
+	require.True(t, continuePipeline)
+	values := aggregateValues(t, result)
+	assert.Equal(t, map[string]string{descriptor1 + "_sum": "3", descriptor1 + "_last": "2"}, values)
+
+	continuePipeline, result = aggregator.Aggregate(context, newAggregateEvent(devID1, "5"))
+	require.True(t, continuePipeline)
+	values = aggregateValues(t, result)
+	assert.Equal(t, map[string]string{descriptor1 + "_sum": "7", descriptor1 + "_last": "5"}, values)
+}
+
+func TestAggregateTumblingTime(t *testing.T) {
+	aggregator, err := NewAggregateByTime(TumblingWindow, "10s")
+	require.NoError(t, err)
+	aggregator.Aggregates = []string{AggregateCount, AggregateMean}
+
+	now := time.Unix(1000, 0)
+	aggregator.now = func() time.Time { return now }
+
+	for _, value := range []string{"1", "2", "3"} {
+		continuePipeline, _ := aggregator.Aggregate(context, newAggregateEvent(devID1, value))
+		assert.False(t, continuePipeline, "Pipeline should stop until the window closes")
+		now = now.Add(3 * time.Second)
+	}
+
+	now = now.Add(5 * time.Second)
+	continuePipeline, result := aggregator.Aggregate(context, newAggregateEvent(devID1, "100"))
+	require.True(t, continuePipeline, "Pipeline should continue once the window has closed")
+	values := aggregateValues(t, result)
+	assert.Equal(t, "3", values[descriptor1+"_count"], "Reading that closed the window starts the next one")
+	assert.Equal(t, "2", values[descriptor1+"_mean"])
+	assert.Equal(t, now.UnixNano(), result.(models.Event).Origin)
+}
+
+func TestAggregateSlidingTime(t *testing.T) {
+	aggregator, err := NewAggregateByTime(SlidingWindow, "10s")
+	require.NoError(t, err)
+	aggregator.Aggregates = []string{AggregateCount, AggregateMax}
+
+	now := time.Unix(1000, 0)
+	aggregator.now = func() time.Time { return now }
+
+	expected := []struct {
+		value string
+		count string
+		max   string
+	}{
+		{"5", "1", "5"},
+		{"3", "2", "5"},
+		{"4", "2", "4"},
+		{"1", "2", "4"},
+	}
+
+	for _, step := range expected {
+		continuePipeline, result := aggregator.Aggregate(context, newAggregateEvent(devID1, step.value))
+		require.True(t, continuePipeline, "Sliding time windows emit on every reading")
+		values := aggregateValues(t, result)
+		assert.Equal(t, step.count, values[descriptor1+"_count"])
+		assert.Equal(t, step.max, values[descriptor1+"_max"])
+		now = now.Add(5 * time.Second)
+	}
+}
+
+func TestAggregateNonNumericAndBadInput(t *testing.T) {
+	aggregator, err := NewAggregateByCount(TumblingWindow, 1)
+	require.NoError(t, err)
+
+	continuePipeline, result := aggregator.Aggregate(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no Event Received")
+
+	continuePipeline, result = aggregator.Aggregate(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "type received is not an Event")
+
+	continuePipeline, result = aggregator.Aggregate(context, newAggregateEvent(devID1, "on"))
+	assert.False(t, continuePipeline, "Non-numeric readings are ignored")
+	assert.Nil(t, result)
+}
@@ -0,0 +1,273 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/streadway/amqp"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	defaultAMQPConfirmTimeout = 30 * time.Second
+	amqpHeartbeat             = 10 * time.Second
+)
+
+// AMQPConfig contains the settings of the AMQPSender
+type AMQPConfig struct {
+	// URL is the amqp:// or amqps:// URL of the broker, including the virtual host, i.e. amqp://rabbitmq:5672/edgex
+	URL string
+	// Exchange is the exchange the data is published to, the default exchange when not set
+	Exchange string
+	// RoutingKey is the routing key, which can hold placeholders, i.e. site1.{device}.{reading}, which are resolved
+	// from the data and the context for each message
+	RoutingKey string
+	// Persistent publishes the messages using persistent delivery, so they survive a broker restart
+	Persistent bool
+	// ContentType is the content type of the messages, none when not set
+	ContentType string
+	// SecretPath is the path in the secret store holding the username and password, which override those in the
+	// URL, when set
+	SecretPath string
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and key used for client authentication and
+	// CACertFile is the certificate authority used to verify the broker, the system's when not set. They are only
+	// used with amqps:// URLs.
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+	// ConfirmTimeout is how long to wait for the broker to confirm a message, 30 seconds when not set
+	ConfirmTimeout time.Duration
+}
+
+// amqpChannel is the part of amqp.Channel used by the AMQPSender
+type amqpChannel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	Publish(exchange string, key string, mandatory bool, immediate bool, msg amqp.Publishing) error
+}
+
+// AMQPSender publishes data to an AMQP 0.9.1 broker, such as RabbitMQ, using publisher confirms
+type AMQPSender struct {
+	config         AMQPConfig
+	persistOnError bool
+	tlsConfig      *tls.Config
+	mutex          sync.Mutex
+	connection     io.Closer
+	channel        amqpChannel
+	confirms       chan amqp.Confirmation
+	closed         chan *amqp.Error
+	// openChannel connects to the broker, returning the channel used to publish and the connection
+	openChannel func(edgexcontext *appcontext.Context) (amqpChannel, io.Closer, error)
+}
+
+// NewAMQPSender creates, initializes and returns a new instance of AMQPSender. An error is returned if the URL isn't
+// a valid AMQP URL or the certificates can't be loaded.
+func NewAMQPSender(config AMQPConfig, persistOnError bool) (*AMQPSender, error) {
+	uri, err := amqp.ParseURI(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AMQP URL: %s", err.Error())
+	}
+
+	sender := &AMQPSender{config: config, persistOnError: persistOnError}
+	sender.openChannel = sender.dial
+
+	if uri.Scheme == "amqps" {
+		sender.tlsConfig, err = config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sender, nil
+}
+
+func (config AMQPConfig) tlsConfig() (*tls.Config, error) {
+	return util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+}
+
+func (sender *AMQPSender) dial(edgexcontext *appcontext.Context) (amqpChannel, io.Closer, error) {
+	config := amqp.Config{Heartbeat: amqpHeartbeat, Locale: "en_US"}
+	if sender.tlsConfig != nil {
+		// The server name is set on the TLS configuration when dialing, so each connection gets its own copy
+		config.TLSClientConfig = sender.tlsConfig.Clone()
+	}
+
+	if sender.config.SecretPath != "" {
+		secrets, err := getSecrets(edgexcontext, sender.config.SecretPath, "username", "password")
+		if err != nil {
+			return nil, nil, err
+		}
+		config.SASL = []amqp.Authentication{&amqp.PlainAuth{Username: secrets["username"], Password: secrets["password"]}}
+	}
+
+	connection, err := amqp.DialConfig(sender.config.URL, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	channel, err := connection.Channel()
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+
+	return channel, connection, nil
+}
+
+// connect opens the channel, putting it in confirm mode, unless it is already open. It must be called with the
+// mutex held.
+func (sender *AMQPSender) connect(edgexcontext *appcontext.Context) error {
+	if sender.channel != nil {
+		select {
+		case err := <-sender.closed:
+			edgexcontext.LoggingClient.Warn(fmt.Sprintf("AMQP channel closed, reconnecting: %v", err))
+			sender.disconnect()
+		default:
+			return nil
+		}
+	}
+
+	edgexcontext.LoggingClient.Info("Connecting to AMQP broker")
+	channel, connection, err := sender.openChannel(edgexcontext)
+	if err != nil {
+		return err
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		connection.Close()
+		return fmt.Errorf("unable to enable publisher confirms: %s", err.Error())
+	}
+
+	sender.channel = channel
+	sender.connection = connection
+	sender.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	sender.closed = channel.NotifyClose(make(chan *amqp.Error, 1))
+	return nil
+}
+
+// disconnect closes the connection, so the next message reconnects. It must be called with the mutex held.
+func (sender *AMQPSender) disconnect() {
+	if sender.connection != nil {
+		_ = sender.connection.Close()
+	}
+	sender.channel = nil
+	sender.connection = nil
+}
+
+// publish publishes the message and waits for the broker to confirm it. Messages are published one at a time, so each
+// confirmation belongs to the message just published.
+func (sender *AMQPSender) publish(edgexcontext *appcontext.Context, routingKey string, message amqp.Publishing) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if err := sender.connect(edgexcontext); err != nil {
+		return fmt.Errorf("Could not connect to AMQP broker: %s", err.Error())
+	}
+
+	if err := sender.channel.Publish(sender.config.Exchange, routingKey, false, false, message); err != nil {
+		sender.disconnect()
+		return err
+	}
+
+	timeout := sender.config.ConfirmTimeout
+	if timeout <= 0 {
+		timeout = defaultAMQPConfirmTimeout
+	}
+
+	select {
+	case confirmation, ok := <-sender.confirms:
+		if !ok {
+			sender.disconnect()
+			return errors.New("AMQP channel closed before the message was confirmed")
+		}
+		if !confirmation.Ack {
+			return errors.New("message was rejected by the AMQP broker")
+		}
+		return nil
+	case err := <-sender.closed:
+		sender.disconnect()
+		return fmt.Errorf("AMQP channel closed before the message was confirmed: %v", err)
+	case <-time.After(timeout):
+		// The late confirmation would be taken for the next message's, so start again on a new channel
+		sender.disconnect()
+		return fmt.Errorf("message wasn't confirmed by the AMQP broker within %v", timeout)
+	}
+}
+
+// AMQPSend publishes data from the previous function to the exchange using the routing key. If no previous function
+// exists, then the event that triggered the pipeline will be used. The correlation ID is set on the message and the
+// context's ExportHeaders, such as the signature, are sent as message headers.
+// The data isn't sent and the pipeline stops if a placeholder in the routing key can't be resolved. If the broker
+// doesn't confirm the message and persistOnError is true and Store and Forward is enabled, the data will be stored
+// for later retry.
+func (sender *AMQPSender) AMQPSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	exportData, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	routingKey, err := resolveDestination(sender.config.RoutingKey, edgexcontext, params[0], "")
+	if err != nil {
+		return false, fmt.Errorf("Could not resolve AMQP routing key: %s", err.Error())
+	}
+
+	message := amqp.Publishing{
+		ContentType:     sender.config.ContentType,
+		ContentEncoding: edgexcontext.ContentEncoding,
+		CorrelationId:   edgexcontext.CorrelationID,
+		Timestamp:       time.Now(),
+		Body:            exportData,
+	}
+	if sender.config.Persistent {
+		message.DeliveryMode = amqp.Persistent
+	}
+	if len(edgexcontext.ExportHeaders) > 0 {
+		message.Headers = make(amqp.Table, len(edgexcontext.ExportHeaders))
+		for name, value := range edgexcontext.ExportHeaders {
+			message.Headers[name] = value
+		}
+	}
+
+	edgexcontext.LoggingClient.Debug("Publishing data to AMQP exchange '" + sender.config.Exchange + "' with routing key " + routingKey)
+	if err := sender.publish(edgexcontext, routingKey, message); err != nil {
+		if sender.persistOnError {
+			edgexcontext.RetryData = exportData
+		}
+		return false, err
+	}
+
+	edgexcontext.LoggingClient.Debug("Sent data to AMQP broker")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "AMQP", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
@@ -0,0 +1,389 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// Avro houses the schema used to encode and decode Avro
+type Avro struct {
+	// OutputEvent makes DecodeWithAvro return a models.Event rather than JSON, for schemas that follow the structure
+	// of an EdgeX Event
+	OutputEvent bool
+	codec       *goavro.Codec
+	schema      interface{}
+	namedTypes  map[string]interface{}
+}
+
+// NewAvro creates, initializes and returns a new instance of Avro for the schema.
+func NewAvro(schema string) (*Avro, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema: %s", err.Error())
+	}
+
+	avro := &Avro{codec: codec, namedTypes: make(map[string]interface{})}
+	if err := json.Unmarshal([]byte(schema), &avro.schema); err != nil {
+		// A schema consisting of a primitive type name alone isn't JSON
+		avro.schema = strings.TrimSpace(schema)
+	}
+	avro.collectNamedTypes(avro.schema, "")
+	return avro, nil
+}
+
+// NewAvroFromFile creates, initializes and returns a new instance of Avro for the schema in the file.
+func NewAvroFromFile(path string) (*Avro, error) {
+	schema, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read Avro schema file: %s", err.Error())
+	}
+	return NewAvro(string(schema))
+}
+
+// EncodeWithAvro encodes the data received from the previous function with the schema and returns the Avro binary
+// encoding as a []byte. An EdgeX Event or any other value that can be marshaled to JSON is converted by matching its
+// JSON field names to the schema, a string or []byte must hold JSON. Values for unions don't need to be wrapped with
+// their type name, the first member of the union matching the value is used.
+// This function will return an error and stop the pipeline if no data is received or the data doesn't match the
+// schema.
+func (avro *Avro) EncodeWithAvro(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Encoding with Avro")
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// Numbers are decoded as json.Number so 64 bit integers, such as origin, don't lose precision
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return false, fmt.Errorf("unable to encode data with Avro, expecting JSON: %s", err.Error())
+	}
+
+	native, err := avro.toNative(avro.schema, "", value)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert data to match Avro schema: %s", err.Error())
+	}
+
+	encoded, err := avro.codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode data with Avro: %s", err.Error())
+	}
+	return true, encoded
+}
+
+// DecodeWithAvro decodes the Avro binary encoding received from the previous function with the schema and returns
+// it as JSON in a []byte, or as a models.Event when OutputEvent is set. Union values are not wrapped with their type
+// name.
+// This function will return an error and stop the pipeline if no data is received or the data can't be decoded.
+func (avro *Avro) DecodeWithAvro(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Decoding with Avro")
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	native, _, err := avro.codec.NativeFromBinary(data)
+	if err != nil {
+		return false, fmt.Errorf("unable to decode data with Avro: %s", err.Error())
+	}
+
+	return decodedOutput(avro.fromNative(avro.schema, "", native), avro.OutputEvent)
+}
+
+// collectNamedTypes records the records, enums and fixed types defined in the schema by name and full name, so they
+// can be resolved when referenced by name
+func (avro *Avro) collectNamedTypes(schema interface{}, namespace string) {
+	switch typed := schema.(type) {
+	case []interface{}:
+		for _, member := range typed {
+			avro.collectNamedTypes(member, namespace)
+		}
+	case map[string]interface{}:
+		name, _ := typed["name"].(string)
+		if name != "" {
+			if ns, ok := typed["namespace"].(string); ok {
+				namespace = ns
+			}
+			fullName := name
+			if !strings.Contains(name, ".") && namespace != "" {
+				fullName = namespace + "." + name
+			}
+			avro.namedTypes[name] = typed
+			avro.namedTypes[fullName] = typed
+		}
+		if fields, ok := typed["fields"].([]interface{}); ok {
+			for _, field := range fields {
+				if fieldMap, ok := field.(map[string]interface{}); ok {
+					avro.collectNamedTypes(fieldMap["type"], namespace)
+				}
+			}
+		}
+		avro.collectNamedTypes(typed["items"], namespace)
+		avro.collectNamedTypes(typed["values"], namespace)
+		if _, ok := typed["type"].(map[string]interface{}); ok {
+			avro.collectNamedTypes(typed["type"], namespace)
+		}
+	}
+}
+
+// resolve returns the type of the schema, i.e. record or long, along with the definition of named types
+func (avro *Avro) resolve(schema interface{}) (string, map[string]interface{}) {
+	switch typed := schema.(type) {
+	case string:
+		if named, ok := avro.namedTypes[typed].(map[string]interface{}); ok {
+			return avro.resolve(named)
+		}
+		return typed, nil
+	case map[string]interface{}:
+		if nested, ok := typed["type"].(map[string]interface{}); ok {
+			return avro.resolve(nested)
+		}
+		typeName, _ := typed["type"].(string)
+		switch typeName {
+		case "record", "error", "enum", "fixed", "array", "map":
+			return typeName, typed
+		}
+		// A primitive type with attributes such as a logicalType
+		return avro.resolve(typeName)
+	case []interface{}:
+		return "union", nil
+	}
+	return "", nil
+}
+
+// unionName returns the name goavro uses to identify the member of a union
+func (avro *Avro) unionName(schema interface{}, namespace string) string {
+	typeName, definition := avro.resolve(schema)
+	if definition == nil {
+		return typeName
+	}
+	name, _ := definition["name"].(string)
+	if name == "" {
+		return typeName
+	}
+	if ns, ok := definition["namespace"].(string); ok {
+		namespace = ns
+	}
+	if !strings.Contains(name, ".") && namespace != "" {
+		return namespace + "." + name
+	}
+	return name
+}
+
+// matches reports whether the JSON value can be converted to the schema, used to select the member of a union
+func (avro *Avro) matches(schema interface{}, value interface{}) bool {
+	typeName, _ := avro.resolve(schema)
+	switch value.(type) {
+	case nil:
+		return typeName == "null"
+	case bool:
+		return typeName == "boolean"
+	case json.Number:
+		if typeName == "int" || typeName == "long" {
+			_, err := value.(json.Number).Int64()
+			return err == nil
+		}
+		return typeName == "float" || typeName == "double"
+	case string:
+		return typeName == "string" || typeName == "enum" || typeName == "bytes" || typeName == "fixed"
+	case []interface{}:
+		return typeName == "array"
+	case map[string]interface{}:
+		return typeName == "record" || typeName == "error" || typeName == "map"
+	}
+	return false
+}
+
+// toNative converts the JSON value to the native form goavro expects for the schema
+func (avro *Avro) toNative(schema interface{}, namespace string, value interface{}) (interface{}, error) {
+	typeName, definition := avro.resolve(schema)
+	if definition != nil {
+		if ns, ok := definition["namespace"].(string); ok {
+			namespace = ns
+		}
+	}
+
+	switch typeName {
+	case "union":
+		members := schema.([]interface{})
+		for _, member := range members {
+			if avro.matches(member, value) {
+				if value == nil {
+					return nil, nil
+				}
+				native, err := avro.toNative(member, namespace, value)
+				if err != nil {
+					return nil, err
+				}
+				return goavro.Union(avro.unionName(member, namespace), native), nil
+			}
+		}
+		return nil, fmt.Errorf("'%v' doesn't match any member of union %v", value, members)
+
+	case "record", "error":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("'%v' is not an object for record %v", value, definition["name"])
+		}
+		fields, _ := definition["fields"].([]interface{})
+		record := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			fieldMap, _ := field.(map[string]interface{})
+			name, _ := fieldMap["name"].(string)
+			fieldValue, ok := object[name]
+			if !ok {
+				if _, hasDefault := fieldMap["default"]; hasDefault {
+					continue
+				}
+				// A missing optional field is null
+				fieldValue = nil
+			}
+			native, err := avro.toNative(fieldMap["type"], namespace, fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s': %s", name, err.Error())
+			}
+			record[name] = native
+		}
+		return record, nil
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("'%v' is not an array", value)
+		}
+		natives := make([]interface{}, len(items))
+		for index, item := range items {
+			native, err := avro.toNative(definition["items"], namespace, item)
+			if err != nil {
+				return nil, err
+			}
+			natives[index] = native
+		}
+		return natives, nil
+
+	case "map":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("'%v' is not an object for map", value)
+		}
+		natives := make(map[string]interface{}, len(object))
+		for key, item := range object {
+			native, err := avro.toNative(definition["values"], namespace, item)
+			if err != nil {
+				return nil, err
+			}
+			natives[key] = native
+		}
+		return natives, nil
+
+	case "int", "long":
+		if number, ok := value.(json.Number); ok {
+			return number.Int64()
+		}
+	case "float", "double":
+		if number, ok := value.(json.Number); ok {
+			return number.Float64()
+		}
+	case "bytes", "fixed":
+		// []byte values, such as the BinaryValue of a reading, are marshaled to JSON as base64
+		if text, ok := value.(string); ok {
+			if decoded, err := base64.StdEncoding.DecodeString(text); err == nil {
+				return decoded, nil
+			}
+			return []byte(text), nil
+		}
+	}
+
+	return value, nil
+}
+
+// fromNative converts the native form returned by goavro for the schema into values that can be marshaled to JSON,
+// unwrapping union values
+func (avro *Avro) fromNative(schema interface{}, namespace string, value interface{}) interface{} {
+	typeName, definition := avro.resolve(schema)
+	if definition != nil {
+		if ns, ok := definition["namespace"].(string); ok {
+			namespace = ns
+		}
+	}
+
+	switch typeName {
+	case "union":
+		wrapped, ok := value.(map[string]interface{})
+		if !ok || len(wrapped) != 1 {
+			return value
+		}
+		for _, member := range schema.([]interface{}) {
+			if inner, ok := wrapped[avro.unionName(member, namespace)]; ok {
+				return avro.fromNative(member, namespace, inner)
+			}
+		}
+
+	case "record", "error":
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		fields, _ := definition["fields"].([]interface{})
+		for _, field := range fields {
+			fieldMap, _ := field.(map[string]interface{})
+			name, _ := fieldMap["name"].(string)
+			if fieldValue, ok := record[name]; ok {
+				record[name] = avro.fromNative(fieldMap["type"], namespace, fieldValue)
+			}
+		}
+
+	case "array":
+		if items, ok := value.([]interface{}); ok {
+			for index, item := range items {
+				items[index] = avro.fromNative(definition["items"], namespace, item)
+			}
+		}
+
+	case "map":
+		if object, ok := value.(map[string]interface{}); ok {
+			for key, item := range object {
+				object[key] = avro.fromNative(definition["values"], namespace, item)
+			}
+		}
+	}
+
+	return value
+}
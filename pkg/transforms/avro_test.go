@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const eventAvroSchema = `{
+  "type": "record", "name": "Event", "namespace": "org.edgex",
+  "fields": [
+    {"name": "device", "type": "string"},
+    {"name": "origin", "type": "long"},
+    {"name": "readings", "type": {"type": "array", "items": {
+      "type": "record", "name": "Reading",
+      "fields": [
+        {"name": "name", "type": "string"},
+        {"name": "value", "type": ["null", "string"], "default": null},
+        {"name": "binaryValue", "type": ["null", "bytes"], "default": null},
+        {"name": "mediaType", "type": ["null", "string"], "default": null},
+        {"name": "origin", "type": ["null", "long", "double"]}
+      ]
+    }}},
+    {"name": "tags", "type": {"type": "map", "values": "string"}, "default": {}},
+    {"name": "location", "type": ["null", "Reading"], "default": null}
+  ]
+}`
+
+func TestNewAvroInvalid(t *testing.T) {
+	_, err := NewAvro(`{"type": "record"}`)
+	assert.Error(t, err)
+
+	_, err = NewAvroFromFile("/does/not/exist.avsc")
+	assert.Error(t, err)
+}
+
+func TestAvroEventRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avro")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "event.avsc")
+	require.NoError(t, ioutil.WriteFile(path, []byte(eventAvroSchema), 0644))
+
+	avro, err := NewAvroFromFile(path)
+	require.NoError(t, err)
+
+	event := models.Event{
+		ID:     "ignored",
+		Device: devID1,
+		Origin: 1577836800123456789,
+		Readings: []models.Reading{
+			{Name: descriptor1, Value: "21.5", Origin: 1577836800123456789},
+			{Name: descriptor2, BinaryValue: []byte{1, 2, 3}, MediaType: "application/octet-stream"},
+		},
+	}
+	continuePipeline, result := avro.EncodeWithAvro(context, event)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	encoded := result.([]byte)
+
+	continuePipeline, result = avro.DecodeWithAvro(context, encoded)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	expected := `{"device":"id1","origin":1577836800123456789,"tags":{},"location":null,"readings":[` +
+		`{"name":"Descriptor1","value":"21.5","binaryValue":null,"mediaType":null,"origin":1577836800123456789},` +
+		`{"name":"Descriptor2","value":null,"binaryValue":"AQID","mediaType":"application/octet-stream","origin":null}]}`
+	assert.JSONEq(t, expected, string(result.([]byte)))
+
+	avro.OutputEvent = true
+	continuePipeline, result = avro.DecodeWithAvro(context, encoded)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	decoded := result.(models.Event)
+	assert.Equal(t, event.Device, decoded.Device)
+	assert.Equal(t, event.Origin, decoded.Origin)
+	require.Len(t, decoded.Readings, 2)
+	assert.Equal(t, event.Readings[0].Origin, decoded.Readings[0].Origin)
+	assert.Equal(t, event.Readings[1].BinaryValue, decoded.Readings[1].BinaryValue)
+}
+
+func TestAvroEncodeMap(t *testing.T) {
+	avro, err := NewAvro(eventAvroSchema)
+	require.NoError(t, err)
+
+	data := map[string]interface{}{
+		"device":   devID2,
+		"origin":   10,
+		"readings": []map[string]interface{}{{"name": "ratio", "origin": 1.5}},
+		"tags":     map[string]string{"site": "plant1"},
+		"location": map[string]interface{}{"name": "room1"},
+	}
+	continuePipeline, result := avro.EncodeWithAvro(context, data)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	continuePipeline, result = avro.DecodeWithAvro(context, result)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	expected := `{"device":"id2","origin":10,"tags":{"site":"plant1"},"readings":[` +
+		`{"name":"ratio","value":null,"binaryValue":null,"mediaType":null,"origin":1.5}],` +
+		`"location":{"name":"room1","value":null,"binaryValue":null,"mediaType":null,"origin":null}}`
+	assert.JSONEq(t, expected, string(result.([]byte)))
+}
+
+func TestAvroErrors(t *testing.T) {
+	avro, err := NewAvro(eventAvroSchema)
+	require.NoError(t, err)
+
+	continuePipeline, result := avro.EncodeWithAvro(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = avro.EncodeWithAvro(context, "not json")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = avro.EncodeWithAvro(context, `{"device":"id1","origin":"now","readings":[]}`)
+	assert.False(t, continuePipeline, "Pipeline should stop when the data doesn't match the schema")
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = avro.DecodeWithAvro(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = avro.DecodeWithAvro(context, []byte{0x02})
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
@@ -2,8 +2,13 @@ package transforms
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/google/uuid"
+
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
 )
@@ -17,16 +22,30 @@ const (
 	BatchByTimeAndCount
 )
 
-// BatchConfig ...
+// PipelineExecutor continues the pipeline with the data released by a batch, using the Context created for it.
+type PipelineExecutor func(edgexcontext *appcontext.Context, data interface{})
+
+// BatchConfig holds the batched data and the strategy used to decide when it is released. It is safe for use by
+// concurrent pipeline executions.
 type BatchConfig struct {
-	timeInterval                string
-	parsedDuration              time.Duration
-	batchThreshold              int
-	batchMode                   BatchMode
-	batchData                   [][]byte
-	continuedPipelineTransforms []appcontext.AppFunction
-	timerActive                 bool
-	done                        chan bool
+	// MaxBytes, when greater than zero, releases the batch once the combined size of the batched data reaches it.
+	MaxBytes          int
+	timeInterval      string
+	parsedDuration    time.Duration
+	batchThreshold    int
+	batchMode         BatchMode
+	batchData         [][]byte
+	batchBytes        int
+	continuedPipeline PipelineExecutor
+	lastContext       *appcontext.Context
+	timer             *time.Timer
+	timerID           uint64
+	timerElapsed      bool
+	closed            bool
+	outputFormat      BatchOutputFormat
+	csvColumns        []string
+	mutex             sync.Mutex
+	flushWg           sync.WaitGroup
 }
 
 // NewBatchByTime create, initializes  and returns a new instance for BatchConfig
@@ -40,7 +59,6 @@ func NewBatchByTime(timeInterval string) (*BatchConfig, error) {
 	if err != nil {
 		return nil, err
 	}
-	config.done = make(chan bool)
 
 	return &config, nil
 }
@@ -67,12 +85,39 @@ func NewBatchByTimeAndCount(timeInterval string, batchThreshold int) (*BatchConf
 	if err != nil {
 		return nil, err
 	}
-	config.done = make(chan bool)
 
 	return &config, nil
 }
 
-// Batch ...
+// ContinueWith sets the functions that follow Batch in the pipeline. When set, data released because the time interval
+// elapsed is passed thru these functions from the background flush goroutine using a new Context, rather than waiting
+// for the next call to Batch. The new Context shares the clients and configuration of the Context last passed to Batch
+// and has a new CorrelationID.
+func (batch *BatchConfig) ContinueWith(transforms ...appcontext.AppFunction) {
+	if len(transforms) == 0 {
+		batch.ContinueWithExecutor(nil)
+		return
+	}
+	batch.ContinueWithExecutor(func(edgexcontext *appcontext.Context, data interface{}) {
+		runTransforms(edgexcontext, transforms, data)
+	})
+}
+
+// ContinueWithExecutor is like ContinueWith, but the data released because the time interval elapsed is passed to
+// execute, which continues the pipeline with it, i.e. thru the runtime so that data whose export fails is stored for
+// later retry.
+func (batch *BatchConfig) ContinueWithExecutor(execute PipelineExecutor) {
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+	batch.continuedPipeline = execute
+}
+
+// Batch appends the data to the batch and returns immediately. The pipeline is stopped until the batch is released,
+// at which point the batched data is returned in the format set with SetOutputFormat, [][]byte by default, and the
+// pipeline continues.
+// In count modes the batch is released by the call that reaches the threshold, as it is when MaxBytes is reached.
+// In time modes the interval begins when the first piece of data is received. When it elapses the batch is passed to
+// the functions set with ContinueWith, or if none are set, released by the next call to Batch.
 func (batch *BatchConfig) Batch(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
 	if len(params) < 1 {
 		// We didn't receive a result
@@ -84,51 +129,174 @@ func (batch *BatchConfig) Batch(edgexcontext *appcontext.Context, params ...inte
 	if err != nil {
 		return false, err
 	}
+
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+
+	if batch.closed {
+		return false, errors.New("batch has been closed")
+	}
+	if err := validateBatchItem(batch.outputFormat, data); err != nil {
+		return false, err
+	}
+
 	// always append data
 	batch.batchData = append(batch.batchData, data)
+	batch.batchBytes += len(data)
+	batch.lastContext = edgexcontext
 
-	// If its time only or time and count
-	if batch.batchMode != BatchByCountOnly {
-		if batch.timerActive == false {
-			batch.timerActive = true
-			for {
-				select {
-				case <-batch.done:
-					edgexcontext.LoggingClient.Debug("Batch count has been reached")
-				case <-time.After(batch.parsedDuration):
-					edgexcontext.LoggingClient.Debug("Timer has elapsed")
-				}
-				break
-			}
-			batch.timerActive = false
-		} else {
-			if batch.batchMode == BatchByTimeOnly {
-				return false, nil
-			}
+	if batch.thresholdReached() {
+		edgexcontext.LoggingClient.Debug("Forwarding Batched Data...")
+		output, err := formatBatchOutput(batch.outputFormat, batch.csvColumns, batch.take())
+		if err != nil {
+			return false, err
 		}
+		return true, output
 	}
 
-	if batch.batchMode != BatchByTimeOnly {
-		//Only want to check the threshold if the timer is running and in TimeAndCount mode OR if we are
-		// in CountOnly mode
-		if batch.batchMode == BatchByCountOnly || (batch.timerActive == true && batch.batchMode == BatchByTimeAndCount) {
-			// if we have not reached the threshold, then stop pipeline and continue batching
-			if len(batch.batchData) < batch.batchThreshold {
-				return false, nil
-			}
-			// if in BatchByCountOnly mode, there are no listeners so this would hang indefinitely
-			if batch.done != nil {
-				batch.done <- true
+	if batch.batchMode != BatchByCountOnly && batch.timer == nil {
+		batch.startTimer()
+	}
+
+	return false, nil
+}
+
+// Close stops the time interval and passes any remaining batched data to the functions set with ContinueWith,
+// waiting for all flushes in progress to complete. Calls to Batch after Close return an error.
+// An error is returned if batched data had to be discarded because no functions were set with ContinueWith.
+func (batch *BatchConfig) Close() error {
+	batch.mutex.Lock()
+	if batch.closed {
+		batch.mutex.Unlock()
+		return nil
+	}
+	batch.closed = true
+	data := batch.take()
+	execute := batch.continuedPipeline
+	template := batch.lastContext
+	format, csvColumns := batch.outputFormat, batch.csvColumns
+	if len(data) > 0 && execute != nil {
+		batch.flushWg.Add(1)
+	}
+	batch.mutex.Unlock()
+
+	if len(data) > 0 {
+		if execute == nil {
+			return fmt.Errorf("%d batched items discarded, no functions to continue the pipeline with", len(data))
+		}
+		batch.continuePipeline(template, execute, format, csvColumns, data)
+	}
+
+	batch.flushWg.Wait()
+	return nil
+}
+
+// thresholdReached must be called with the mutex held
+func (batch *BatchConfig) thresholdReached() bool {
+	if batch.timerElapsed {
+		return true
+	}
+	if batch.batchMode != BatchByTimeOnly && len(batch.batchData) >= batch.batchThreshold {
+		return true
+	}
+	return batch.MaxBytes > 0 && batch.batchBytes >= batch.MaxBytes
+}
+
+// take must be called with the mutex held. It empties the batch and stops any active timer.
+func (batch *BatchConfig) take() [][]byte {
+	if batch.timer != nil {
+		batch.timer.Stop()
+		batch.timer = nil
+	}
+	// Invalidate a timer that has already fired but is still waiting for the mutex
+	batch.timerID++
+	batch.timerElapsed = false
+
+	data := batch.batchData
+	batch.batchData = nil
+	batch.batchBytes = 0
+	return data
+}
+
+// startTimer must be called with the mutex held
+func (batch *BatchConfig) startTimer() {
+	batch.timerID++
+	id := batch.timerID
+	batch.timer = time.AfterFunc(batch.parsedDuration, func() {
+		batch.flush(id)
+	})
+}
+
+func (batch *BatchConfig) flush(id uint64) {
+	batch.mutex.Lock()
+	if id != batch.timerID || batch.closed || len(batch.batchData) == 0 {
+		batch.mutex.Unlock()
+		return
+	}
+
+	execute := batch.continuedPipeline
+	if execute == nil {
+		// Nowhere to send the data from here, so release it on the next call to Batch
+		batch.timer = nil
+		batch.timerElapsed = true
+		batch.mutex.Unlock()
+		return
+	}
+
+	data := batch.take()
+	template := batch.lastContext
+	format, csvColumns := batch.outputFormat, batch.csvColumns
+	batch.flushWg.Add(1)
+	batch.mutex.Unlock()
+
+	batch.continuePipeline(template, execute, format, csvColumns, data)
+}
+
+// continuePipeline continues the pipeline with the batched data. The caller must have added to flushWg.
+func (batch *BatchConfig) continuePipeline(template *appcontext.Context, execute PipelineExecutor,
+	format BatchOutputFormat, csvColumns []string, data [][]byte) {
+	defer batch.flushWg.Done()
+
+	edgexcontext := newFlushContext(template)
+	edgexcontext.LoggingClient.Debug("Continuing pipeline with Batched Data...")
+
+	result, err := formatBatchOutput(format, csvColumns, data)
+	if err != nil {
+		edgexcontext.LoggingClient.Error("Unable to format Batched Data", "error", err.Error(),
+			clients.CorrelationHeader, edgexcontext.CorrelationID)
+		return
+	}
+	execute(edgexcontext, result)
+}
+
+// runTransforms runs the functions with the data as the pipeline does, but without storing data for later retry
+func runTransforms(edgexcontext *appcontext.Context, transforms []appcontext.AppFunction, data interface{}) {
+	result := data
+	for index, trxFunc := range transforms {
+		continuePipeline, output := trxFunc(edgexcontext, result)
+		if !continuePipeline {
+			if err, ok := output.(error); ok {
+				edgexcontext.LoggingClient.Error(
+					fmt.Sprintf("Continued pipeline function #%d resulted in error", index),
+					"error", err.Error(), clients.CorrelationHeader, edgexcontext.CorrelationID)
 			}
+			return
 		}
+		result = output
 	}
+}
 
-	edgexcontext.LoggingClient.Debug("Forwarding Batched Data...")
-	// we've met the threshold, lets clear out the buffer and send it forward in the pipeline
-	if len(batch.batchData) > 0 {
-		copy := batch.batchData
-		batch.batchData = nil
-		return true, copy
+// newFlushContext creates a Context for continuing the pipeline outside of a trigger, sharing the clients and
+// configuration of template.
+func newFlushContext(template *appcontext.Context) *appcontext.Context {
+	return &appcontext.Context{
+		CorrelationID:         uuid.New().String(),
+		Configuration:         template.Configuration,
+		LoggingClient:         template.LoggingClient,
+		EventClient:           template.EventClient,
+		ValueDescriptorClient: template.ValueDescriptorClient,
+		CommandClient:         template.CommandClient,
+		NotificationsClient:   template.NotificationsClient,
+		SecretProvider:        template.SecretProvider,
 	}
-	return false, nil
 }
@@ -6,10 +6,42 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 )
 
 var dataToBatch = [3]string{"Test1", "Test2", "Test3"}
 
+// batchReceiver collects the data passed to the functions set with ContinueWith
+type batchReceiver struct {
+	mutex    sync.Mutex
+	received [][][]byte
+	contexts []*appcontext.Context
+	notify   chan struct{}
+}
+
+func newBatchReceiver() *batchReceiver {
+	return &batchReceiver{notify: make(chan struct{}, 10)}
+}
+
+func (receiver *batchReceiver) receive(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	receiver.mutex.Lock()
+	receiver.received = append(receiver.received, params[0].([][]byte))
+	receiver.contexts = append(receiver.contexts, edgexcontext)
+	receiver.mutex.Unlock()
+	receiver.notify <- struct{}{}
+	return true, nil
+}
+
+func (receiver *batchReceiver) wait(t *testing.T) {
+	select {
+	case <-receiver.notify:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timed out waiting for batch to be flushed")
+	}
+}
+
 func TestBatchNoData(t *testing.T) {
 
 	bs, _ := NewBatchByCount(1)
@@ -48,111 +80,165 @@ func TestBatchInCountMode(t *testing.T) {
 	assert.Len(t, result4, 3, "Should have 3 records")
 	assert.Len(t, bs.batchData, 0, "Records should have been cleared")
 }
-func TestBatchInTimeAndCountMode_TimeElapsed(t *testing.T) {
 
-	bs, _ := NewBatchByTimeAndCount("2s", 10)
-	var wgAll sync.WaitGroup
-	var wgFirst sync.WaitGroup
-	wgAll.Add(3)
-	wgFirst.Add(1)
+func TestBatchInCountModeConcurrent(t *testing.T) {
+	bs, _ := NewBatchByCount(10)
 
-	go func() {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	released := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
 		go func() {
-			time.Sleep(100)
-			wgFirst.Done()
+			defer wg.Done()
+			continuePipeline, result := bs.Batch(context, []byte(dataToBatch[1]))
+			if continuePipeline {
+				mutex.Lock()
+				released += len(result.([][]byte))
+				mutex.Unlock()
+			}
 		}()
-		continuePipeline1, result := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.True(t, continuePipeline1)
-		assert.Equal(t, 3, len(result.([][]byte)))
-		assert.Len(t, bs.batchData, 0, "Should have 0 records")
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		continuePipeline2, _ := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline2)
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		continuePipeline3, _ := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline3)
-		wgAll.Done()
-	}()
-	wgAll.Wait()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, released, "All records should have been released")
+}
+
+func TestBatchMaxBytes(t *testing.T) {
+	bs, _ := NewBatchByTime("90s")
+	bs.MaxBytes = 12
+
+	continuePipeline, _ := bs.Batch(context, []byte(dataToBatch[0]))
+	assert.False(t, continuePipeline)
+	continuePipeline, _ = bs.Batch(context, []byte(dataToBatch[1]))
+	assert.False(t, continuePipeline)
+
+	continuePipeline, result := bs.Batch(context, []byte(dataToBatch[2]))
+	require.True(t, continuePipeline, "Pipeline should continue once MaxBytes is reached")
+	assert.Len(t, result, 3, "Should have 3 records")
+	assert.Nil(t, bs.timer, "Timer should have been stopped")
+}
+
+func TestBatchInTimeAndCountMode_TimeElapsed(t *testing.T) {
+
+	bs, _ := NewBatchByTimeAndCount("200ms", 10)
+	receiver := newBatchReceiver()
+	bs.ContinueWith(receiver.receive)
+
+	start := time.Now()
+	for _, data := range dataToBatch {
+		continuePipeline, result := bs.Batch(context, []byte(data))
+		assert.False(t, continuePipeline)
+		assert.Nil(t, result)
+	}
+	assert.True(t, time.Since(start) < 200*time.Millisecond, "Batch should not block")
+
+	receiver.wait(t)
+	require.Len(t, receiver.received, 1)
+	assert.Len(t, receiver.received[0], 3, "Should have 3 records")
+	assert.NotEqual(t, context, receiver.contexts[0], "Flush should use a new context")
+	assert.NotEmpty(t, receiver.contexts[0].CorrelationID)
+	assert.Len(t, bs.batchData, 0, "Should have 0 records")
 }
 
 func TestBatchInTimeAndCountMode_CountMet(t *testing.T) {
 
 	bs, _ := NewBatchByTimeAndCount("90s", 3)
-	var wgAll sync.WaitGroup
-	var wgFirst sync.WaitGroup
-	var wgSecond sync.WaitGroup
-	wgAll.Add(3)
-	wgFirst.Add(1)
-	wgSecond.Add(1)
-
-	go func() {
-		go func() {
-			time.Sleep(200)
-			wgFirst.Done()
-		}()
-		continuePipeline1, _ := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline1)
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		go func() {
-			time.Sleep(200)
-			wgSecond.Done()
-		}()
-		continuePipeline2, _ := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline2)
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		wgSecond.Wait()
-		continuePipeline3, result := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.True(t, continuePipeline3)
-		assert.Equal(t, 3, len(result.([][]byte)))
-		assert.Nil(t, bs.batchData, "Should have 0 records")
-		wgAll.Done()
-	}()
-	wgAll.Wait()
+	receiver := newBatchReceiver()
+	bs.ContinueWith(receiver.receive)
+
+	continuePipeline1, _ := bs.Batch(context, []byte(dataToBatch[0]))
+	assert.False(t, continuePipeline1)
+	continuePipeline2, _ := bs.Batch(context, []byte(dataToBatch[1]))
+	assert.False(t, continuePipeline2)
+
+	continuePipeline3, result := bs.Batch(context, []byte(dataToBatch[2]))
+	assert.True(t, continuePipeline3)
+	assert.Equal(t, 3, len(result.([][]byte)))
+	assert.Nil(t, bs.batchData, "Should have 0 records")
+	assert.Nil(t, bs.timer, "Timer should have been stopped")
+	assert.Empty(t, receiver.received, "Nothing should have been flushed")
 }
-func TestBatchInTimeMode(t *testing.T) {
 
-	bs, _ := NewBatchByTime("3s")
-	var wgAll sync.WaitGroup
-	var wgFirst sync.WaitGroup
-	wgAll.Add(3)
-	wgFirst.Add(1)
+func TestBatchInTimeMode(t *testing.T) {
 
-	go func() {
-		go func() {
-			time.Sleep(100)
-			wgFirst.Done()
-		}()
+	bs, _ := NewBatchByTime("100ms")
+	receiver := newBatchReceiver()
+	bs.ContinueWith(receiver.receive)
 
-		continuePipeline1, result := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.True(t, continuePipeline1)
-		assert.Equal(t, 3, len(result.([][]byte)))
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		continuePipeline2, result := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline2)
+	for _, data := range dataToBatch {
+		continuePipeline, result := bs.Batch(context, []byte(data))
+		assert.False(t, continuePipeline)
 		assert.Nil(t, result)
-		wgAll.Done()
-	}()
-	go func() {
-		wgFirst.Wait()
-		continuePipeline3, _ := bs.Batch(context, []byte(dataToBatch[0]))
-		assert.False(t, continuePipeline3)
-		wgAll.Done()
-	}()
-	wgAll.Wait()
+	}
+	receiver.wait(t)
+
+	continuePipeline, _ := bs.Batch(context, []byte(dataToBatch[0]))
+	assert.False(t, continuePipeline)
+	receiver.wait(t)
+
+	require.Len(t, receiver.received, 2)
+	assert.Len(t, receiver.received[0], 3, "Should have 3 records")
+	assert.Len(t, receiver.received[1], 1, "Should have 1 record")
+}
+
+func TestBatchInTimeModeWithoutContinuation(t *testing.T) {
+
+	bs, _ := NewBatchByTime("50ms")
+
+	continuePipeline, _ := bs.Batch(context, []byte(dataToBatch[0]))
+	assert.False(t, continuePipeline)
+
+	time.Sleep(200 * time.Millisecond)
+
+	continuePipeline, result := bs.Batch(context, []byte(dataToBatch[1]))
+	assert.True(t, continuePipeline, "Elapsed batch should be released by the next call")
+	assert.Len(t, result, 2, "Should have 2 records")
+}
+
+func TestBatchClose(t *testing.T) {
+
+	bs, _ := NewBatchByTime("90s")
+	receiver := newBatchReceiver()
+	bs.ContinueWith(receiver.receive)
+
+	bs.Batch(context, []byte(dataToBatch[0]))
+	bs.Batch(context, []byte(dataToBatch[1]))
+
+	err := bs.Close()
+	require.NoError(t, err)
+	require.Len(t, receiver.received, 1, "Remaining data should be flushed on Close")
+	assert.Len(t, receiver.received[0], 2, "Should have 2 records")
+
+	continuePipeline, result := bs.Batch(context, []byte(dataToBatch[2]))
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "batch has been closed")
+
+	assert.NoError(t, bs.Close(), "Close should be idempotent")
+}
+
+func TestBatchCloseWithoutContinuation(t *testing.T) {
+
+	bs, _ := NewBatchByTime("90s")
+	bs.Batch(context, []byte(dataToBatch[0]))
+
+	err := bs.Close()
+	assert.EqualError(t, err, "1 batched items discarded, no functions to continue the pipeline with")
+}
+
+func TestBatchContinueWithExecutor(t *testing.T) {
+
+	bs, _ := NewBatchByTime("50ms")
+	receiver := newBatchReceiver()
+	bs.ContinueWithExecutor(func(edgexcontext *appcontext.Context, data interface{}) {
+		receiver.receive(edgexcontext, data)
+	})
+
+	bs.Batch(context, []byte(dataToBatch[0]))
+	bs.Batch(context, []byte(dataToBatch[1]))
+
+	receiver.wait(t)
+	require.Len(t, receiver.received, 1)
+	assert.Len(t, receiver.received[0], 2, "Should have 2 records")
+	assert.NotEqual(t, context, receiver.contexts[0], "Flush should use a new context")
 }
@@ -0,0 +1,215 @@
+package transforms
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+)
+
+// BatchOutputFormat Enum for choosing the format of the data released by Batch. Default is BatchOutputRaw.
+type BatchOutputFormat int
+
+const (
+	// BatchOutputRaw releases the batched data as a [][]byte
+	BatchOutputRaw BatchOutputFormat = iota
+	// BatchOutputJSONArray releases a JSON array of the batched objects as a []byte
+	BatchOutputJSONArray
+	// BatchOutputNDJSON releases newline-delimited JSON, one batched object per line, as a []byte
+	BatchOutputNDJSON
+	// BatchOutputCSV releases CSV with one row per reading of the batched Events as a []byte
+	BatchOutputCSV
+)
+
+// CSV columns supported for BatchOutputCSV. The event columns are taken from the Event containing the reading.
+const (
+	CSVColumnEventID       = "eventid"
+	CSVColumnEventOrigin   = "eventorigin"
+	CSVColumnID            = "id"
+	CSVColumnDevice        = "device"
+	CSVColumnName          = "name"
+	CSVColumnValue         = "value"
+	CSVColumnValueType     = "valuetype"
+	CSVColumnFloatEncoding = "floatencoding"
+	CSVColumnMediaType     = "mediatype"
+	CSVColumnOrigin        = "origin"
+	CSVColumnCreated       = "created"
+	CSVColumnPushed        = "pushed"
+	CSVColumnModified      = "modified"
+)
+
+// DefaultCSVColumns are the columns used for BatchOutputCSV when none are specified.
+var DefaultCSVColumns = []string{CSVColumnDevice, CSVColumnName, CSVColumnValue, CSVColumnOrigin}
+
+// ParseBatchOutputFormat converts "raw", "json", "ndjson" or "csv" into the matching BatchOutputFormat.
+func ParseBatchOutputFormat(value string) (BatchOutputFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "raw":
+		return BatchOutputRaw, nil
+	case "json":
+		return BatchOutputJSONArray, nil
+	case "ndjson":
+		return BatchOutputNDJSON, nil
+	case "csv":
+		return BatchOutputCSV, nil
+	}
+	return BatchOutputRaw, fmt.Errorf("unsupported batch output format '%s', must be raw, json, ndjson or csv", value)
+}
+
+// SetOutputFormat sets the format of the data released by Batch. csvColumns selects the columns, in order, when the
+// format is BatchOutputCSV. DefaultCSVColumns are used if none are specified.
+func (batch *BatchConfig) SetOutputFormat(format BatchOutputFormat, csvColumns ...string) error {
+	switch format {
+	case BatchOutputRaw, BatchOutputJSONArray, BatchOutputNDJSON:
+		csvColumns = nil
+	case BatchOutputCSV:
+		columns, err := normalizeCSVColumns(csvColumns)
+		if err != nil {
+			return err
+		}
+		csvColumns = columns
+	default:
+		return fmt.Errorf("unsupported batch output format %d", format)
+	}
+
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+	batch.outputFormat = format
+	batch.csvColumns = csvColumns
+	return nil
+}
+
+// formatBatchOutput converts the batched data into the specified format
+func formatBatchOutput(format BatchOutputFormat, csvColumns []string, data [][]byte) (interface{}, error) {
+	switch format {
+	case BatchOutputJSONArray:
+		items := make([]json.RawMessage, len(data))
+		for index, item := range data {
+			items[index] = toJSON(item)
+		}
+		return json.Marshal(items)
+
+	case BatchOutputNDJSON:
+		var buf bytes.Buffer
+		for _, item := range data {
+			if err := json.Compact(&buf, toJSON(item)); err != nil {
+				return nil, err
+			}
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+
+	case BatchOutputCSV:
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if err := writer.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		for _, item := range data {
+			// The items were validated by validateBatchItem when they were batched
+			var event models.Event
+			_ = json.Unmarshal(item, &event)
+			if err := writeCSVRows(writer, csvColumns, event); err != nil {
+				return nil, err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	return data, nil
+}
+
+// validateBatchItem returns an error if the item can't be formatted in the format, so it is rejected when it is batched
+// rather than failing the whole batch when it is released.
+func validateBatchItem(format BatchOutputFormat, item []byte) error {
+	if format != BatchOutputCSV {
+		return nil
+	}
+	var event models.Event
+	if err := json.Unmarshal(item, &event); err != nil {
+		return fmt.Errorf("unable to batch data for CSV output, expecting models.Event: %s", err.Error())
+	}
+	return nil
+}
+
+// toJSON returns the item as is when it is valid JSON, otherwise it is encoded as a JSON string.
+func toJSON(item []byte) json.RawMessage {
+	if json.Valid(item) {
+		return item
+	}
+	encoded, _ := json.Marshal(string(item))
+	return encoded
+}
+
+// normalizeCSVColumns validates the columns and converts them to lower case. DefaultCSVColumns are returned if no
+// columns are specified.
+func normalizeCSVColumns(csvColumns []string) ([]string, error) {
+	if len(csvColumns) == 0 {
+		csvColumns = DefaultCSVColumns
+	}
+	columns := make([]string, len(csvColumns))
+	for index, column := range csvColumns {
+		columns[index] = strings.ToLower(strings.TrimSpace(column))
+		if _, err := csvColumnValue(columns[index], models.Event{}, models.Reading{}); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+// writeCSVRows writes a row with the columns for each reading of the Event
+func writeCSVRows(writer *csv.Writer, columns []string, event models.Event) error {
+	for _, reading := range event.Readings {
+		row := make([]string, len(columns))
+		for index, column := range columns {
+			row[index], _ = csvColumnValue(column, event, reading)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func csvColumnValue(column string, event models.Event, reading models.Reading) (string, error) {
+	switch column {
+	case CSVColumnEventID:
+		return event.ID, nil
+	case CSVColumnEventOrigin:
+		return strconv.FormatInt(event.Origin, 10), nil
+	case CSVColumnID:
+		return reading.Id, nil
+	case CSVColumnDevice:
+		if reading.Device == "" {
+			return event.Device, nil
+		}
+		return reading.Device, nil
+	case CSVColumnName:
+		return reading.Name, nil
+	case CSVColumnValue:
+		return reading.Value, nil
+	case CSVColumnValueType:
+		return reading.ValueType, nil
+	case CSVColumnFloatEncoding:
+		return reading.FloatEncoding, nil
+	case CSVColumnMediaType:
+		return reading.MediaType, nil
+	case CSVColumnOrigin:
+		return strconv.FormatInt(reading.Origin, 10), nil
+	case CSVColumnCreated:
+		return strconv.FormatInt(reading.Created, 10), nil
+	case CSVColumnPushed:
+		return strconv.FormatInt(reading.Pushed, 10), nil
+	case CSVColumnModified:
+		return strconv.FormatInt(reading.Modified, 10), nil
+	}
+	return "", fmt.Errorf("unsupported CSV column '%s'", column)
+}
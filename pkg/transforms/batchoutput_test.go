@@ -0,0 +1,139 @@
+package transforms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchEvents(bs *BatchConfig) (bool, interface{}) {
+	events := []models.Event{
+		{ID: "event1", Device: devID1, Origin: 10, Readings: []models.Reading{
+			{Name: descriptor1, Value: "1", Origin: 11},
+			{Name: descriptor2, Value: "a,b", Origin: 12},
+		}},
+		{ID: "event2", Device: devID2, Origin: 20, Readings: []models.Reading{
+			{Device: devID2, Name: descriptor1, Value: "2", Origin: 21},
+		}},
+	}
+
+	var continuePipeline bool
+	var result interface{}
+	for _, event := range events {
+		continuePipeline, result = bs.Batch(context, event)
+	}
+	return continuePipeline, result
+}
+
+func TestParseBatchOutputFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected BatchOutputFormat
+	}{
+		{"", BatchOutputRaw},
+		{"raw", BatchOutputRaw},
+		{"JSON", BatchOutputJSONArray},
+		{" ndjson ", BatchOutputNDJSON},
+		{"csv", BatchOutputCSV},
+	}
+	for _, tt := range tests {
+		format, err := ParseBatchOutputFormat(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, format)
+	}
+
+	_, err := ParseBatchOutputFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestSetOutputFormatValidation(t *testing.T) {
+	bs, _ := NewBatchByCount(2)
+
+	assert.Error(t, bs.SetOutputFormat(BatchOutputFormat(42)))
+	assert.Error(t, bs.SetOutputFormat(BatchOutputCSV, "device", "bogus"))
+	assert.NoError(t, bs.SetOutputFormat(BatchOutputCSV, " Device ", "VALUE"))
+	assert.Equal(t, []string{"device", "value"}, bs.csvColumns)
+
+	assert.NoError(t, bs.SetOutputFormat(BatchOutputCSV))
+	assert.Equal(t, DefaultCSVColumns, bs.csvColumns)
+}
+
+func TestBatchOutputRaw(t *testing.T) {
+	bs, _ := NewBatchByCount(2)
+
+	continuePipeline, result := batchEvents(bs)
+	require.True(t, continuePipeline)
+	assert.Len(t, result.([][]byte), 2)
+}
+
+func TestBatchOutputJSONArray(t *testing.T) {
+	bs, _ := NewBatchByCount(3)
+	require.NoError(t, bs.SetOutputFormat(BatchOutputJSONArray))
+
+	bs.Batch(context, "not json")
+	continuePipeline, result := batchEvents(bs)
+	require.True(t, continuePipeline)
+
+	var items []interface{}
+	require.NoError(t, json.Unmarshal(result.([]byte), &items))
+	require.Len(t, items, 3)
+	assert.Equal(t, "not json", items[0], "Non-JSON data should be encoded as a JSON string")
+	assert.Equal(t, "event1", items[1].(map[string]interface{})["id"])
+	assert.Equal(t, devID2, items[2].(map[string]interface{})["device"])
+}
+
+func TestBatchOutputNDJSON(t *testing.T) {
+	bs, _ := NewBatchByCount(3)
+	require.NoError(t, bs.SetOutputFormat(BatchOutputNDJSON))
+
+	bs.Batch(context, "{ \"pretty\" :\n true }")
+	bs.Batch(context, "plain")
+	continuePipeline, result := bs.Batch(context, []byte(`{"a":1}`))
+	require.True(t, continuePipeline)
+
+	assert.Equal(t, "{\"pretty\":true}\n\"plain\"\n{\"a\":1}\n", string(result.([]byte)))
+}
+
+func TestBatchOutputCSV(t *testing.T) {
+	bs, _ := NewBatchByCount(2)
+	require.NoError(t, bs.SetOutputFormat(BatchOutputCSV, "eventid", "device", "name", "value", "origin"))
+
+	continuePipeline, result := batchEvents(bs)
+	require.True(t, continuePipeline)
+
+	expected := "eventid,device,name,value,origin\n" +
+		"event1,id1,Descriptor1,1,11\n" +
+		"event1,id1,Descriptor2,\"a,b\",12\n" +
+		"event2,id2,Descriptor1,2,21\n"
+	assert.Equal(t, expected, string(result.([]byte)))
+}
+
+func TestBatchOutputCSVNotAnEvent(t *testing.T) {
+	bs, _ := NewBatchByCount(1)
+	require.NoError(t, bs.SetOutputFormat(BatchOutputCSV))
+
+	continuePipeline, result := bs.Batch(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestBatchOutputCSVSkipsOnlyBadItem(t *testing.T) {
+	bs, _ := NewBatchByCount(2)
+	require.NoError(t, bs.SetOutputFormat(BatchOutputCSV, "device", "name", "value"))
+
+	continuePipeline, result := bs.Batch(context, models.Event{Device: devID1, Readings: []models.Reading{{Name: descriptor1, Value: "1"}}})
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	continuePipeline, result = bs.Batch(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.Len(t, bs.batchData, 1, "The bad item should not be batched")
+
+	continuePipeline, result = bs.Batch(context, models.Event{Device: devID2, Readings: []models.Reading{{Name: descriptor1, Value: "2"}}})
+	require.True(t, continuePipeline)
+	assert.Equal(t, "device,name,value\nid1,Descriptor1,1\nid2,Descriptor1,2\n", string(result.([]byte)))
+}
@@ -23,14 +23,39 @@ import (
 	"compress/zlib"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
 
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
 )
 
+// DefaultCompressionLevel uses the default compression level of the algorithm
+const DefaultCompressionLevel = 0
+
+// DefaultMaxDecompressedSize is the maximum size, 32MB, of the decompressed data when MaxDecompressedSize isn't set
+const DefaultMaxDecompressedSize = 32 * 1024 * 1024
+
 type Compression struct {
-	gzipWriter *gzip.Writer
-	zlibWriter *zlib.Writer
+	// Level is the compression level, which depends on the algorithm, 1-9 for GZIP and ZLIB, 1-22 for ZSTD and
+	// 0-11 for Brotli. SNAPPY and LZ4 don't have levels. DefaultCompressionLevel uses the default of the algorithm.
+	Level int
+	// MaxDecompressedSize is the maximum size in bytes of the data returned by DecompressWithGZIP,
+	// DecompressWithZLIB and DecompressWithLZ4, which guards against a small payload decompressing to an excessive size. Zero uses
+	// DefaultMaxDecompressedSize.
+	MaxDecompressedSize int
+	// RawOutput returns the compressed data as is rather than base64 encoded. The context's ContentEncoding is set to
+	// the algorithm, so HTTPSender sets the Content-Encoding header accordingly.
+	RawOutput   bool
+	gzipWriter  *gzip.Writer
+	zlibWriter  *zlib.Writer
+	zstdEncoder *zstd.Encoder
 }
 
 // NewCompression creates, initializes and returns a new instance of Compression
@@ -38,6 +63,15 @@ func NewCompression() Compression {
 	return Compression{}
 }
 
+// NewCompressionWithOptions creates, initializes and returns a new instance of Compression using the compression level
+// and, when rawOutput is set, returning the compressed data as is rather than base64 encoded.
+func NewCompressionWithOptions(level int, rawOutput bool) Compression {
+	return Compression{
+		Level:     level,
+		RawOutput: rawOutput,
+	}
+}
+
 // CompressWithGZIP compresses data received as either a string,[]byte, or json.Marshaler using gzip algorithm
 // and returns a base64 encoded string as a []byte.
 func (compression *Compression) CompressWithGZIP(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
@@ -53,7 +87,13 @@ func (compression *Compression) CompressWithGZIP(edgexcontext *appcontext.Contex
 	var buf bytes.Buffer
 
 	if compression.gzipWriter == nil {
-		compression.gzipWriter = gzip.NewWriter(&buf)
+		level := gzip.DefaultCompression
+		if compression.Level != DefaultCompressionLevel {
+			level = compression.Level
+		}
+		if compression.gzipWriter, err = gzip.NewWriterLevel(&buf, level); err != nil {
+			return false, fmt.Errorf("unable to compress with GZIP: %s", err.Error())
+		}
 	} else {
 		compression.gzipWriter.Reset(&buf)
 	}
@@ -61,7 +101,7 @@ func (compression *Compression) CompressWithGZIP(edgexcontext *appcontext.Contex
 	compression.gzipWriter.Write([]byte(data))
 	compression.gzipWriter.Close()
 
-	return true, bytesBufferToBase64(buf)
+	return true, compression.output(edgexcontext, buf.Bytes(), "gzip")
 
 }
 
@@ -80,7 +120,13 @@ func (compression *Compression) CompressWithZLIB(edgexcontext *appcontext.Contex
 	var buf bytes.Buffer
 
 	if compression.zlibWriter == nil {
-		compression.zlibWriter = zlib.NewWriter(&buf)
+		level := zlib.DefaultCompression
+		if compression.Level != DefaultCompressionLevel {
+			level = compression.Level
+		}
+		if compression.zlibWriter, err = zlib.NewWriterLevel(&buf, level); err != nil {
+			return false, fmt.Errorf("unable to compress with ZLIB: %s", err.Error())
+		}
 	} else {
 		compression.zlibWriter.Reset(&buf)
 	}
@@ -88,12 +134,238 @@ func (compression *Compression) CompressWithZLIB(edgexcontext *appcontext.Contex
 	compression.zlibWriter.Write([]byte(data))
 	compression.zlibWriter.Close()
 
-	return true, bytesBufferToBase64(buf)
+	// The HTTP deflate content encoding is the zlib format
+	return true, compression.output(edgexcontext, buf.Bytes(), "deflate")
+
+}
+
+// CompressWithZSTD compresses data received as either a string,[]byte, or json.Marshaler using the Zstandard
+// algorithm and returns a base64 encoded string as a []byte.
+func (compression *Compression) CompressWithZSTD(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Compression with ZSTD")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	if compression.zstdEncoder == nil {
+		level := zstd.SpeedDefault
+		if compression.Level != DefaultCompressionLevel {
+			if compression.Level < 1 || compression.Level > 22 {
+				return false, fmt.Errorf("unable to compress with ZSTD: invalid compression level %d", compression.Level)
+			}
+			// The zstd levels are mapped onto the closest speed of the pure Go encoder
+			level = zstd.EncoderLevelFromZstd(compression.Level)
+		}
+		if compression.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(level)); err != nil {
+			return false, fmt.Errorf("unable to compress with ZSTD: %s", err.Error())
+		}
+	}
+
+	return true, compression.output(edgexcontext, compression.zstdEncoder.EncodeAll(data, nil), "zstd")
+}
+
+// CompressWithSNAPPY compresses data received as either a string,[]byte, or json.Marshaler using the Snappy block
+// format and returns a base64 encoded string as a []byte.
+func (compression *Compression) CompressWithSNAPPY(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Compression with SNAPPY")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	return true, compression.output(edgexcontext, snappy.Encode(nil, data), "snappy")
+}
+
+// CompressWithLZ4 compresses data received as either a string,[]byte, or json.Marshaler using the standard LZ4 frame
+// format, as the lz4 command line tool does, and returns a base64 encoded string as a []byte.
+func (compression *Compression) CompressWithLZ4(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Compression with LZ4")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	writer := lz4.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return false, fmt.Errorf("unable to compress with LZ4: %s", err.Error())
+	}
+	if err := writer.Close(); err != nil {
+		return false, fmt.Errorf("unable to compress with LZ4: %s", err.Error())
+	}
+
+	return true, compression.output(edgexcontext, buf.Bytes(), "lz4")
+}
+
+// CompressWithBrotli compresses data received as either a string,[]byte, or json.Marshaler using the Brotli algorithm
+// and returns a base64 encoded string as a []byte.
+func (compression *Compression) CompressWithBrotli(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Compression with Brotli")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	level := brotli.DefaultCompression
+	if compression.Level != DefaultCompressionLevel {
+		if compression.Level < brotli.BestSpeed || compression.Level > brotli.BestCompression {
+			return false, fmt.Errorf("unable to compress with Brotli: invalid compression level %d", compression.Level)
+		}
+		level = compression.Level
+	}
+
+	var buf bytes.Buffer
+	writer := brotli.NewWriterLevel(&buf, level)
+	writer.Write(data)
+	if err := writer.Close(); err != nil {
+		return false, fmt.Errorf("unable to compress with Brotli: %s", err.Error())
+	}
+
+	return true, compression.output(edgexcontext, buf.Bytes(), "br")
+}
+
+// output returns the compressed data as is, setting the context's ContentEncoding, when RawOutput is set and base64
+// encoded otherwise
+func (compression *Compression) output(edgexcontext *appcontext.Context, compressed []byte, contentEncoding string) []byte {
+	if compression.RawOutput {
+		edgexcontext.ContentEncoding = contentEncoding
+		return compressed
+	}
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(compressed)))
+	base64.StdEncoding.Encode(encoded, compressed)
+	return encoded
+}
+
+// DecompressWithGZIP decompresses gzip compressed data received as either a string or []byte, such as the output of
+// CompressWithGZIP, and returns the decompressed data as a []byte. The compressed data may be raw or base64 encoded.
+// It will return an error and stop the pipeline if no data is received, the data can't be decompressed or the
+// decompressed data exceeds MaxDecompressedSize.
+func (compression *Compression) DecompressWithGZIP(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Decompression with GZIP")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// gzip data starts with the magic number 0x1f 0x8b
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		if data, err = base64Decode(data); err != nil {
+			return false, errors.New("unable to decompress with GZIP, data is neither gzip nor base64 encoded")
+		}
+	}
 
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false, fmt.Errorf("unable to decompress with GZIP: %s", err.Error())
+	}
+	edgexcontext.ContentEncoding = ""
+	return decompress(reader, "GZIP", compression.maxDecompressedSize())
 }
 
-func bytesBufferToBase64(buf bytes.Buffer) []byte {
-	dst := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
-	base64.StdEncoding.Encode(dst, buf.Bytes())
-	return dst
+// DecompressWithZLIB decompresses zlib compressed data received as either a string or []byte, such as the output of
+// CompressWithZLIB, and returns the decompressed data as a []byte. The compressed data may be raw or base64 encoded.
+// It will return an error and stop the pipeline if no data is received, the data can't be decompressed or the
+// decompressed data exceeds MaxDecompressedSize.
+func (compression *Compression) DecompressWithZLIB(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Decompression with ZLIB")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// The zlib header is two bytes using the deflate method, 8, whose value is a multiple of 31
+	if len(data) < 2 || data[0]&0x0f != 8 || (uint16(data[0])<<8|uint16(data[1]))%31 != 0 {
+		if data, err = base64Decode(data); err != nil {
+			return false, errors.New("unable to decompress with ZLIB, data is neither zlib nor base64 encoded")
+		}
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return false, fmt.Errorf("unable to decompress with ZLIB: %s", err.Error())
+	}
+	edgexcontext.ContentEncoding = ""
+	return decompress(reader, "ZLIB", compression.maxDecompressedSize())
+}
+
+// DecompressWithLZ4 decompresses data in the LZ4 frame format received as either a string or []byte, such as the
+// output of CompressWithLZ4, and returns the decompressed data as a []byte. The compressed data may be raw or base64
+// encoded. It will return an error and stop the pipeline if no data is received, the data can't be decompressed or
+// the decompressed data exceeds MaxDecompressedSize.
+func (compression *Compression) DecompressWithLZ4(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	edgexcontext.LoggingClient.Debug("Decompression with LZ4")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// LZ4 frames start with the little endian magic number 0x184D2204
+	if len(data) < 4 || !bytes.Equal(data[:4], []byte{0x04, 0x22, 0x4d, 0x18}) {
+		if data, err = base64Decode(data); err != nil {
+			return false, errors.New("unable to decompress with LZ4, data is neither an LZ4 frame nor base64 encoded")
+		}
+	}
+
+	edgexcontext.ContentEncoding = ""
+	return decompress(ioutil.NopCloser(lz4.NewReader(bytes.NewReader(data))), "LZ4", compression.maxDecompressedSize())
+}
+
+func (compression *Compression) maxDecompressedSize() int {
+	if compression.MaxDecompressedSize <= 0 {
+		return DefaultMaxDecompressedSize
+	}
+	return compression.MaxDecompressedSize
+}
+
+func decompress(reader io.ReadCloser, algorithm string, maxSize int) (bool, interface{}) {
+	defer reader.Close()
+	// Reading one byte more than the maximum tells an exceeding size apart from one that is exactly the maximum
+	decompressed, err := ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return false, fmt.Errorf("unable to decompress with %s: %s", algorithm, err.Error())
+	}
+	if len(decompressed) > maxSize {
+		return false, fmt.Errorf("unable to decompress with %s, decompressed data exceeds the maximum size of %d bytes", algorithm, maxSize)
+	}
+	return true, decompressed
+}
+
+// base64Decode decodes the standard base64 encoded data, ignoring surrounding whitespace
+func base64Decode(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	decoded := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(decoded, data)
+	if err != nil {
+		return nil, err
+	}
+	return decoded[:n], nil
 }
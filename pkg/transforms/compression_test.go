@@ -25,15 +25,23 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 )
 
 const (
 	clearString = "This is the test string used for testing"
 	gzipString  = "H4sIAAAJbogA/wrJyCxWyCxWKMlIVShJLS5RKC4pysxLVygtTk1RSMsvAgtm5qUDAgAA//8tdaMdKAAAAA=="
 	zlibString  = "eJwKycgsVsgsVijJSFUoSS0uUSguKcrMS1coLU5NUUjLLwILZualAwIAAP//KucO4w=="
+	// lz4String is the output of the lz4 command line tool
+	lz4String = "BCJNGGRApygAAIBUaGlzIGlzIHRoZSB0ZXN0IHN0cmluZyB1c2VkIGZvciB0ZXN0aW5nAAAAAPj9fco="
 )
 
 func TestGzip(t *testing.T) {
@@ -85,6 +93,227 @@ func TestZlib(t *testing.T) {
 	assert.Equal(t, result.([]byte), result2.([]byte))
 }
 
+func TestDecompressGzip(t *testing.T) {
+	comp := NewCompression()
+
+	continuePipeline, result := comp.DecompressWithGZIP(context, gzipString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	raw, err := base64.StdEncoding.DecodeString(gzipString)
+	require.NoError(t, err)
+	continuePipeline, result = comp.DecompressWithGZIP(context, raw)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	_, compressed := comp.CompressWithGZIP(context, []byte(clearString))
+	continuePipeline, result = comp.DecompressWithGZIP(context, compressed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+}
+
+func TestDecompressZlib(t *testing.T) {
+	comp := NewCompression()
+
+	continuePipeline, result := comp.DecompressWithZLIB(context, zlibString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	raw, err := base64.StdEncoding.DecodeString(zlibString)
+	require.NoError(t, err)
+	continuePipeline, result = comp.DecompressWithZLIB(context, raw)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	_, compressed := comp.CompressWithZLIB(context, []byte(clearString))
+	continuePipeline, result = comp.DecompressWithZLIB(context, compressed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+}
+
+func TestDecompressLZ4(t *testing.T) {
+	comp := NewCompression()
+
+	continuePipeline, result := comp.DecompressWithLZ4(context, lz4String)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	raw, err := base64.StdEncoding.DecodeString(lz4String)
+	require.NoError(t, err)
+	continuePipeline, result = comp.DecompressWithLZ4(context, raw)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	_, compressed := comp.CompressWithLZ4(context, []byte(clearString))
+	continuePipeline, result = comp.DecompressWithLZ4(context, compressed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+
+	comp.MaxDecompressedSize = len(clearString) - 1
+	continuePipeline, result = comp.DecompressWithLZ4(context, lz4String)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestDecompressErrors(t *testing.T) {
+	comp := NewCompression()
+
+	continuePipeline, result := comp.DecompressWithGZIP(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = comp.DecompressWithGZIP(context, "not compressed")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.DecompressWithGZIP(context, zlibString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.DecompressWithZLIB(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = comp.DecompressWithZLIB(context, "not compressed")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	// Truncated data
+	continuePipeline, result = comp.DecompressWithZLIB(context, zlibString[:20])
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.DecompressWithLZ4(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = comp.DecompressWithLZ4(context, "not compressed")
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.DecompressWithLZ4(context, zlibString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestCompressionAlgorithms(t *testing.T) {
+	decompressGzip := func(compressed []byte) ([]byte, error) {
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(reader)
+	}
+	decompressZlib := func(compressed []byte) ([]byte, error) {
+		reader, err := zlib.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(reader)
+	}
+	decompressZstd := func(compressed []byte) ([]byte, error) {
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return decoder.DecodeAll(compressed, nil)
+	}
+	decompressSnappy := func(compressed []byte) ([]byte, error) {
+		return snappy.Decode(nil, compressed)
+	}
+	decompressLZ4 := func(compressed []byte) ([]byte, error) {
+		return ioutil.ReadAll(lz4.NewReader(bytes.NewReader(compressed)))
+	}
+	decompressBrotli := func(compressed []byte) ([]byte, error) {
+		return ioutil.ReadAll(brotli.NewReader(bytes.NewReader(compressed)))
+	}
+
+	tests := []struct {
+		name            string
+		level           int
+		compress        func(comp *Compression) appcontext.AppFunction
+		decompress      func(compressed []byte) ([]byte, error)
+		contentEncoding string
+	}{
+		{"GZIP", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithGZIP }, decompressGzip, "gzip"},
+		{"GZIP best compression", 9, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithGZIP }, decompressGzip, "gzip"},
+		{"ZLIB", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithZLIB }, decompressZlib, "deflate"},
+		{"ZLIB best speed", 1, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithZLIB }, decompressZlib, "deflate"},
+		{"ZSTD", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithZSTD }, decompressZstd, "zstd"},
+		{"ZSTD level 19", 19, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithZSTD }, decompressZstd, "zstd"},
+		{"SNAPPY", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithSNAPPY }, decompressSnappy, "snappy"},
+		{"LZ4", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithLZ4 }, decompressLZ4, "lz4"},
+		{"Brotli", DefaultCompressionLevel, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithBrotli }, decompressBrotli, "br"},
+		{"Brotli level 11", 11, func(comp *Compression) appcontext.AppFunction { return comp.CompressWithBrotli }, decompressBrotli, "br"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+
+			comp := NewCompressionWithOptions(test.level, false)
+			continuePipeline, result := test.compress(&comp)(ctx, clearString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Empty(t, ctx.ContentEncoding)
+			compressed, err := base64.StdEncoding.DecodeString(string(result.([]byte)))
+			require.NoError(t, err)
+			decompressed, err := test.decompress(compressed)
+			require.NoError(t, err)
+			assert.Equal(t, clearString, string(decompressed))
+
+			raw := NewCompressionWithOptions(test.level, true)
+			continuePipeline, result = test.compress(&raw)(ctx, clearString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Equal(t, test.contentEncoding, ctx.ContentEncoding)
+			assert.Equal(t, compressed, result)
+		})
+	}
+}
+
+func TestCompressionInvalidLevel(t *testing.T) {
+	comp := NewCompressionWithOptions(42, false)
+
+	continuePipeline, result := comp.CompressWithGZIP(context, clearString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.CompressWithZLIB(context, clearString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.CompressWithZSTD(context, clearString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = comp.CompressWithBrotli(context, clearString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestCompressionNoData(t *testing.T) {
+	comp := NewCompression()
+	for _, compress := range []appcontext.AppFunction{comp.CompressWithZSTD, comp.CompressWithSNAPPY,
+		comp.CompressWithLZ4, comp.CompressWithBrotli} {
+		continuePipeline, result := compress(context)
+		assert.False(t, continuePipeline)
+		assert.EqualError(t, result.(error), "No Data Received")
+	}
+}
+
+func TestDecompressClearsContentEncoding(t *testing.T) {
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	comp := NewCompressionWithOptions(DefaultCompressionLevel, true)
+
+	_, compressed := comp.CompressWithGZIP(ctx, clearString)
+	require.Equal(t, "gzip", ctx.ContentEncoding)
+
+	continuePipeline, result := comp.DecompressWithGZIP(ctx, compressed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+	assert.Empty(t, ctx.ContentEncoding)
+}
+
 var result []byte
 
 func BenchmarkGzip(b *testing.B) {
@@ -110,3 +339,21 @@ func BenchmarkZlib(b *testing.B) {
 	b.SetBytes(int64(len(enc.([]byte))))
 	result = enc.([]byte)
 }
+
+func TestDecompressMaxSize(t *testing.T) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write(bytes.Repeat([]byte("a"), 1024))
+	require.NoError(t, writer.Close())
+	compressed := buf.Bytes()
+
+	comp := Compression{MaxDecompressedSize: 1024}
+	continuePipeline, result := comp.DecompressWithGZIP(context, compressed)
+	require.True(t, continuePipeline, "Data at the maximum size should be decompressed")
+	assert.Len(t, result, 1024)
+
+	comp.MaxDecompressedSize = 1023
+	continuePipeline, result = comp.DecompressWithGZIP(context, compressed)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "unable to decompress with GZIP, decompressed data exceeds the maximum size of 1023 bytes")
+}
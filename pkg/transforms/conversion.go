@@ -17,16 +17,36 @@
 package transforms
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 )
 
-// Conversion houses various built in conversion transforms (XML, JSON, CSV)
+// Conversion houses various built in conversion transforms (XML, JSON, CSV, InfluxDB line protocol)
 type Conversion struct {
+	// CSVColumns are the columns, in order, written by TransformToCSV. DefaultCSVColumns are used when empty.
+	CSVColumns []string
+	// CSVHeader writes a header row with the column names before the rows written by TransformToCSV
+	CSVHeader bool
+	// CSVDelimiter separates the columns written by TransformToCSV. A comma is used when not set.
+	CSVDelimiter rune
+	// Measurement is the measurement written by TransformToInfluxLineProtocol. The device name is used when empty.
+	Measurement string
+	// Tags are added to the device tag written by TransformToInfluxLineProtocol, i.e. the device profile.
+	Tags map[string]string
 }
 
 // NewConversion creates, initializes and returns a new instance of Conversion
@@ -34,6 +54,34 @@ func NewConversion() Conversion {
 	return Conversion{}
 }
 
+// NewCSVConversion creates, initializes and returns a new instance of Conversion for TransformToCSV with the
+// specified columns, header row and delimiter, or an error if a column or the delimiter is not supported.
+// See DefaultCSVColumns and CSVColumnDevice for the supported columns.
+func NewCSVConversion(columns []string, header bool, delimiter rune) (Conversion, error) {
+	columns, err := normalizeCSVColumns(columns)
+	if err != nil {
+		return Conversion{}, err
+	}
+	if delimiter == 0 {
+		delimiter = ','
+	}
+	if delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError || !utf8.ValidRune(delimiter) {
+		return Conversion{}, fmt.Errorf("unsupported CSV delimiter %q", delimiter)
+	}
+	return Conversion{CSVColumns: columns, CSVHeader: header, CSVDelimiter: delimiter}, nil
+}
+
+// NewInfluxLineProtocolConversion creates, initializes and returns a new instance of Conversion for
+// TransformToInfluxLineProtocol with the specified measurement and additional tags, or an error if a tag is empty.
+func NewInfluxLineProtocolConversion(measurement string, tags map[string]string) (Conversion, error) {
+	for key, value := range tags {
+		if strings.TrimSpace(key) == "" || strings.TrimSpace(value) == "" {
+			return Conversion{}, fmt.Errorf("invalid tag '%s=%s', key and value must be specified", key, value)
+		}
+	}
+	return Conversion{Measurement: strings.TrimSpace(measurement), Tags: tags}, nil
+}
+
 // TransformToXML transforms an EdgeX event to XML.
 // It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
 func (f Conversion) TransformToXML(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, stringType interface{}) {
@@ -73,3 +121,175 @@ func (f Conversion) TransformToJSON(edgexcontext *appcontext.Context, params ...
 	}
 	return false, errors.New("Unexpected type received")
 }
+
+// TransformToCSV transforms an EdgeX event to CSV with a row for each reading, using the CSVColumns, CSVHeader and
+// CSVDelimiter that have been set.
+// It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+func (f Conversion) TransformToCSV(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, stringType interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+	edgexcontext.LoggingClient.Debug("Transforming to CSV")
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received")
+	}
+
+	columns, err := normalizeCSVColumns(f.CSVColumns)
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if f.CSVDelimiter != 0 {
+		writer.Comma = f.CSVDelimiter
+	}
+	if f.CSVHeader {
+		if err := writer.Write(columns); err != nil {
+			return false, err
+		}
+	}
+	if err := writeCSVRows(writer, columns, event); err != nil {
+		return false, err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return false, err
+	}
+	return true, buf.String()
+}
+
+// TransformToInfluxLineProtocol transforms an EdgeX event to a line of InfluxDB line protocol. The measurement is the
+// Measurement that has been set or the device name, the tags are the device name along with the Tags that have been
+// set, the fields are the readings and the timestamp is the origin of the event in nanoseconds. The field type is
+// determined by the value type of the reading, Binary readings are skipped.
+// It will return an error and stop the pipeline if a non-edgex event is received, no data is received or the event
+// has no readings that can be converted.
+func (f Conversion) TransformToInfluxLineProtocol(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, stringType interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Event Received")
+	}
+	edgexcontext.LoggingClient.Debug("Transforming to InfluxDB line protocol")
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("Unexpected type received")
+	}
+
+	measurement := f.Measurement
+	if measurement == "" {
+		measurement = event.Device
+	}
+	if measurement == "" {
+		return false, errors.New("unable to determine the measurement, the event has no device name")
+	}
+
+	tags := map[string]string{"device": event.Device}
+	for key, value := range f.Tags {
+		tags[key] = value
+	}
+	tagKeys := make([]string, 0, len(tags))
+	for key, value := range tags {
+		if value != "" {
+			tagKeys = append(tagKeys, key)
+		}
+	}
+	// InfluxDB recommends sorting the tags by key for performance
+	sort.Strings(tagKeys)
+
+	var line strings.Builder
+	line.WriteString(influxEscaper.measurement.Replace(measurement))
+	for _, key := range tagKeys {
+		line.WriteString("," + influxEscaper.key.Replace(key) + "=" + influxEscaper.key.Replace(tags[key]))
+	}
+
+	fields := 0
+	for _, reading := range event.Readings {
+		value, ok := influxFieldValue(reading)
+		if !ok {
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("Skipping reading '%s' with value type %s", reading.Name, reading.ValueType))
+			continue
+		}
+		if fields == 0 {
+			line.WriteString(" ")
+		} else {
+			line.WriteString(",")
+		}
+		line.WriteString(influxEscaper.key.Replace(reading.Name) + "=" + value)
+		fields++
+	}
+	if fields == 0 {
+		return false, fmt.Errorf("no readings to convert for device '%s'", event.Device)
+	}
+
+	if event.Origin != 0 {
+		line.WriteString(" " + strconv.FormatInt(event.Origin, 10))
+	}
+	return true, line.String()
+}
+
+var influxEscaper = struct {
+	measurement *strings.Replacer
+	key         *strings.Replacer
+	stringValue *strings.Replacer
+}{
+	measurement: strings.NewReplacer(",", "\\,", " ", "\\ "),
+	key:         strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ "),
+	stringValue: strings.NewReplacer("\\", "\\\\", "\"", "\\\""),
+}
+
+// influxFieldValue formats the value of the reading as an InfluxDB field value based on the value type of the reading.
+// Values that don't match their value type, arrays and readings without a value type are written as strings, unless
+// a reading without a value type holds a number. It returns false for Binary readings.
+func influxFieldValue(reading models.Reading) (string, bool) {
+	value := strings.TrimSpace(reading.Value)
+	switch reading.ValueType {
+	case models.ValueTypeBinary:
+		return "", false
+	case models.ValueTypeBool:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return strconv.FormatBool(parsed), true
+		}
+	case models.ValueTypeInt8, models.ValueTypeInt16, models.ValueTypeInt32, models.ValueTypeInt64:
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return strconv.FormatInt(parsed, 10) + "i", true
+		}
+	case models.ValueTypeUint8, models.ValueTypeUint16, models.ValueTypeUint32, models.ValueTypeUint64:
+		// Unsigned integers use the u suffix, since a Uint64 may not fit in the signed integer type of the i suffix
+		if parsed, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return strconv.FormatUint(parsed, 10) + "u", true
+		}
+	case models.ValueTypeFloat32, models.ValueTypeFloat64:
+		if parsed, err := readingFloat(reading); err == nil {
+			bitSize := 64
+			if reading.ValueType == models.ValueTypeFloat32 {
+				bitSize = 32
+			}
+			return strconv.FormatFloat(parsed, 'g', -1, bitSize), true
+		}
+	case "":
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return strconv.FormatFloat(parsed, 'g', -1, 64), true
+		}
+	}
+	return "\"" + influxEscaper.stringValue.Replace(reading.Value) + "\"", true
+}
+
+// readingFloat returns the value of a float reading, decoding it when it is Base64 encoded as EdgeX device services do
+func readingFloat(reading models.Reading) (float64, error) {
+	if reading.FloatEncoding != models.Base64Encoding {
+		return strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(reading.Value)
+	if err != nil {
+		return 0, err
+	}
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	}
+	return 0, fmt.Errorf("unable to decode float value '%s'", reading.Value)
+}
@@ -170,3 +170,99 @@ func TestTransformToJSONMultipleParametersTwoEvents(t *testing.T) {
 	assert.Equal(t, expectedResult, result.(string))
 
 }
+func TestNewCSVConversionInvalid(t *testing.T) {
+	_, err := NewCSVConversion([]string{"device", "bogus"}, true, ',')
+	assert.Error(t, err, "Expected error for unsupported column")
+
+	_, err = NewCSVConversion(nil, true, '"')
+	assert.Error(t, err, "Expected error for unsupported delimiter")
+}
+func TestTransformToCSV(t *testing.T) {
+	eventIn := models.Event{
+		Device: devID1,
+		Readings: []models.Reading{
+			{Name: descriptor1, Value: "1;2", Origin: 10},
+			{Name: descriptor2, Value: "3", Origin: 20},
+		},
+	}
+
+	conv, err := NewCSVConversion([]string{"Device", "name", "value", "origin"}, true, ';')
+	require.NoError(t, err)
+	continuePipeline, result := conv.TransformToCSV(context, eventIn)
+	require.True(t, continuePipeline)
+	expectedResult := "device;name;value;origin\n" +
+		"id1;Descriptor1;\"1;2\";10\n" +
+		"id1;Descriptor2;3;20\n"
+	assert.Equal(t, expectedResult, result.(string))
+
+	conv = NewConversion()
+	continuePipeline, result = conv.TransformToCSV(context, eventIn)
+	require.True(t, continuePipeline)
+	assert.Equal(t, "id1,Descriptor1,1;2,10\nid1,Descriptor2,3,20\n", result.(string), "Defaults should be used")
+}
+func TestTransformToCSVNotAnEvent(t *testing.T) {
+	conv := NewConversion()
+	continuePipeline, result := conv.TransformToCSV(context, "")
+	require.EqualError(t, result.(error), "Unexpected type received")
+	assert.False(t, continuePipeline)
+
+	continuePipeline, result = conv.TransformToCSV(context)
+	require.EqualError(t, result.(error), "No Event Received")
+	assert.False(t, continuePipeline)
+}
+func TestTransformToInfluxLineProtocol(t *testing.T) {
+	eventIn := models.Event{
+		Device: "Room 1,Sensor",
+		Origin: 1577836800000000000,
+		Readings: []models.Reading{
+			{Name: "temperature", Value: "21.5", ValueType: models.ValueTypeFloat64},
+			{Name: "pressure", Value: "QTAAAA==", ValueType: models.ValueTypeFloat32, FloatEncoding: models.Base64Encoding},
+			{Name: "count", Value: "42", ValueType: models.ValueTypeUint16},
+			{Name: "total", Value: "18446744073709551615", ValueType: models.ValueTypeUint64},
+			{Name: "offset", Value: "-7", ValueType: models.ValueTypeInt64},
+			{Name: "on", Value: "true", ValueType: models.ValueTypeBool},
+			{Name: "status", Value: `say "hi"`, ValueType: models.ValueTypeString},
+			{Name: "raw", Value: "1.5"},
+			{Name: "image", ValueType: models.ValueTypeBinary, BinaryValue: []byte{1}},
+		},
+	}
+
+	conv, err := NewInfluxLineProtocolConversion("", map[string]string{"profile": "Thermostat"})
+	require.NoError(t, err)
+	continuePipeline, result := conv.TransformToInfluxLineProtocol(context, eventIn)
+	require.True(t, continuePipeline)
+	expectedResult := `Room\ 1\,Sensor,device=Room\ 1\,Sensor,profile=Thermostat ` +
+		`temperature=21.5,pressure=11,count=42u,total=18446744073709551615u,offset=-7i,on=true,status="say \"hi\"",raw=1.5 1577836800000000000`
+	assert.Equal(t, expectedResult, result.(string))
+
+	conv, err = NewInfluxLineProtocolConversion("sensors", nil)
+	require.NoError(t, err)
+	continuePipeline, result = conv.TransformToInfluxLineProtocol(context, models.Event{
+		Device:   devID1,
+		Readings: []models.Reading{{Name: descriptor1, Value: "abc", ValueType: models.ValueTypeInt32}},
+	})
+	require.True(t, continuePipeline)
+	assert.Equal(t, `sensors,device=id1 Descriptor1="abc"`, result.(string), "Invalid values should be written as strings")
+}
+func TestTransformToInfluxLineProtocolErrors(t *testing.T) {
+	_, err := NewInfluxLineProtocolConversion("", map[string]string{"profile": ""})
+	assert.Error(t, err, "Expected error for empty tag value")
+
+	conv := NewConversion()
+	continuePipeline, result := conv.TransformToInfluxLineProtocol(context, "")
+	require.EqualError(t, result.(error), "Unexpected type received")
+	assert.False(t, continuePipeline)
+
+	continuePipeline, result = conv.TransformToInfluxLineProtocol(context, models.Event{
+		Device:   devID1,
+		Readings: []models.Reading{{Name: "image", ValueType: models.ValueTypeBinary}},
+	})
+	assert.Error(t, result.(error), "Expected error when there are no fields")
+	assert.False(t, continuePipeline)
+
+	continuePipeline, result = conv.TransformToInfluxLineProtocol(context, models.Event{
+		Readings: []models.Reading{{Name: descriptor1, Value: "1"}},
+	})
+	assert.Error(t, result.(error), "Expected error when there is no measurement")
+	assert.False(t, continuePipeline)
+}
@@ -18,6 +18,7 @@ package transforms
 import (
 	"errors"
 
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
 )
@@ -60,3 +61,23 @@ func (cdc *CoreData) PushToCoreData(edgexcontext *appcontext.Context, params ...
 	}
 	return true, result
 }
+
+// PushEventToCoreData pushes the EdgeX Event received from the previous function, i.e. from TransformToEvent, to
+// CoreData as a new event with all of its readings. If validation is turned on in CoreServices then the device and
+// reading names must exist in the CoreMetadata and be properly registered in EdgeX.
+// This function will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+func (cdc *CoreData) PushEventToCoreData(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+	event, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("type received is not an Event")
+	}
+	result, err := edgexcontext.PushEventToCoreData(event)
+	if err != nil {
+		return false, err
+	}
+	return true, result
+}
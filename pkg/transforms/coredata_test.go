@@ -49,3 +49,19 @@ func TestPushToCore_NoData(t *testing.T) {
 	assert.Equal(t, "No Data Received", result.(error).Error())
 	assert.False(t, continuePipeline)
 }
+func TestPushEventToCore_NoData(t *testing.T) {
+	coreData := NewCoreData()
+	continuePipeline, result := coreData.PushEventToCoreData(context)
+
+	assert.NotNil(t, result)
+	assert.Equal(t, "No Data Received", result.(error).Error())
+	assert.False(t, continuePipeline)
+}
+func TestPushEventToCore_NotAnEvent(t *testing.T) {
+	coreData := NewCoreData()
+	continuePipeline, result := coreData.PushEventToCoreData(context, "something")
+
+	assert.NotNil(t, result)
+	assert.Equal(t, "type received is not an Event", result.(error).Error())
+	assert.False(t, continuePipeline)
+}
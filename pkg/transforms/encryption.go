@@ -21,9 +21,13 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/rand"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
@@ -32,6 +36,13 @@ import (
 type Encryption struct {
 	Key                  string
 	InitializationVector string
+	// SecretPath locates the key for EncryptWithAESGCM and DecryptWithAESGCM in the secret store, it is used instead of
+	// Key when set
+	SecretPath string
+	// SecretName is the name of the key at the SecretPath, "key" when not set
+	SecretName string
+	// AdditionalData is authenticated, but not encrypted, by EncryptWithAESGCM. The same data must be used to decrypt.
+	AdditionalData string
 }
 
 // NewEncryption creates, initializes and returns a new instance of Encryption
@@ -42,6 +53,15 @@ func NewEncryption(key string, initializationVector string) Encryption {
 	}
 }
 
+// NewEncryptionWithSecret creates, initializes and returns a new instance of Encryption for AES-256-GCM that retrieves
+// the key with the secretName from the secretPath in the secret store. The key must be 32 bytes, hex or base64 encoded.
+func NewEncryptionWithSecret(secretPath string, secretName string) Encryption {
+	return Encryption{
+		SecretPath: secretPath,
+		SecretName: secretName,
+	}
+}
+
 // IV and KEY must be 16 bytes
 const blockSize = 16
 
@@ -51,6 +71,37 @@ func pkcs5Padding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
+func pkcs5Unpadding(content []byte, blockSize int) ([]byte, error) {
+	if len(content) == 0 || len(content)%blockSize != 0 {
+		return nil, errors.New("invalid padding, data is not a multiple of the block size")
+	}
+	padding := int(content[len(content)-1])
+	if padding == 0 || padding > blockSize {
+		return nil, errors.New("invalid padding")
+	}
+	for _, value := range content[len(content)-padding:] {
+		if int(value) != padding {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return content[:len(content)-padding], nil
+}
+
+// key derives the AES key from the SHA1 hash of the Key
+func (aesData Encryption) key() []byte {
+	hash := sha1.New()
+
+	hash.Write([]byte((aesData.Key)))
+	key := hash.Sum(nil)
+	return key[:blockSize]
+}
+
+func (aesData Encryption) initializationVector() []byte {
+	iv := make([]byte, blockSize)
+	copy(iv, []byte(aesData.InitializationVector))
+	return iv
+}
+
 // EncryptWithAES encrypts a string, []byte, or json.Marshaller type using AES encryption.
 // It will return a Base64 encode []byte of the encrypted data.
 func (aesData Encryption) EncryptWithAES(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
@@ -63,16 +114,9 @@ func (aesData Encryption) EncryptWithAES(edgexcontext *appcontext.Context, param
 		return false, err
 	}
 
-	iv := make([]byte, blockSize)
-	copy(iv, []byte(aesData.InitializationVector))
+	iv := aesData.initializationVector()
 
-	hash := sha1.New()
-
-	hash.Write([]byte((aesData.Key)))
-	key := hash.Sum(nil)
-	key = key[:blockSize]
-
-	block, err := aes.NewCipher(key)
+	block, err := aes.NewCipher(aesData.key())
 	if err != nil {
 		return false, err
 	}
@@ -86,3 +130,166 @@ func (aesData Encryption) EncryptWithAES(edgexcontext *appcontext.Context, param
 
 	return true, encodedData
 }
+
+// DecryptWithAES decrypts data received as either a string or []byte, such as the output of EncryptWithAES, using the
+// same Key and InitializationVector. The encrypted data may be raw or base64 encoded.
+// It will return a []byte of the decrypted data, or an error and stop the pipeline if the data can't be decrypted.
+func (aesData Encryption) DecryptWithAES(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("no data received to decrypt")
+	}
+	edgexcontext.LoggingClient.Debug("Decrypting with AES")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// Raw encrypted data is unlikely to be valid base64, so only use it as is when it can't be decoded
+	if decoded, err := base64Decode(data); err == nil {
+		data = decoded
+	}
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return false, errors.New("unable to decrypt with AES, data is not a multiple of the block size")
+	}
+
+	block, err := aes.NewCipher(aesData.key())
+	if err != nil {
+		return false, err
+	}
+
+	cbc := cipher.NewCBCDecrypter(block, aesData.initializationVector())
+	decrypted := make([]byte, len(data))
+	cbc.CryptBlocks(decrypted, data)
+
+	decrypted, err = pkcs5Unpadding(decrypted, block.BlockSize())
+	if err != nil {
+		return false, fmt.Errorf("unable to decrypt with AES, check the key and initialization vector: %s", err.Error())
+	}
+	return true, decrypted
+}
+
+// gcmKeySize is the size of the key used for AES-256-GCM
+const gcmKeySize = 32
+
+const defaultSecretName = "key"
+
+// gcm creates the AES-256-GCM cipher using the key retrieved from the secret store, when SecretPath is set, or the Key
+func (aesData Encryption) gcm(edgexcontext *appcontext.Context) (cipher.AEAD, error) {
+	encodedKey := aesData.Key
+	if aesData.SecretPath != "" {
+		var err error
+		encodedKey, err = getSecret(edgexcontext, aesData.SecretPath, aesData.SecretName)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve the AES-256-GCM key: %s", err.Error())
+		}
+	}
+
+	key, err := decodeKey(encodedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getSecret retrieves the secret with the secretName, "key" when not set, from the secretPath in the secret store
+func getSecret(edgexcontext *appcontext.Context, secretPath string, secretName string) (string, error) {
+	if secretName == "" {
+		secretName = defaultSecretName
+	}
+	secrets, err := getSecrets(edgexcontext, secretPath, secretName)
+	if err != nil {
+		return "", err
+	}
+	return secrets[secretName], nil
+}
+
+// getSecrets retrieves the secrets with the secretNames from the secretPath in the secret store
+func getSecrets(edgexcontext *appcontext.Context, secretPath string, secretNames ...string) (map[string]string, error) {
+	if edgexcontext.SecretProvider == nil {
+		return nil, errors.New("the secret provider is not available")
+	}
+	secrets, err := edgexcontext.GetSecrets(secretPath, secretNames...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get secrets %v from secret path '%s': %s", secretNames, secretPath, err.Error())
+	}
+	return secrets, nil
+}
+
+// decodeKey decodes the hex or base64 encoded AES-256-GCM key
+func decodeKey(encodedKey string) ([]byte, error) {
+	if key, err := hex.DecodeString(encodedKey); err == nil && len(key) == gcmKeySize {
+		return key, nil
+	}
+	if key, err := base64Decode([]byte(encodedKey)); err == nil && len(key) == gcmKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("the AES-256-GCM key must be %d bytes, hex or base64 encoded", gcmKeySize)
+}
+
+// EncryptWithAESGCM encrypts a string, []byte, or json.Marshaller type using AES-256-GCM authenticated encryption.
+// A random nonce is generated for every message and prepended to the encrypted data. The AdditionalData, when set,
+// is authenticated along with the data.
+// It will return a Base64 encoded []byte of the nonce followed by the encrypted data.
+func (aesData Encryption) EncryptWithAESGCM(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("no data received to encrypt")
+	}
+	edgexcontext.LoggingClient.Debug("Encrypting with AES-256-GCM")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	gcm, err := aesData.gcm(edgexcontext)
+	if err != nil {
+		return false, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return false, fmt.Errorf("unable to generate nonce: %s", err.Error())
+	}
+
+	crypted := gcm.Seal(nonce, nonce, data, []byte(aesData.AdditionalData))
+
+	return true, []byte(base64.StdEncoding.EncodeToString(crypted))
+}
+
+// DecryptWithAESGCM decrypts data received as either a string or []byte, such as the output of EncryptWithAESGCM,
+// using the same key and AdditionalData. The encrypted data, prefixed with its nonce, may be raw or base64 encoded.
+// It will return a []byte of the decrypted data, or an error and stop the pipeline if the data can't be decrypted or
+// fails authentication.
+func (aesData Encryption) DecryptWithAESGCM(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("no data received to decrypt")
+	}
+	edgexcontext.LoggingClient.Debug("Decrypting with AES-256-GCM")
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	gcm, err := aesData.gcm(edgexcontext)
+	if err != nil {
+		return false, err
+	}
+
+	if decoded, err := base64Decode(data); err == nil {
+		data = decoded
+	}
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return false, errors.New("unable to decrypt with AES-256-GCM, data is too short")
+	}
+
+	nonce, crypted := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	decrypted, err := gcm.Open(nil, nonce, crypted, []byte(aesData.AdditionalData))
+	if err != nil {
+		return false, fmt.Errorf("unable to decrypt with AES-256-GCM: %s", err.Error())
+	}
+	return true, decrypted
+}
@@ -22,17 +22,25 @@ import (
 	"crypto/cipher"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
+	"os"
 
 	"testing"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
 )
 
 const (
 	plainString = "This is the test string used for testing"
 	iv          = "123456789012345678901234567890"
 	key         = "aquqweoruqwpeoruqwpoeruqwpoierupqoweiurpoqwiuerpqowieurqpowieurpoqiweuroipwqure"
+	gcmKey      = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
 )
 
 func aesDecrypt(crypt []byte, aesData models.EncryptionDetails) []byte {
@@ -97,3 +105,148 @@ func TestAESNoData(t *testing.T) {
 	assert.False(t, continuePipeline)
 	assert.Error(t, result.(error), "expect an error")
 }
+
+func TestDecryptAES(t *testing.T) {
+	enc := NewEncryption(key, iv)
+
+	continuePipeline, encrypted := enc.EncryptWithAES(context, []byte(plainString))
+	require.True(t, continuePipeline)
+
+	continuePipeline, decrypted := enc.DecryptWithAES(context, encrypted)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", decrypted)
+	assert.Equal(t, plainString, string(decrypted.([]byte)))
+
+	// Raw encrypted data is accepted as well as base64
+	raw, err := base64.StdEncoding.DecodeString(string(encrypted.([]byte)))
+	require.NoError(t, err)
+	continuePipeline, decrypted = enc.DecryptWithAES(context, raw)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", decrypted)
+	assert.Equal(t, plainString, string(decrypted.([]byte)))
+}
+
+func TestDecryptAESErrors(t *testing.T) {
+	enc := NewEncryption(key, iv)
+
+	continuePipeline, result := enc.DecryptWithAES(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no data received to decrypt")
+
+	continuePipeline, result = enc.DecryptWithAES(context, []byte("short"))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	_, encrypted := enc.EncryptWithAES(context, []byte(plainString))
+	wrongKey := NewEncryption("wrong key", iv)
+	continuePipeline, result = wrongKey.DecryptWithAES(context, encrypted)
+	if continuePipeline {
+		// The padding can happen to be valid, but the result is never the original data
+		assert.NotEqual(t, plainString, string(result.([]byte)))
+	} else {
+		assert.Error(t, result.(error))
+	}
+}
+
+func TestAESGCM(t *testing.T) {
+	enc := NewEncryption(gcmKey, "")
+	enc.AdditionalData = devID1
+
+	continuePipeline, encrypted := enc.EncryptWithAESGCM(context, []byte(plainString))
+	require.True(t, continuePipeline, "Pipeline should continue: %v", encrypted)
+
+	// A random nonce is used for every message
+	_, encrypted2 := enc.EncryptWithAESGCM(context, []byte(plainString))
+	assert.NotEqual(t, encrypted, encrypted2)
+
+	continuePipeline, decrypted := enc.DecryptWithAESGCM(context, encrypted)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", decrypted)
+	assert.Equal(t, plainString, string(decrypted.([]byte)))
+
+	// The key may be base64 encoded and the encrypted data raw
+	rawKey, _ := hex.DecodeString(gcmKey)
+	base64Key := NewEncryption(base64.StdEncoding.EncodeToString(rawKey), "")
+	base64Key.AdditionalData = devID1
+	raw, err := base64.StdEncoding.DecodeString(string(encrypted.([]byte)))
+	require.NoError(t, err)
+	continuePipeline, decrypted = base64Key.DecryptWithAESGCM(context, raw)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", decrypted)
+	assert.Equal(t, plainString, string(decrypted.([]byte)))
+}
+
+func TestAESGCMAuthentication(t *testing.T) {
+	enc := NewEncryption(gcmKey, "")
+	enc.AdditionalData = devID1
+	_, encrypted := enc.EncryptWithAESGCM(context, []byte(plainString))
+
+	wrongData := NewEncryption(gcmKey, "")
+	wrongData.AdditionalData = devID2
+	continuePipeline, result := wrongData.DecryptWithAESGCM(context, encrypted)
+	assert.False(t, continuePipeline, "Pipeline should stop when the additional data doesn't match")
+	assert.Error(t, result.(error))
+
+	raw, err := base64.StdEncoding.DecodeString(string(encrypted.([]byte)))
+	require.NoError(t, err)
+	raw[len(raw)-1] ^= 0xff
+	continuePipeline, result = enc.DecryptWithAESGCM(context, raw)
+	assert.False(t, continuePipeline, "Pipeline should stop when the data has been tampered with")
+	assert.Error(t, result.(error))
+}
+
+func TestAESGCMErrors(t *testing.T) {
+	enc := NewEncryption(gcmKey, "")
+
+	continuePipeline, result := enc.EncryptWithAESGCM(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no data received to encrypt")
+
+	continuePipeline, result = enc.DecryptWithAESGCM(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no data received to decrypt")
+
+	continuePipeline, result = enc.DecryptWithAESGCM(context, []byte("short"))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	// AES-256-GCM requires a 32 byte key
+	continuePipeline, result = NewEncryption(key, iv).EncryptWithAESGCM(context, []byte(plainString))
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "the AES-256-GCM key must be 32 bytes, hex or base64 encoded")
+
+	// The context doesn't have a secret provider
+	continuePipeline, result = NewEncryptionWithSecret("aes", "").EncryptWithAESGCM(context, []byte(plainString))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestAESGCMWithSecret(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	config := &common.ConfigurationStruct{}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"AES": common.InsecureSecretsInfo{
+			Path:    "aes",
+			Secrets: map[string]string{"key": gcmKey, "other": "not a key"},
+		},
+	}
+	secretContext := &appcontext.Context{
+		LoggingClient:  context.LoggingClient,
+		SecretProvider: security.NewSecretProvider(context.LoggingClient, config),
+	}
+
+	enc := NewEncryptionWithSecret("aes", "")
+	continuePipeline, encrypted := enc.EncryptWithAESGCM(secretContext, []byte(plainString))
+	require.True(t, continuePipeline, "Pipeline should continue: %v", encrypted)
+
+	// The same key provided directly decrypts the data
+	continuePipeline, decrypted := NewEncryption(gcmKey, "").DecryptWithAESGCM(context, encrypted)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", decrypted)
+	assert.Equal(t, plainString, string(decrypted.([]byte)))
+
+	continuePipeline, result := NewEncryptionWithSecret("aes", "other").EncryptWithAESGCM(secretContext, []byte(plainString))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = NewEncryptionWithSecret("missing", "key").EncryptWithAESGCM(secretContext, []byte(plainString))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
@@ -0,0 +1,384 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// ReadingMapping specifies the name and value type of a reading and the expression used to extract its value
+type ReadingMapping struct {
+	Name       string
+	ValueType  string
+	Expression string
+}
+
+// EventMapping houses the expressions used to convert JSON data into an EdgeX Event
+type EventMapping struct {
+	device   func(data interface{}) (interface{}, error)
+	origin   func(data interface{}) (interface{}, error)
+	readings []readingMapping
+}
+
+type readingMapping struct {
+	ReadingMapping
+	evaluate func(data interface{}) (interface{}, error)
+}
+
+// NewEventMapping creates, initializes and returns a new instance of EventMapping. The expressions are written in the
+// specified language, either JSONPath or JMESPath. The device expression is required, use a literal such as
+// `'my-device'` with JMESPath when the device name isn't part of the data. The origin expression is optional, the
+// current time is used when it isn't specified. The reading value type defaults to String when it isn't specified.
+func NewEventMapping(language string, deviceExpression string, originExpression string, readings []ReadingMapping) (*EventMapping, error) {
+	language, err := parseLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	if len(readings) == 0 {
+		return nil, errors.New("at least one reading must be specified")
+	}
+
+	if strings.TrimSpace(deviceExpression) == "" {
+		return nil, errors.New("device expression must be specified")
+	}
+
+	mapping := &EventMapping{}
+	mapping.device, err = compileExpression(language, strings.TrimSpace(deviceExpression))
+	if err != nil {
+		return nil, fmt.Errorf("invalid device expression '%s': %s", deviceExpression, err.Error())
+	}
+
+	if strings.TrimSpace(originExpression) != "" {
+		mapping.origin, err = compileExpression(language, strings.TrimSpace(originExpression))
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin expression '%s': %s", originExpression, err.Error())
+		}
+	}
+
+	for _, reading := range readings {
+		reading.Name = strings.TrimSpace(reading.Name)
+		if reading.Name == "" {
+			return nil, fmt.Errorf("reading name must be specified for expression '%s'", reading.Expression)
+		}
+
+		reading.ValueType, err = parseValueType(reading.ValueType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value type for reading '%s': %s", reading.Name, err.Error())
+		}
+
+		evaluate, err := compileExpression(language, strings.TrimSpace(reading.Expression))
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression for reading '%s': %s", reading.Name, err.Error())
+		}
+
+		mapping.readings = append(mapping.readings, readingMapping{ReadingMapping: reading, evaluate: evaluate})
+	}
+
+	return mapping, nil
+}
+
+// TransformToEvent converts the JSON data received from the previous function into an EdgeX Event with a reading for
+// each reading mapping and returns the Event. A reading whose expression doesn't find a value is left out.
+// This function will return an error and stop the pipeline if the data received is not JSON, the device name or
+// origin can't be determined, a value doesn't match its value type or no readings are found.
+func (mapping *EventMapping) TransformToEvent(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Transforming to Event")
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return false, fmt.Errorf("unable to transform data to Event, expecting JSON: %s", err.Error())
+	}
+	// The numbers are also decoded as json.Number, so large integers such as a nanosecond origin keep their precision
+	var precise interface{}
+	if err := decodePrecise(data, &precise); err != nil {
+		return false, fmt.Errorf("unable to transform data to Event, expecting JSON: %s", err.Error())
+	}
+
+	device, err := mapping.device(input)
+	if err != nil || device == nil {
+		return false, fmt.Errorf("unable to determine the device name: %v", err)
+	}
+	deviceName, ok := device.(string)
+	if !ok || deviceName == "" {
+		return false, fmt.Errorf("unable to determine the device name, '%v' is not a string", device)
+	}
+
+	origin := time.Now().UnixNano()
+	if mapping.origin != nil {
+		value, err := evaluate(mapping.origin, input, precise)
+		if err != nil {
+			return false, fmt.Errorf("unable to determine the origin: %s", err.Error())
+		}
+		origin, err = toOrigin(value)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	event := models.Event{
+		Device: deviceName,
+		Origin: origin,
+	}
+
+	for _, rule := range mapping.readings {
+		value, err := evaluate(rule.evaluate, input, precise)
+		if err != nil || value == nil {
+			edgexcontext.LoggingClient.Debug(fmt.Sprintf("No value found for reading '%s'", rule.Name))
+			continue
+		}
+
+		reading := models.Reading{
+			Device:    deviceName,
+			Name:      rule.Name,
+			ValueType: rule.ValueType,
+			Origin:    origin,
+		}
+		reading.Value, err = toReadingValue(value, rule.ValueType)
+		if err != nil {
+			return false, fmt.Errorf("unable to convert value for reading '%s': %s", rule.Name, err.Error())
+		}
+		if rule.ValueType == models.ValueTypeFloat32 || rule.ValueType == models.ValueTypeFloat64 {
+			reading.FloatEncoding = models.ENotation
+		}
+
+		event.Readings = append(event.Readings, reading)
+	}
+
+	if len(event.Readings) == 0 {
+		return false, fmt.Errorf("no readings found for device '%s'", deviceName)
+	}
+
+	return true, event
+}
+
+// evaluate evaluates the expression against the input decoded with float64 numbers, which the JSONPath and JMESPath
+// filters and functions need to compare numbers, and against the input decoded with json.Number numbers. The precise
+// result is returned when it selects the same value, otherwise the float64 result is returned.
+func evaluate(expression func(data interface{}) (interface{}, error), input interface{}, precise interface{}) (interface{}, error) {
+	value, err := expression(input)
+	if err != nil || value == nil {
+		return value, err
+	}
+	preciseValue, err := expression(precise)
+	if err == nil && reflect.DeepEqual(toFloat64Numbers(preciseValue), value) {
+		return preciseValue, nil
+	}
+	return value, nil
+}
+
+// toFloat64Numbers returns a copy of the value with its json.Number numbers converted to float64
+func toFloat64Numbers(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case json.Number:
+		number, _ := strconv.ParseFloat(typed.String(), 64)
+		return number
+	case []interface{}:
+		converted := make([]interface{}, len(typed))
+		for index, element := range typed {
+			converted[index] = toFloat64Numbers(element)
+		}
+		return converted
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, element := range typed {
+			converted[key] = toFloat64Numbers(element)
+		}
+		return converted
+	}
+	return value
+}
+
+var valueTypes = []string{
+	models.ValueTypeBool, models.ValueTypeString,
+	models.ValueTypeUint8, models.ValueTypeUint16, models.ValueTypeUint32, models.ValueTypeUint64,
+	models.ValueTypeInt8, models.ValueTypeInt16, models.ValueTypeInt32, models.ValueTypeInt64,
+	models.ValueTypeFloat32, models.ValueTypeFloat64,
+	models.ValueTypeBoolArray, models.ValueTypeStringArray,
+	models.ValueTypeUint8Array, models.ValueTypeUint16Array, models.ValueTypeUint32Array, models.ValueTypeUint64Array,
+	models.ValueTypeInt8Array, models.ValueTypeInt16Array, models.ValueTypeInt32Array, models.ValueTypeInt64Array,
+	models.ValueTypeFloat32Array, models.ValueTypeFloat64Array,
+}
+
+// parseValueType matches the value type, ignoring case, to one of the EdgeX reading value types other than Binary
+func parseValueType(valueType string) (string, error) {
+	valueType = strings.TrimSpace(valueType)
+	if valueType == "" {
+		return models.ValueTypeString, nil
+	}
+	for _, supported := range valueTypes {
+		if strings.EqualFold(valueType, supported) {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported value type '%s'", valueType)
+}
+
+// toReadingValue converts the value to the string representation EdgeX uses for the value type
+func toReadingValue(value interface{}, valueType string) (string, error) {
+	if strings.HasSuffix(valueType, "Array") {
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("'%v' is not an array", value)
+		}
+		elementType := strings.TrimSuffix(valueType, "Array")
+		elements := make([]json.RawMessage, len(values))
+		for index, element := range values {
+			converted, err := toReadingValue(element, elementType)
+			if err != nil {
+				return "", err
+			}
+			if elementType == models.ValueTypeString {
+				elements[index], _ = json.Marshal(converted)
+			} else {
+				elements[index] = json.RawMessage(converted)
+			}
+		}
+		encoded, err := json.Marshal(elements)
+		return string(encoded), err
+	}
+
+	var text string
+	switch typed := value.(type) {
+	case string:
+		text = strings.TrimSpace(typed)
+	case json.Number:
+		text = typed.String()
+	case float64:
+		text = strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		text = strconv.FormatBool(typed)
+	default:
+		if valueType != models.ValueTypeString {
+			return "", fmt.Errorf("'%v' is not a %s", value, valueType)
+		}
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	}
+
+	switch valueType {
+	case models.ValueTypeString:
+		if stringValue, ok := value.(string); ok {
+			return stringValue, nil
+		}
+		return text, nil
+	case models.ValueTypeBool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a %s", text, valueType)
+		}
+		return strconv.FormatBool(parsed), nil
+	case models.ValueTypeFloat32, models.ValueTypeFloat64:
+		bitSize := 64
+		if valueType == models.ValueTypeFloat32 {
+			bitSize = 32
+		}
+		parsed, err := strconv.ParseFloat(text, bitSize)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a %s", text, valueType)
+		}
+		return strconv.FormatFloat(parsed, 'e', -1, bitSize), nil
+	}
+
+	// The remaining value types are the integers, i.e. Int8 or Uint64
+	bitSize, _ := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(valueType, "Uint"), "Int"))
+	if strings.HasPrefix(valueType, "Uint") {
+		parsed, err := strconv.ParseUint(text, 10, bitSize)
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a %s", text, valueType)
+		}
+		return strconv.FormatUint(parsed, 10), nil
+	}
+	parsed, err := strconv.ParseInt(text, 10, bitSize)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not a %s", text, valueType)
+	}
+	return strconv.FormatInt(parsed, 10), nil
+}
+
+// toOrigin converts the value to nanoseconds since the epoch. The value is either an RFC3339 formatted time or a
+// number of seconds, milliseconds, microseconds or nanoseconds since the epoch, determined by its magnitude.
+func toOrigin(value interface{}) (int64, error) {
+	var number float64
+	switch typed := value.(type) {
+	case float64:
+		number = typed
+	case string, json.Number:
+		text := strings.TrimSpace(fmt.Sprint(typed))
+		if parsed, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return parsed.UnixNano(), nil
+		}
+		// An integer is converted without going thru float64, which can't hold a nanosecond origin exactly
+		if integer, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return toIntegerOrigin(integer), nil
+		}
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unable to convert origin '%s', expecting an RFC3339 time or a number", text)
+		}
+		number = parsed
+	default:
+		return 0, fmt.Errorf("unable to convert origin '%v', expecting an RFC3339 time or a number", value)
+	}
+
+	switch magnitude := math.Abs(number); {
+	case magnitude < 1e11:
+		return int64(number * 1e9), nil
+	case magnitude < 1e14:
+		return int64(number * 1e6), nil
+	case magnitude < 1e17:
+		return int64(number * 1e3), nil
+	}
+	return int64(number), nil
+}
+
+// toIntegerOrigin converts the integer number of seconds, milliseconds, microseconds or nanoseconds since the epoch,
+// determined by its magnitude, to nanoseconds since the epoch
+func toIntegerOrigin(number int64) int64 {
+	magnitude := number
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	switch {
+	case magnitude < 1e11:
+		return number * 1e9
+	case magnitude < 1e14:
+		return number * 1e6
+	case magnitude < 1e17:
+		return number * 1e3
+	}
+	return number
+}
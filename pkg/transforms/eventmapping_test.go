@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vendorEventJSON = `{"meta":{"serial":"sensor-7","ts":"2020-01-01T00:00:00Z"},` +
+	`"data":{"temp":21.5,"count":"42","on":true,"label":"hall","levels":[1,2,3],"tags":["a","b"]}}`
+
+func TestNewEventMappingInvalid(t *testing.T) {
+	readings := []ReadingMapping{{Name: "Temperature", ValueType: "Float64", Expression: "$.data.temp"}}
+
+	_, err := NewEventMapping("xpath", "$.meta.serial", "", readings)
+	assert.Error(t, err, "Expected error for unsupported language")
+
+	_, err = NewEventMapping(JSONPath, "$.meta.serial", "", nil)
+	assert.Error(t, err, "Expected error for no readings")
+
+	_, err = NewEventMapping(JSONPath, "", "", readings)
+	assert.Error(t, err, "Expected error for missing device expression")
+
+	_, err = NewEventMapping(JSONPath, "$.meta.serial", "$.meta[", readings)
+	assert.Error(t, err, "Expected error for invalid origin expression")
+
+	_, err = NewEventMapping(JSONPath, "$.meta.serial", "", []ReadingMapping{{Expression: "$.data.temp"}})
+	assert.Error(t, err, "Expected error for missing reading name")
+
+	_, err = NewEventMapping(JSONPath, "$.meta.serial", "", []ReadingMapping{{Name: "Image", ValueType: "Binary", Expression: "$.data.temp"}})
+	assert.Error(t, err, "Expected error for unsupported value type")
+}
+
+func TestTransformToEvent(t *testing.T) {
+	mapping, err := NewEventMapping(JSONPath, "$.meta.serial", "$.meta.ts", []ReadingMapping{
+		{Name: "Temperature", ValueType: "float64", Expression: "$.data.temp"},
+		{Name: "Count", ValueType: models.ValueTypeUint16, Expression: "$.data.count"},
+		{Name: "On", ValueType: models.ValueTypeBool, Expression: "$.data.on"},
+		{Name: "Label", Expression: "$.data.label"},
+		{Name: "Levels", ValueType: models.ValueTypeInt32Array, Expression: "$.data.levels"},
+		{Name: "Tags", ValueType: models.ValueTypeStringArray, Expression: "$.data.tags"},
+		{Name: "Humidity", ValueType: models.ValueTypeFloat64, Expression: "$.data.humidity"},
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := mapping.TransformToEvent(context, []byte(vendorEventJSON))
+	require.True(t, continuePipeline, "Pipeline should continue")
+	event := result.(models.Event)
+
+	origin := int64(1577836800000000000)
+	assert.Equal(t, "sensor-7", event.Device)
+	assert.Equal(t, origin, event.Origin)
+	require.Len(t, event.Readings, 6, "Missing reading should be left out")
+
+	expected := []models.Reading{
+		{Device: "sensor-7", Name: "Temperature", Value: "2.15e+01", ValueType: models.ValueTypeFloat64, FloatEncoding: models.ENotation, Origin: origin},
+		{Device: "sensor-7", Name: "Count", Value: "42", ValueType: models.ValueTypeUint16, Origin: origin},
+		{Device: "sensor-7", Name: "On", Value: "true", ValueType: models.ValueTypeBool, Origin: origin},
+		{Device: "sensor-7", Name: "Label", Value: "hall", ValueType: models.ValueTypeString, Origin: origin},
+		{Device: "sensor-7", Name: "Levels", Value: "[1,2,3]", ValueType: models.ValueTypeInt32Array, Origin: origin},
+		{Device: "sensor-7", Name: "Tags", Value: `["a","b"]`, ValueType: models.ValueTypeStringArray, Origin: origin},
+	}
+	assert.Equal(t, expected, event.Readings)
+}
+
+func TestTransformToEventWithJMESPath(t *testing.T) {
+	mapping, err := NewEventMapping(JMESPath, "'fixed-device'", "", []ReadingMapping{
+		{Name: "Max", ValueType: models.ValueTypeInt64, Expression: "max(data.levels)"},
+	})
+	require.NoError(t, err)
+
+	before := time.Now().UnixNano()
+	continuePipeline, result := mapping.TransformToEvent(context, vendorEventJSON)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	event := result.(models.Event)
+
+	assert.Equal(t, "fixed-device", event.Device)
+	assert.True(t, event.Origin >= before, "Origin should default to the current time")
+	require.Len(t, event.Readings, 1)
+	assert.Equal(t, "3", event.Readings[0].Value)
+}
+
+func TestTransformToEventLargeNumbers(t *testing.T) {
+	mapping, err := NewEventMapping(JSONPath, "$.serial", "$.ts", []ReadingMapping{
+		{Name: "Counter", ValueType: models.ValueTypeUint64, Expression: "$.counter"},
+		{Name: "Raw", Expression: "$.counter"},
+		{Name: "Filtered", ValueType: models.ValueTypeInt64Array, Expression: `$.values[?(@.name == "b")].value`},
+		{Name: "Compared", ValueType: models.ValueTypeInt64Array, Expression: "$.values[?(@.value == 5)].value"},
+	})
+	require.NoError(t, err)
+
+	// Values larger than 2^53 can't be held exactly as a float64
+	continuePipeline, result := mapping.TransformToEvent(context,
+		`{"serial":"sensor-7","ts":1577836800123456789,"counter":18446744073709551615,"values":[{"name":"a","value":5},{"name":"b","value":9007199254740993}]}`)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	event := result.(models.Event)
+
+	assert.Equal(t, int64(1577836800123456789), event.Origin)
+	require.Len(t, event.Readings, 4)
+	assert.Equal(t, "18446744073709551615", event.Readings[0].Value)
+	assert.Equal(t, "18446744073709551615", event.Readings[1].Value)
+	assert.Equal(t, "[9007199254740993]", event.Readings[2].Value)
+	assert.Equal(t, "[5]", event.Readings[3].Value, "Filters comparing numbers should still work")
+}
+
+func TestTransformToEventErrors(t *testing.T) {
+	mapping, err := NewEventMapping(JSONPath, "$.meta.serial", "", []ReadingMapping{
+		{Name: "Count", ValueType: models.ValueTypeUint8, Expression: "$.data.count"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []interface{}
+	}{
+		{"No Data", nil},
+		{"Not JSON", []interface{}{"not json"}},
+		{"Missing Device", []interface{}{`{"data":{"count":1}}`}},
+		{"Device Not A String", []interface{}{`{"meta":{"serial":7},"data":{"count":1}}`}},
+		{"No Readings", []interface{}{`{"meta":{"serial":"sensor-7"},"data":{}}`}},
+		{"Out Of Range", []interface{}{`{"meta":{"serial":"sensor-7"},"data":{"count":256}}`}},
+		{"Wrong Type", []interface{}{`{"meta":{"serial":"sensor-7"},"data":{"count":1.5}}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			continuePipeline, result := mapping.TransformToEvent(context, tt.params...)
+			assert.False(t, continuePipeline)
+			assert.Error(t, result.(error))
+		})
+	}
+}
+
+func TestToOrigin(t *testing.T) {
+	expected := int64(1577836800000000000)
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"Seconds", float64(1577836800)},
+		{"Milliseconds", float64(1577836800000)},
+		{"Microseconds", float64(1577836800000000)},
+		{"Nanoseconds", float64(1577836800000000000)},
+		{"Numeric String", "1577836800000"},
+		{"Number Seconds", json.Number("1577836800")},
+		{"Number Nanoseconds", json.Number("1577836800000000000")},
+		{"Number Fraction", json.Number("1577836800.0")},
+		{"RFC3339", "2020-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			origin, err := toOrigin(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, expected, origin)
+		})
+	}
+
+	_, err := toOrigin("yesterday")
+	assert.Error(t, err)
+	_, err = toOrigin(true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/grpcapi"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// defaultGRPCTimeout is how long to wait for the endpoint to respond to a message when Timeout isn't set
+const defaultGRPCTimeout = 10 * time.Second
+
+// GRPCConfig contains the settings of the GRPCSender
+type GRPCConfig struct {
+	// Address is the host:port of the endpoint serving the AppService of the grpcapi package
+	Address string
+	// ContentType is the content type of the data, application/json when not set
+	ContentType string
+	// UseTLS connects to the endpoint using TLS, with the optional client certificate and CA certificate
+	UseTLS         bool
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+	// Timeout is how long to wait for the endpoint to respond to a message, 10 seconds when not set
+	Timeout time.Duration
+}
+
+// GRPCSender streams data to an endpoint serving the AppService of the grpcapi package, such as the gRPC Trigger of
+// another application service
+type GRPCSender struct {
+	config         GRPCConfig
+	persistOnError bool
+	dialOptions    []grpc.DialOption
+	mutex          sync.Mutex
+	conn           *grpc.ClientConn
+	stream         grpcapi.AppService_TriggerStreamClient
+	cancelStream   stdcontext.CancelFunc
+}
+
+// NewGRPCSender creates, initializes and returns a new instance of GRPCSender. An error is returned if the address
+// isn't set or the certificates can't be loaded.
+func NewGRPCSender(config GRPCConfig, persistOnError bool) (*GRPCSender, error) {
+	if config.Address == "" {
+		return nil, errors.New("the gRPC endpoint address must be set")
+	}
+	if config.ContentType == "" {
+		config.ContentType = clients.ContentTypeJSON
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultGRPCTimeout
+	}
+
+	sender := &GRPCSender{config: config, persistOnError: persistOnError}
+
+	if config.UseTLS {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		sender.dialOptions = append(sender.dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		sender.dialOptions = append(sender.dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	return sender, nil
+}
+
+func (config GRPCConfig) tlsConfig() (*tls.Config, error) {
+	return util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+}
+
+// getStream returns the stream the data is sent on, opening it unless already open. The connection reconnects on
+// its own when lost, so it is only made once, while a new stream is opened after a failed send. It must be called
+// with the mutex held.
+func (sender *GRPCSender) getStream(edgexcontext *appcontext.Context) (grpcapi.AppService_TriggerStreamClient, error) {
+	if sender.stream != nil {
+		return sender.stream, nil
+	}
+
+	if sender.conn == nil {
+		edgexcontext.LoggingClient.Info("Connecting to gRPC endpoint " + sender.config.Address)
+		conn, err := grpc.Dial(sender.config.Address, sender.dialOptions...)
+		if err != nil {
+			return nil, err
+		}
+		sender.conn = conn
+	}
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	stream, err := grpcapi.NewAppServiceClient(sender.conn).TriggerStream(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sender.stream = stream
+	sender.cancelStream = cancel
+	return stream, nil
+}
+
+func (sender *GRPCSender) closeStream() {
+	if sender.stream != nil {
+		sender.cancelStream()
+		sender.stream = nil
+	}
+}
+
+// send sends the message and waits for its response. The messages share the stream, so they are sent one at a time,
+// and the stream is closed, to be opened again by the next send, if it fails or the response doesn't arrive in time.
+func (sender *GRPCSender) send(edgexcontext *appcontext.Context, message *grpcapi.Message) (*grpcapi.Response, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	stream, err := sender.getStream(edgexcontext)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.AfterFunc(sender.config.Timeout, sender.cancelStream)
+
+	var response *grpcapi.Response
+	err = stream.Send(message)
+	// The send fails with EOF when the stream is broken, and the reason is returned when receiving
+	if err == nil || err == io.EOF {
+		response, err = stream.Recv()
+	}
+
+	timedOut := !timer.Stop()
+	if timedOut || err != nil {
+		sender.closeStream()
+	}
+	if err != nil {
+		if timedOut {
+			return nil, fmt.Errorf("no response within %s", sender.config.Timeout)
+		}
+		if err == io.EOF {
+			return nil, errors.New("the stream was closed by the endpoint")
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// GRPCSend streams data from the previous function to the gRPC endpoint and returns the output data the endpoint
+// responds with. If no previous function exists, then the event that triggered the pipeline will be used. The
+// correlation ID and the context's ExportHeaders, such as the signature, are sent along with the data.
+// If the endpoint doesn't receive the data, or fails to process it for a reason other than invalid data, and
+// persistOnError is true and Store and Forward is enabled, the data will be stored for later retry.
+func (sender *GRPCSender) GRPCSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	exportData, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	message := &grpcapi.Message{
+		Payload:       exportData,
+		CorrelationId: edgexcontext.CorrelationID,
+		ContentType:   sender.config.ContentType,
+		Headers:       edgexcontext.ExportHeaders,
+	}
+
+	edgexcontext.LoggingClient.Debug("Sending data to gRPC endpoint " + sender.config.Address)
+	response, err := sender.send(edgexcontext, message)
+	if err != nil {
+		if sender.persistOnError {
+			edgexcontext.RetryData = exportData
+		}
+		return false, fmt.Errorf("Could not send data to gRPC endpoint: %s", err.Error())
+	}
+
+	if code := codes.Code(response.GetCode()); code != codes.OK {
+		// Sending invalid data again would fail the same way
+		if sender.persistOnError && code != codes.InvalidArgument {
+			edgexcontext.RetryData = exportData
+		}
+		return false, fmt.Errorf("gRPC endpoint failed to process data with %s code: %s", code, response.GetError())
+	}
+
+	edgexcontext.LoggingClient.Debug("Sent data to gRPC endpoint")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "gRPC", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, response.GetOutput()
+}
@@ -0,0 +1,253 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/grpcapi"
+)
+
+// fakeAppService responds to each message with the response returned by respond
+type fakeAppService struct {
+	grpcapi.UnimplementedAppServiceServer
+	respond func(message *grpcapi.Message) *grpcapi.Response
+}
+
+func (service *fakeAppService) TriggerStream(stream grpcapi.AppService_TriggerStreamServer) error {
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if response := service.respond(message); response != nil {
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// newBufconnSender returns a sender connected to the service through an in-memory connection
+func newBufconnSender(t *testing.T, service *fakeAppService, config GRPCConfig, persistOnError bool) (*GRPCSender, func()) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	grpcapi.RegisterAppServiceServer(server, service)
+	go server.Serve(listener)
+
+	config.Address = "bufnet"
+	sender, err := NewGRPCSender(config, persistOnError)
+	require.NoError(t, err)
+	sender.dialOptions = append(sender.dialOptions, grpc.WithContextDialer(func(stdcontext.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+
+	return sender, server.Stop
+}
+
+func TestGRPCSend(t *testing.T) {
+	messages := make(chan *grpcapi.Message, 2)
+	service := &fakeAppService{respond: func(message *grpcapi.Message) *grpcapi.Response {
+		messages <- message
+		return &grpcapi.Response{CorrelationId: message.CorrelationId, Output: []byte("output")}
+	}}
+	sender, stop := newBufconnSender(t, service, GRPCConfig{}, false)
+	defer stop()
+
+	context.CorrelationID = "correlation"
+	context.ExportHeaders = map[string]string{"X-Signature": "signature"}
+	defer func() {
+		context.CorrelationID = ""
+		context.ExportHeaders = nil
+	}()
+
+	for i := 0; i < 2; i++ {
+		continuePipeline, result := sender.GRPCSend(context, clearString)
+		require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+		assert.Equal(t, "output", string(result.([]byte)), "The endpoint's output should be returned")
+	}
+
+	message := <-messages
+	assert.Equal(t, clearString, string(message.Payload))
+	assert.Equal(t, "correlation", message.CorrelationId)
+	assert.Equal(t, "application/json", message.ContentType)
+	assert.Equal(t, "signature", message.Headers["X-Signature"])
+	<-messages
+}
+
+func TestGRPCSendNoData(t *testing.T) {
+	sender, err := NewGRPCSender(GRPCConfig{Address: "localhost:50051"}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := sender.GRPCSend(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestGRPCSendEndpointError(t *testing.T) {
+	tests := []struct {
+		Name             string
+		Code             codes.Code
+		ExpectRetryData  bool
+		ExpectedErrorMsg string
+	}{
+		{"Invalid Data", codes.InvalidArgument, false, "gRPC endpoint failed to process data with InvalidArgument code: bad data"},
+		{"Pipeline Failed", codes.Internal, true, "gRPC endpoint failed to process data with Internal code: bad data"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			service := &fakeAppService{respond: func(message *grpcapi.Message) *grpcapi.Response {
+				return &grpcapi.Response{Code: int32(test.Code), Error: "bad data"}
+			}}
+			sender, stop := newBufconnSender(t, service, GRPCConfig{}, true)
+			defer stop()
+			context.RetryData = nil
+			defer func() { context.RetryData = nil }()
+
+			continuePipeline, result := sender.GRPCSend(context, clearString)
+			require.False(t, continuePipeline)
+			assert.EqualError(t, result.(error), test.ExpectedErrorMsg)
+			assert.Equal(t, test.ExpectRetryData, context.RetryData != nil)
+		})
+	}
+}
+
+func TestGRPCSendTimeout(t *testing.T) {
+	respond := make(chan bool, 2)
+	respond <- false
+	respond <- true
+	service := &fakeAppService{respond: func(message *grpcapi.Message) *grpcapi.Response {
+		if !<-respond {
+			return nil
+		}
+		return &grpcapi.Response{}
+	}}
+	sender, stop := newBufconnSender(t, service, GRPCConfig{Timeout: 100 * time.Millisecond}, true)
+	defer stop()
+	context.RetryData = nil
+	defer func() { context.RetryData = nil }()
+
+	continuePipeline, result := sender.GRPCSend(context, clearString)
+	require.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "Could not send data to gRPC endpoint: no response within 100ms")
+	assert.Equal(t, clearString, string(context.RetryData))
+
+	continuePipeline, result = sender.GRPCSend(context, clearString)
+	assert.True(t, continuePipeline, "A new stream should be opened after the timeout: %v", result)
+}
+
+func TestGRPCSendUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	sender, err := NewGRPCSender(GRPCConfig{Address: address}, true)
+	require.NoError(t, err)
+	context.RetryData = nil
+	defer func() { context.RetryData = nil }()
+
+	continuePipeline, _ := sender.GRPCSend(context, clearString)
+	assert.False(t, continuePipeline)
+	assert.Equal(t, clearString, string(context.RetryData))
+}
+
+func TestGRPCSendTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "grpc")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "endpoint"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0644))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{certDER}, PrivateKey: key}},
+	})))
+	grpcapi.RegisterAppServiceServer(server, &fakeAppService{respond: func(message *grpcapi.Message) *grpcapi.Response {
+		return &grpcapi.Response{Output: message.Payload}
+	}})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	// The endpoint's certificate isn't trusted without the CA certificate
+	sender, err := NewGRPCSender(GRPCConfig{Address: listener.Addr().String(), UseTLS: true, Timeout: time.Second}, false)
+	require.NoError(t, err)
+	continuePipeline, _ := sender.GRPCSend(context, clearString)
+	require.False(t, continuePipeline)
+
+	sender, err = NewGRPCSender(GRPCConfig{Address: listener.Addr().String(), UseTLS: true, CACertFile: caFile}, false)
+	require.NoError(t, err)
+	continuePipeline, result := sender.GRPCSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string(result.([]byte)))
+}
+
+func TestNewGRPCSender(t *testing.T) {
+	_, err := NewGRPCSender(GRPCConfig{}, false)
+	assert.EqualError(t, err, "the gRPC endpoint address must be set")
+
+	_, err = NewGRPCSender(GRPCConfig{Address: "localhost:50051", UseTLS: true, CACertFile: "missing.pem"}, false)
+	assert.Error(t, err, "Sender shouldn't be created when the CA certificate can't be read")
+
+	sender, err := NewGRPCSender(GRPCConfig{Address: "localhost:50051"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, defaultGRPCTimeout, sender.config.Timeout)
+	assert.Equal(t, "application/json", sender.config.ContentType)
+}
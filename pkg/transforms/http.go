@@ -18,10 +18,17 @@ package transforms
 
 import (
 	"bytes"
+	stdcontext "context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
 
@@ -29,11 +36,78 @@ import (
 	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
 )
 
+const (
+	// BearerAuth sends the token secret as a bearer token in the Authorization header
+	BearerAuth = "bearer"
+	// BasicAuth sends the username and password secrets using basic authentication
+	BasicAuth = "basic"
+	// APIKeyAuth sends the apikey secret in the APIKeyHeader
+	APIKeyAuth = "apikey"
+	// OAuth2Auth sends a bearer token fetched from the TokenURL using the OAuth2 client credentials grant with the
+	// clientid and clientsecret secrets
+	OAuth2Auth = "oauth2"
+
+	// DefaultAPIKeyHeader is the header holding the API key when APIKeyHeader isn't set
+	DefaultAPIKeyHeader = "X-API-Key"
+
+	// DefaultMaxIdleConnsPerHost is the number of idle connections kept open to the endpoint when MaxIdleConnsPerHost
+	// isn't set. It is higher than the http.Transport default of 2 since a sender exports to a single host.
+	DefaultMaxIdleConnsPerHost = 100
+)
+
+// HTTPSenderOptions contains the optional settings of the HTTPSender
+type HTTPSenderOptions struct {
+	// Headers are added to every request. The values may be Go templates, which are rendered with the .Data being
+	// exported, decoded when it is JSON, and the .CorrelationID of the pipeline.
+	Headers map[string]string
+	// AuthMode is the authentication, BearerAuth, BasicAuth, APIKeyAuth or OAuth2Auth, none when not set. The
+	// credentials are retrieved from the SecretPath in the secret store, token for BearerAuth, username and password
+	// for BasicAuth, apikey for APIKeyAuth and clientid and clientsecret for OAuth2Auth.
+	AuthMode   string
+	SecretPath string
+	// TokenURL is the OAuth2 token endpoint and Scopes the optional scopes requested for OAuth2Auth
+	TokenURL string
+	Scopes   []string
+	// APIKeyHeader is the header holding the API key, DefaultAPIKeyHeader when not set
+	APIKeyHeader string
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and key used for client authentication
+	ClientCertFile string
+	ClientKeyFile  string
+	// CACertFile is the PEM encoded certificate authority used to verify the server, the system's when not set
+	CACertFile     string
+	SkipCertVerify bool
+	// Timeout is the time allowed for each request, including reading the response, no limit when not set
+	Timeout time.Duration
+	// SuccessStatusCodes are the response status codes considered successful, any 2xx when not set
+	SuccessStatusCodes []int
+	// RetryStatusCodes are the unsuccessful response status codes for which the data is persisted for later retry
+	// when PersistOnError is set, any unsuccessful status code when not set
+	RetryStatusCodes []int
+	// MaxIdleConns and MaxIdleConnsPerHost limit the idle connections kept open for reuse, 100 and
+	// DefaultMaxIdleConnsPerHost when not set. IdleConnTimeout is how long they are kept, 90 seconds when not set.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	// DisableKeepAlives opens a new connection for every request
+	DisableKeepAlives bool
+	// DisableHTTP2 prevents HTTP/2 from being negotiated with TLS endpoints
+	DisableHTTP2 bool
+	// ProxyURL is the proxy used for all requests, otherwise the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables are used
+	ProxyURL string
+	// MaxResponseBytes limits the size of the response read, which is returned to the pipeline, no limit when not set
+	MaxResponseBytes int64
+}
+
 // HTTPSender ...
 type HTTPSender struct {
 	URL            string
 	MimeType       string
 	PersistOnError bool
+	options        HTTPSenderOptions
+	headers        map[string]*template.Template
+	client         *http.Client
+	token          *oauth2Token
 }
 
 // NewHTTPSender creates, initializes and returns a new instance of HTTPSender
@@ -42,13 +116,133 @@ func NewHTTPSender(url string, mimeType string, persistOnError bool) HTTPSender
 		URL:            url,
 		MimeType:       mimeType,
 		PersistOnError: persistOnError,
+		client:         &http.Client{Transport: newTransport()},
+	}
+}
+
+// NewHTTPSenderWithOptions creates, initializes and returns a new instance of HTTPSender using the options. An error
+// is returned if a header template can't be parsed, the AuthMode isn't supported, the certificates can't be loaded or
+// the ProxyURL is invalid.
+func NewHTTPSenderWithOptions(url string, mimeType string, persistOnError bool, options HTTPSenderOptions) (HTTPSender, error) {
+	sender := NewHTTPSender(url, mimeType, persistOnError)
+	sender.options = options
+
+	if len(options.Headers) > 0 {
+		sender.headers = make(map[string]*template.Template, len(options.Headers))
+		for name, value := range options.Headers {
+			parsed, err := template.New(name).Funcs(templateFuncs).Parse(value)
+			if err != nil {
+				return HTTPSender{}, fmt.Errorf("unable to parse template for header '%s': %s", name, err.Error())
+			}
+			sender.headers[name] = parsed
+		}
+	}
+
+	switch options.AuthMode {
+	case "":
+	case BearerAuth, BasicAuth, APIKeyAuth, OAuth2Auth:
+		if options.SecretPath == "" {
+			return HTTPSender{}, fmt.Errorf("secret path must be specified for %s authentication", options.AuthMode)
+		}
+	default:
+		return HTTPSender{}, fmt.Errorf("unsupported authentication mode '%s', must be %s, %s, %s or %s",
+			options.AuthMode, BearerAuth, BasicAuth, APIKeyAuth, OAuth2Auth)
+	}
+	if options.AuthMode == OAuth2Auth {
+		if options.TokenURL == "" {
+			return HTTPSender{}, fmt.Errorf("token URL must be specified for %s authentication", OAuth2Auth)
+		}
+		sender.token = newOAuth2Token(options.TokenURL, options.Scopes, options.SecretPath)
+	}
+
+	transport, err := options.transport()
+	if err != nil {
+		return HTTPSender{}, err
+	}
+	sender.client = &http.Client{Transport: transport}
+
+	return sender, nil
+}
+
+// newTransport returns the connection pooling transport used by default, which is http.DefaultTransport keeping more
+// idle connections open to the endpoint
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	return transport
+}
+
+// transport returns the transport configured with the connection, proxy and TLS options
+func (options HTTPSenderOptions) transport() (*http.Transport, error) {
+	transport := newTransport()
+	if options.MaxIdleConns > 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if options.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	}
+	if options.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = options.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = options.DisableKeepAlives
+
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse proxy URL: %s", err.Error())
+		}
+		if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			return nil, fmt.Errorf("unsupported proxy URL scheme '%s', must be http, https or socks5", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if options.DisableHTTP2 {
+		// A non-nil empty TLSNextProto disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return transport, nil
+}
+
+// tlsConfig returns the TLS configuration for the certificates, nil when the defaults are used
+func (options HTTPSenderOptions) tlsConfig() (*tls.Config, error) {
+	if options.ClientCertFile == "" && options.ClientKeyFile == "" && options.CACertFile == "" && !options.SkipCertVerify {
+		return nil, nil
 	}
+
+	return util.NewTLSConfig(options.ClientCertFile, options.ClientKeyFile, options.CACertFile, options.SkipCertVerify)
 }
 
 // HTTPPost will send data from the previous function to the specified Endpoint via http POST.
 // If no previous function exists, then the event that triggered the pipeline will be used.
 // An empty string for the mimetype will default to application/json.
 func (sender HTTPSender) HTTPPost(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	return sender.httpSend(edgexcontext, http.MethodPost, params...)
+}
+
+// HTTPPut will send data from the previous function to the specified Endpoint via http PUT.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// An empty string for the mimetype will default to application/json.
+func (sender HTTPSender) HTTPPut(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	return sender.httpSend(edgexcontext, http.MethodPut, params...)
+}
+
+// HTTPPatch will send data from the previous function to the specified Endpoint via http PATCH.
+// If no previous function exists, then the event that triggered the pipeline will be used.
+// An empty string for the mimetype will default to application/json.
+func (sender HTTPSender) HTTPPatch(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	return sender.httpSend(edgexcontext, http.MethodPatch, params...)
+}
+
+func (sender HTTPSender) httpSend(edgexcontext *appcontext.Context, method string, params ...interface{}) (bool, interface{}) {
 	if len(params) < 1 {
 		// We didn't receive a result
 		return false, errors.New("No Data Received")
@@ -61,8 +255,32 @@ func (sender HTTPSender) HTTPPost(edgexcontext *appcontext.Context, params ...in
 		return false, err
 	}
 
-	edgexcontext.LoggingClient.Debug("POSTing data")
-	response, err := http.Post(sender.URL, sender.MimeType, bytes.NewReader(exportData))
+	ctx := stdcontext.Background()
+	if sender.options.Timeout > 0 {
+		var cancel stdcontext.CancelFunc
+		ctx, cancel = stdcontext.WithTimeout(ctx, sender.options.Timeout)
+		defer cancel()
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", sender.MimeType)
+	if edgexcontext.ContentEncoding != "" {
+		header.Set("Content-Encoding", edgexcontext.ContentEncoding)
+	}
+	for name, value := range edgexcontext.ExportHeaders {
+		header.Set(name, value)
+	}
+	if err := sender.setHeaders(edgexcontext, header, exportData); err != nil {
+		return false, err
+	}
+
+	client := sender.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	edgexcontext.LoggingClient.Debug(fmt.Sprintf("Sending data via HTTP %s", method))
+	response, err := sender.do(ctx, edgexcontext, client, method, header, exportData)
 	if err != nil {
 		sender.setRetryData(edgexcontext, exportData)
 		return false, err
@@ -70,17 +288,27 @@ func (sender HTTPSender) HTTPPost(edgexcontext *appcontext.Context, params ...in
 	defer response.Body.Close()
 	edgexcontext.LoggingClient.Debug(fmt.Sprintf("Response: %s", response.Status))
 	edgexcontext.LoggingClient.Debug(fmt.Sprintf("Sent data: %s", string(exportData)))
-	bodyBytes, errReadingBody := ioutil.ReadAll(response.Body)
+	body := io.Reader(response.Body)
+	if sender.options.MaxResponseBytes > 0 {
+		body = io.LimitReader(response.Body, sender.options.MaxResponseBytes+1)
+	}
+	bodyBytes, errReadingBody := ioutil.ReadAll(body)
 	if errReadingBody != nil {
 		sender.setRetryData(edgexcontext, exportData)
 		return false, errReadingBody
 	}
+	if sender.options.MaxResponseBytes > 0 && int64(len(bodyBytes)) > sender.options.MaxResponseBytes {
+		// The data has been exported, so it isn't retried
+		return false, fmt.Errorf("response exceeds the %d byte limit", sender.options.MaxResponseBytes)
+	}
 
 	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "HTTP", clients.CorrelationHeader, edgexcontext.CorrelationID)
 
-	// continues the pipeline if we get a 2xx response, stops pipeline if non-2xx response
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		sender.setRetryData(edgexcontext, exportData)
+	// continues the pipeline if we get a successful response, stops pipeline otherwise
+	if !sender.isSuccess(response.StatusCode) {
+		if sender.isRetryable(response.StatusCode) {
+			sender.setRetryData(edgexcontext, exportData)
+		}
 		return false, fmt.Errorf("export failed with %d HTTP status code", response.StatusCode)
 	}
 
@@ -88,6 +316,112 @@ func (sender HTTPSender) HTTPPost(edgexcontext *appcontext.Context, params ...in
 
 }
 
+// do sends the request, fetching a new OAuth2 access token and sending it again if the cached token is rejected
+func (sender HTTPSender) do(ctx stdcontext.Context, edgexcontext *appcontext.Context, client *http.Client, method string,
+	header http.Header, exportData []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		request, err := http.NewRequest(method, sender.URL, bytes.NewReader(exportData))
+		if err != nil {
+			return nil, err
+		}
+		request = request.WithContext(ctx)
+		for name, values := range header {
+			request.Header[name] = values
+		}
+		if err := sender.setAuthentication(edgexcontext, client, request); err != nil {
+			return nil, err
+		}
+
+		response, err := client.Do(request)
+		if err != nil || response.StatusCode != http.StatusUnauthorized || sender.token == nil || attempt > 0 {
+			return response, err
+		}
+
+		edgexcontext.LoggingClient.Debug("OAuth2 access token rejected, requesting a new token")
+		_, _ = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		sender.token.invalidate(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "))
+	}
+}
+
+// setHeaders renders the header templates and adds them to the headers of the request
+func (sender HTTPSender) setHeaders(edgexcontext *appcontext.Context, header http.Header, exportData []byte) error {
+	if len(sender.headers) == 0 {
+		return nil
+	}
+
+	data := struct {
+		Data          interface{}
+		CorrelationID string
+	}{
+		Data:          decodeTemplateData(exportData),
+		CorrelationID: edgexcontext.CorrelationID,
+	}
+	for name, value := range sender.headers {
+		var buf bytes.Buffer
+		if err := value.Execute(&buf, data); err != nil {
+			return fmt.Errorf("unable to render header '%s': %s", name, err.Error())
+		}
+		header.Set(name, strings.TrimSpace(buf.String()))
+	}
+	return nil
+}
+
+// setAuthentication adds the credentials retrieved from the secret store to the request
+func (sender HTTPSender) setAuthentication(edgexcontext *appcontext.Context, client *http.Client, request *http.Request) error {
+	switch sender.options.AuthMode {
+	case BearerAuth:
+		secrets, err := getSecrets(edgexcontext, sender.options.SecretPath, "token")
+		if err != nil {
+			return fmt.Errorf("unable to retrieve bearer token: %s", err.Error())
+		}
+		request.Header.Set("Authorization", "Bearer "+secrets["token"])
+	case BasicAuth:
+		secrets, err := getSecrets(edgexcontext, sender.options.SecretPath, "username", "password")
+		if err != nil {
+			return fmt.Errorf("unable to retrieve basic authentication credentials: %s", err.Error())
+		}
+		request.SetBasicAuth(secrets["username"], secrets["password"])
+	case APIKeyAuth:
+		secrets, err := getSecrets(edgexcontext, sender.options.SecretPath, "apikey")
+		if err != nil {
+			return fmt.Errorf("unable to retrieve API key: %s", err.Error())
+		}
+		header := sender.options.APIKeyHeader
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		request.Header.Set(header, secrets["apikey"])
+	case OAuth2Auth:
+		accessToken, err := sender.token.get(request.Context(), edgexcontext, client)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return nil
+}
+
+func (sender HTTPSender) isSuccess(statusCode int) bool {
+	if len(sender.options.SuccessStatusCodes) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+	return containsStatusCode(sender.options.SuccessStatusCodes, statusCode)
+}
+
+func (sender HTTPSender) isRetryable(statusCode int) bool {
+	return len(sender.options.RetryStatusCodes) == 0 || containsStatusCode(sender.options.RetryStatusCodes, statusCode)
+}
+
+func containsStatusCode(statusCodes []int, statusCode int) bool {
+	for _, code := range statusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
 func (sender HTTPSender) setRetryData(ctx *appcontext.Context, exportData []byte) {
 	if sender.PersistOnError {
 		ctx.RetryData = exportData
@@ -17,16 +17,27 @@
 package transforms
 
 import (
+	"encoding/pem"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
 )
 
 func TestHTTPPost(t *testing.T) {
@@ -113,3 +124,360 @@ func TestHTTPPostInvalidParameter(t *testing.T) {
 	assert.Equal(t, "marshaling input data to JSON failed, "+
 		"passed in data must be of type []byte, string, or support marshaling to JSON", result.(error).Error())
 }
+
+func TestHTTPPostContentEncoding(t *testing.T) {
+	var contentEncoding string
+	var received []byte
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		contentEncoding = r.Header.Get("Content-Encoding")
+		received, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	comp := NewCompressionWithOptions(DefaultCompressionLevel, true)
+	continuePipeline, compressed := comp.CompressWithGZIP(ctx, clearString)
+	require.True(t, continuePipeline)
+
+	sender := NewHTTPSender(ts.URL, "", false)
+	continuePipeline, result := sender.HTTPPost(ctx, compressed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, "gzip", contentEncoding)
+	assert.Equal(t, compressed, received)
+
+	// Without a previous function setting the encoding no Content-Encoding is sent
+	continuePipeline, result = sender.HTTPPost(&appcontext.Context{LoggingClient: context.LoggingClient}, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Empty(t, contentEncoding)
+}
+
+func TestHTTPPostExportHeaders(t *testing.T) {
+	var header http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusOK)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	signer, err := NewSignature(HMACSHA256, "my shared secret")
+	require.NoError(t, err)
+	_, signed := signer.Sign(ctx, clearString)
+
+	sender := NewHTTPSender(ts.URL, "", false)
+	continuePipeline, result := sender.HTTPPost(ctx, signed)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, ctx.ExportHeaders[DefaultSignatureHeader], header.Get(DefaultSignatureHeader))
+	assert.Equal(t, HMACSHA256, header.Get(SignatureAlgorithmHeader))
+}
+
+func TestHTTPSenderMethods(t *testing.T) {
+	var method string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(http.StatusOK)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	sender := NewHTTPSender(ts.URL, "", false)
+	tests := []struct {
+		method string
+		send   appcontext.AppFunction
+	}{
+		{http.MethodPost, sender.HTTPPost},
+		{http.MethodPut, sender.HTTPPut},
+		{http.MethodPatch, sender.HTTPPatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			continuePipeline, result := test.send(context, clearString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Equal(t, test.method, method)
+		})
+	}
+}
+
+func TestHTTPSenderHeaders(t *testing.T) {
+	var header http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusOK)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	options := HTTPSenderOptions{
+		Headers: map[string]string{
+			"X-Static":      "static value",
+			"X-Device":      "{{.Data.device}}",
+			"X-Correlation": "{{.CorrelationID}}",
+		},
+	}
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", false, options)
+	require.NoError(t, err)
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient, CorrelationID: "abc"}
+	continuePipeline, result := sender.HTTPPost(ctx, `{"device":"id1"}`)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, "static value", header.Get("X-Static"))
+	assert.Equal(t, devID1, header.Get("X-Device"))
+	assert.Equal(t, "abc", header.Get("X-Correlation"))
+
+	_, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{Headers: map[string]string{"X-Bad": "{{.Data"}})
+	assert.Error(t, err)
+}
+
+func TestHTTPSenderAuthentication(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	var header http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		w.WriteHeader(http.StatusOK)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	config := &common.ConfigurationStruct{}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"Cloud": common.InsecureSecretsInfo{
+			Path:    "cloud",
+			Secrets: map[string]string{"token": "mytoken", "username": "user", "password": "pass", "apikey": "mykey"},
+		},
+	}
+	ctx := &appcontext.Context{
+		LoggingClient:  context.LoggingClient,
+		SecretProvider: security.NewSecretProvider(context.LoggingClient, config),
+	}
+
+	tests := []struct {
+		name     string
+		options  HTTPSenderOptions
+		header   string
+		expected string
+	}{
+		{"Bearer", HTTPSenderOptions{AuthMode: BearerAuth, SecretPath: "cloud"}, "Authorization", "Bearer mytoken"},
+		{"Basic", HTTPSenderOptions{AuthMode: BasicAuth, SecretPath: "cloud"}, "Authorization", "Basic dXNlcjpwYXNz"},
+		{"API Key", HTTPSenderOptions{AuthMode: APIKeyAuth, SecretPath: "cloud"}, DefaultAPIKeyHeader, "mykey"},
+		{"API Key Header", HTTPSenderOptions{AuthMode: APIKeyAuth, SecretPath: "cloud", APIKeyHeader: "X-Key"}, "X-Key", "mykey"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sender, err := NewHTTPSenderWithOptions(ts.URL, "", false, test.options)
+			require.NoError(t, err)
+			continuePipeline, result := sender.HTTPPost(ctx, clearString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Equal(t, test.expected, header.Get(test.header))
+		})
+	}
+
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", true, HTTPSenderOptions{AuthMode: BearerAuth, SecretPath: "missing"})
+	require.NoError(t, err)
+	ctx.RetryData = nil
+	continuePipeline, result := sender.HTTPPost(ctx, clearString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the credentials can't be retrieved")
+	assert.Error(t, result.(error))
+	assert.NotNil(t, ctx.RetryData)
+
+	_, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{AuthMode: BearerAuth})
+	assert.Error(t, err, "Secret path is required")
+	_, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{AuthMode: "digest", SecretPath: "cloud"})
+	assert.Error(t, err, "Unsupported authentication mode")
+}
+
+func TestHTTPSenderTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	// The server's self signed certificate isn't trusted by default
+	continuePipeline, result := NewHTTPSender(ts.URL, "", false).HTTPPost(context, clearString)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	dir, err := ioutil.TempDir("", "http")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(caFile, caPEM, 0644))
+
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{CACertFile: caFile})
+	require.NoError(t, err)
+	continuePipeline, result = sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	sender, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{SkipCertVerify: true})
+	require.NoError(t, err)
+	continuePipeline, result = sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	_, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{CACertFile: filepath.Join(dir, "missing.pem")})
+	assert.Error(t, err)
+	_, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{ClientCertFile: caFile, ClientKeyFile: caFile})
+	assert.Error(t, err)
+}
+
+func TestHTTPSenderTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", true, HTTPSenderOptions{Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := sender.HTTPPost(ctx, clearString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the request times out")
+	assert.Error(t, result.(error))
+	assert.NotNil(t, ctx.RetryData)
+}
+
+func TestHTTPSenderStatusCodes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	options := HTTPSenderOptions{
+		SuccessStatusCodes: []int{http.StatusOK, http.StatusConflict},
+		RetryStatusCodes:   []int{http.StatusServiceUnavailable},
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		options      HTTPSenderOptions
+		success      bool
+		retryDataSet bool
+	}{
+		{"Default success", http.StatusAccepted, HTTPSenderOptions{}, true, false},
+		{"Default failure", http.StatusBadRequest, HTTPSenderOptions{}, false, true},
+		{"Custom success", http.StatusConflict, options, true, false},
+		{"Custom failure", http.StatusAccepted, options, false, false},
+		{"Custom retry", http.StatusServiceUnavailable, options, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sender, err := NewHTTPSenderWithOptions(ts.URL+"/"+strconv.Itoa(test.status), "", true, test.options)
+			require.NoError(t, err)
+
+			ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+			continuePipeline, _ := sender.HTTPPost(ctx, clearString)
+			assert.Equal(t, test.success, continuePipeline)
+			assert.Equal(t, test.retryDataSet, ctx.RetryData != nil)
+		})
+	}
+}
+
+func TestHTTPSenderConnectionReuse(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     HTTPSenderOptions
+		connections int32
+	}{
+		{"Keep alive", HTTPSenderOptions{}, 1},
+		{"Keep alive disabled", HTTPSenderOptions{DisableKeepAlives: true}, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var connections int32
+			ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&connections, 1)
+				}
+			}
+			ts.Start()
+			defer ts.Close()
+
+			sender, err := NewHTTPSenderWithOptions(ts.URL, "", false, test.options)
+			require.NoError(t, err)
+			for i := 0; i < 3; i++ {
+				continuePipeline, result := sender.HTTPPost(context, clearString)
+				require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			}
+			assert.Equal(t, test.connections, atomic.LoadInt32(&connections))
+		})
+	}
+}
+
+func TestHTTPSenderHTTP2(t *testing.T) {
+	var protoMajor int
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protoMajor = r.ProtoMajor
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{SkipCertVerify: true})
+	require.NoError(t, err)
+	continuePipeline, result := sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 2, protoMajor)
+
+	sender, err = NewHTTPSenderWithOptions(ts.URL, "", false, HTTPSenderOptions{SkipCertVerify: true, DisableHTTP2: true})
+	require.NoError(t, err)
+	continuePipeline, result = sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 1, protoMajor)
+}
+
+func TestHTTPSenderProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	sender, err := NewHTTPSenderWithOptions("http://export.invalid/data", "", false, HTTPSenderOptions{ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	continuePipeline, result := sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, "http://export.invalid/data", proxied)
+
+	_, err = NewHTTPSenderWithOptions("http://export.invalid/data", "", false, HTTPSenderOptions{ProxyURL: "ftp://proxy"})
+	assert.Error(t, err)
+	_, err = NewHTTPSenderWithOptions("http://export.invalid/data", "", false, HTTPSenderOptions{ProxyURL: "://proxy"})
+	assert.Error(t, err)
+}
+
+func TestHTTPSenderMaxResponseBytes(t *testing.T) {
+	response := strings.Repeat("x", 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(response))
+	}))
+	defer ts.Close()
+
+	sender, err := NewHTTPSenderWithOptions(ts.URL, "", true, HTTPSenderOptions{MaxResponseBytes: 100})
+	require.NoError(t, err)
+	continuePipeline, result := sender.HTTPPost(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, []byte(response), result)
+
+	sender, err = NewHTTPSenderWithOptions(ts.URL, "", true, HTTPSenderOptions{MaxResponseBytes: 10})
+	require.NoError(t, err)
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result = sender.HTTPPost(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.Nil(t, ctx.RetryData, "Exported data shouldn't be retried")
+}
@@ -15,12 +15,14 @@ import (
 // JSONLogic ...
 type JSONLogic struct {
 	Rule *strings.Reader
+	rule string
 }
 
 // NewJSONLogic creates, initializes and returns a new instance of HTTPSender
 func NewJSONLogic(rule string) JSONLogic {
 	return JSONLogic{
 		Rule: strings.NewReader(rule),
+		rule: rule,
 	}
 }
 
@@ -36,10 +38,16 @@ func (logic JSONLogic) Evaluate(edgexcontext *appcontext.Context, params ...inte
 		return false, err
 	}
 
+	// Applying the rule consumes the reader, so use a new one for each evaluation when the rule text is known
+	rule := logic.Rule
+	if logic.rule != "" {
+		rule = strings.NewReader(logic.rule)
+	}
+
 	data := strings.NewReader(string(coercedData))
 	var logicresult bytes.Buffer
 	edgexcontext.LoggingClient.Debug("Applying JSONLogic Rule")
-	err = jsonlogic.Apply(logic.Rule, data, &logicresult)
+	err = jsonlogic.Apply(rule, data, &logicresult)
 	if err != nil {
 		return false, err
 	}
@@ -31,6 +31,19 @@ func TestJSONLogicAdvanced(t *testing.T) {
 	assert.JSONEq(t, data, result.(string))
 }
 
+func TestJSONLogicEvaluatedRepeatedly(t *testing.T) {
+	jsonlogic := NewJSONLogic(`{"<" : [ { "var" : "temp" }, 110 ]}`)
+
+	continuePipeline, _ := jsonlogic.Evaluate(context, `{ "temp" : 100 }`)
+	assert.True(t, continuePipeline)
+
+	continuePipeline, _ = jsonlogic.Evaluate(context, `{ "temp" : 120 }`)
+	assert.False(t, continuePipeline)
+
+	continuePipeline, _ = jsonlogic.Evaluate(context, `{ "temp" : 90 }`)
+	assert.True(t, continuePipeline, "Rule should still be applied after previous evaluations")
+}
+
 func TestJSONLogicMalformedJSONRule(t *testing.T) {
 	//missing quote
 	jsonlogic := NewJSONLogic(`{"==: [1, 1]}`)
@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/jmespath/go-jmespath"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// Expression languages supported by JSONMapping
+const (
+	JSONPath = "jsonpath"
+	JMESPath = "jmespath"
+)
+
+// JSONMapping houses the mapping rules used to build a new JSON document from the JSON data received
+type JSONMapping struct {
+	// KeepUnmapped keeps the fields of the received JSON object that are not mapped, rather than dropping them
+	KeepUnmapped bool
+	mappings     []fieldMapping
+}
+
+type fieldMapping struct {
+	field      []string
+	expression string
+	evaluate   func(data interface{}) (interface{}, error)
+}
+
+// NewJSONMapping creates, initializes and returns a new instance of JSONMapping. Each rule has the form
+// `outputField = expression`, where the expression is written in the specified language, either JSONPath or JMESPath.
+// The output field may use dots to create nested objects, i.e. `sensor.temperature = $.data.temp`.
+func NewJSONMapping(language string, rules []string, keepUnmapped bool) (*JSONMapping, error) {
+	language, err := parseLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("at least one mapping rule must be specified")
+	}
+
+	mapping := &JSONMapping{KeepUnmapped: keepUnmapped}
+	for _, rule := range rules {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid mapping rule '%s', must be of the form 'outputField = expression'", rule)
+		}
+
+		field := strings.TrimSpace(parts[0])
+		expression := strings.TrimSpace(parts[1])
+		if field == "" || expression == "" {
+			return nil, fmt.Errorf("invalid mapping rule '%s', must be of the form 'outputField = expression'", rule)
+		}
+
+		fieldPath := strings.Split(field, ".")
+		for _, name := range fieldPath {
+			if name == "" {
+				return nil, fmt.Errorf("invalid output field '%s' in mapping rule '%s'", field, rule)
+			}
+		}
+
+		evaluate, err := compileExpression(language, expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression in mapping rule '%s': %s", rule, err.Error())
+		}
+
+		mapping.mappings = append(mapping.mappings, fieldMapping{
+			field:      fieldPath,
+			expression: expression,
+			evaluate:   evaluate,
+		})
+	}
+
+	return mapping, nil
+}
+
+// parseLanguage normalizes the expression language, which must be JSONPath or JMESPath
+func parseLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != JSONPath && language != JMESPath {
+		return "", fmt.Errorf("unsupported expression language '%s', must be %s or %s", language, JSONPath, JMESPath)
+	}
+	return language, nil
+}
+
+func compileExpression(language string, expression string) (func(data interface{}) (interface{}, error), error) {
+	if language == JMESPath {
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return nil, err
+		}
+		return compiled.Search, nil
+	}
+
+	compiled, err := jsonpath.New(expression)
+	if err != nil {
+		return nil, err
+	}
+	return func(data interface{}) (interface{}, error) {
+		return compiled(stdcontext.Background(), data)
+	}, nil
+}
+
+// MapJSON evaluates the mapping rules against the JSON data received from the previous function and returns the
+// resulting JSON document as a []byte. Unless KeepUnmapped is set, the document only contains the mapped fields.
+// This function will return an error and stop the pipeline if the data received is not JSON or an expression fails
+// to evaluate, i.e. a JSONPath refers to a key that is not present.
+func (mapping *JSONMapping) MapJSON(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Mapping JSON")
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return false, fmt.Errorf("unable to map data, expecting JSON: %s", err.Error())
+	}
+	// The numbers are also decoded as json.Number, so large integers keep their precision
+	var precise interface{}
+	if err := decodePrecise(data, &precise); err != nil {
+		return false, fmt.Errorf("unable to map data, expecting JSON: %s", err.Error())
+	}
+
+	output := make(map[string]interface{})
+	if mapping.KeepUnmapped {
+		// Decode a separate copy so the expressions are always evaluated against the unmodified input
+		if _, ok := input.(map[string]interface{}); !ok {
+			return false, errors.New("unable to keep unmapped fields, expecting a JSON object")
+		}
+		if err := decodePrecise(data, &output); err != nil {
+			return false, err
+		}
+	}
+
+	for _, rule := range mapping.mappings {
+		value, err := evaluate(rule.evaluate, input, precise)
+		if err != nil {
+			return false, fmt.Errorf("unable to evaluate '%s': %s", rule.expression, err.Error())
+		}
+		if err := setField(output, rule.field, value); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := json.Marshal(output)
+	if err != nil {
+		return false, err
+	}
+	return true, result
+}
+
+// decodePrecise decodes the JSON data into value with the numbers as json.Number rather than float64
+func decodePrecise(data []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
+
+// setField sets the value at the path of field names, creating the intermediate objects as needed
+func setField(object map[string]interface{}, path []string, value interface{}) error {
+	for _, name := range path[:len(path)-1] {
+		next, ok := object[name].(map[string]interface{})
+		if !ok {
+			if _, exists := object[name]; exists {
+				return fmt.Errorf("unable to set '%s', '%s' is not an object", strings.Join(path, "."), name)
+			}
+			next = make(map[string]interface{})
+			object[name] = next
+		}
+		object = next
+	}
+	object[path[len(path)-1]] = value
+	return nil
+}
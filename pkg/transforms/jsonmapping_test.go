@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vendorJSON = `{"vendor":"acme","data":{"temp":21.5,"unit":"C"},"sensors":[{"id":"s1","v":1},{"id":"s2","v":2}]}`
+
+func TestNewJSONMappingInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		rules    []string
+	}{
+		{"bad language", "xpath", []string{"a = $.a"}},
+		{"no rules", JSONPath, nil},
+		{"no equals", JSONPath, []string{"a $.a"}},
+		{"no field", JSONPath, []string{" = $.a"}},
+		{"empty field name", JSONPath, []string{"a..b = $.a"}},
+		{"bad jsonpath", JSONPath, []string{"a = $.a["}},
+		{"bad jmespath", JMESPath, []string{"a = data.["}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJSONMapping(tt.language, tt.rules, false)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMapJSONWithJSONPath(t *testing.T) {
+	mapping, err := NewJSONMapping("JSONPath", []string{
+		"source = $.vendor",
+		"reading.temperature = $.data.temp",
+		"reading.unit = $.data.unit",
+		"ids = $.sensors[*].id",
+	}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := mapping.MapJSON(context, []byte(vendorJSON))
+	require.True(t, continuePipeline)
+	expected := `{"source":"acme","reading":{"temperature":21.5,"unit":"C"},"ids":["s1","s2"]}`
+	assert.JSONEq(t, expected, string(result.([]byte)))
+}
+
+func TestMapJSONWithJMESPath(t *testing.T) {
+	mapping, err := NewJSONMapping(JMESPath, []string{
+		"temperature = data.temp",
+		"total = sum(sensors[*].v)",
+		"missing = data.humidity",
+	}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := mapping.MapJSON(context, vendorJSON)
+	require.True(t, continuePipeline)
+	assert.JSONEq(t, `{"temperature":21.5,"total":3,"missing":null}`, string(result.([]byte)))
+}
+
+func TestMapJSONKeepUnmapped(t *testing.T) {
+	mapping, err := NewJSONMapping(JSONPath, []string{"data.fahrenheit = $.data.temp", "source = $.vendor", "vendor = $.data.temp"}, true)
+	require.NoError(t, err)
+
+	continuePipeline, result := mapping.MapJSON(context, []byte(`{"vendor":"acme","data":{"temp":70}}`))
+	require.True(t, continuePipeline)
+	assert.JSONEq(t, `{"vendor":70,"source":"acme","data":{"temp":70,"fahrenheit":70}}`, string(result.([]byte)))
+
+	continuePipeline, result = mapping.MapJSON(context, []byte(`[1,2]`))
+	assert.False(t, continuePipeline, "Unmapped fields can only be kept for JSON objects")
+	assert.Error(t, result.(error))
+}
+
+func TestMapJSONLargeNumbers(t *testing.T) {
+	data := []byte(`{"t":1,"ts":1577836800123456789,"readings":[{"v":1577836800123456789}]}`)
+
+	// JSONEq would compare the numbers as float64, so the exact output is compared instead
+	mapping, err := NewJSONMapping(JSONPath, []string{"time = $.ts", "values = $.readings[*].v"}, true)
+	require.NoError(t, err)
+	continuePipeline, result := mapping.MapJSON(context, data)
+	require.True(t, continuePipeline)
+	assert.Equal(t, `{"readings":[{"v":1577836800123456789}],"t":1,"time":1577836800123456789,`+
+		`"ts":1577836800123456789,"values":[1577836800123456789]}`, string(result.([]byte)))
+
+	// Functions such as max are evaluated on float64, so their result is not exact
+	mapping, err = NewJSONMapping(JMESPath, []string{"time = ts", "latest = max(readings[*].v)"}, false)
+	require.NoError(t, err)
+	continuePipeline, result = mapping.MapJSON(context, data)
+	require.True(t, continuePipeline)
+	assert.Equal(t, `{"latest":1577836800123456800,"time":1577836800123456789}`, string(result.([]byte)))
+}
+
+func TestMapJSONErrors(t *testing.T) {
+	mapping, err := NewJSONMapping(JSONPath, []string{"temperature = $.data.temp"}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := mapping.MapJSON(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = mapping.MapJSON(context, []byte("not json"))
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = mapping.MapJSON(context, []byte(`{"vendor":"acme"}`))
+	assert.False(t, continuePipeline, "Pipeline should stop when a JSONPath refers to a missing key")
+	assert.Error(t, result.(error))
+
+	mapping, err = NewJSONMapping(JSONPath, []string{"vendor.name = $.vendor"}, true)
+	require.NoError(t, err)
+	continuePipeline, result = mapping.MapJSON(context, []byte(`{"vendor":"acme"}`))
+	assert.False(t, continuePipeline, "Pipeline should stop when an output field can't be nested")
+	assert.Error(t, result.(error))
+}
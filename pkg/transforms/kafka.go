@@ -0,0 +1,267 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	// KafkaAcksAll waits for all in-sync replicas to acknowledge the message
+	KafkaAcksAll = "all"
+	// KafkaAcksOne waits for the partition leader to acknowledge the message
+	KafkaAcksOne = "one"
+	// KafkaAcksNone doesn't wait for the message to be acknowledged
+	KafkaAcksNone = "none"
+
+	// KafkaSASLPlain authenticates with the username and password secrets using SASL/PLAIN
+	KafkaSASLPlain = util.KafkaSASLPlain
+	// KafkaSASLScramSHA256 authenticates with the username and password secrets using SASL/SCRAM-SHA-256
+	KafkaSASLScramSHA256 = util.KafkaSASLScramSHA256
+	// KafkaSASLScramSHA512 authenticates with the username and password secrets using SASL/SCRAM-SHA-512
+	KafkaSASLScramSHA512 = util.KafkaSASLScramSHA512
+
+	// defaultKafkaBatchTimeout is how long messages sent by concurrent pipelines are batched when BatchTimeout isn't set
+	defaultKafkaBatchTimeout = 10 * time.Millisecond
+)
+
+var kafkaAcks = map[string]kafka.RequiredAcks{
+	KafkaAcksAll:  kafka.RequireAll,
+	KafkaAcksOne:  kafka.RequireOne,
+	KafkaAcksNone: kafka.RequireNone,
+}
+
+var kafkaCompression = map[string]kafka.Compression{
+	"gzip":   kafka.Gzip,
+	"snappy": kafka.Snappy,
+	"lz4":    kafka.Lz4,
+	"zstd":   kafka.Zstd,
+}
+
+// KafkaConfig contains the settings of the KafkaSender
+type KafkaConfig struct {
+	// Brokers are the host:port addresses used to discover the Kafka cluster
+	Brokers []string
+	// Topic is the topic the data is sent to. It can hold placeholders, i.e. site1.{device}, which are resolved from
+	// the data and the context for each message.
+	Topic string
+	// Key is the message key, which can hold placeholders, i.e. {device}. Messages with the same key are sent to the
+	// same partition, while messages are spread across the partitions when it isn't set.
+	Key string
+	// Acks is the acknowledgement, KafkaAcksAll, KafkaAcksOne or KafkaAcksNone, waited for, KafkaAcksAll when not set
+	Acks string
+	// Compression is the gzip, snappy, lz4 or zstd compression of the messages, none when not set
+	Compression string
+	// ClientID identifies the sender to the brokers
+	ClientID string
+	// SASLMechanism is the authentication, KafkaSASLPlain, KafkaSASLScramSHA256 or KafkaSASLScramSHA512, none when
+	// not set. The username and password are retrieved from the SecretPath in the secret store.
+	SASLMechanism string
+	SecretPath    string
+	// UseTLS connects to the brokers using TLS. ClientCertFile and ClientKeyFile are the PEM encoded certificate and
+	// key used for client authentication and CACertFile is the certificate authority used to verify the brokers, the
+	// system's when not set.
+	UseTLS         bool
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+	// BatchTimeout is how long messages sent by concurrent pipelines are batched before being written, 10ms when
+	// not set
+	BatchTimeout time.Duration
+	// Timeout is the time allowed to write the messages, 10 seconds when not set
+	Timeout time.Duration
+}
+
+// kafkaWriter is the part of kafka.Writer used by the KafkaSender
+type kafkaWriter interface {
+	WriteMessages(ctx stdcontext.Context, messages ...kafka.Message) error
+}
+
+// KafkaSender sends data to a Kafka cluster
+type KafkaSender struct {
+	config         KafkaConfig
+	persistOnError bool
+	acks           kafka.RequiredAcks
+	compression    kafka.Compression
+	tlsConfig      *tls.Config
+	mutex          sync.Mutex
+	writer         kafkaWriter
+}
+
+// NewKafkaSender creates, initializes and returns a new instance of KafkaSender. An error is returned if no brokers
+// or topic are set, the Acks, Compression or SASLMechanism isn't supported or the certificates can't be loaded.
+func NewKafkaSender(config KafkaConfig, persistOnError bool) (*KafkaSender, error) {
+	if len(config.Brokers) == 0 {
+		return nil, errors.New("at least one Kafka broker must be set")
+	}
+	if strings.TrimSpace(config.Topic) == "" {
+		return nil, errors.New("the Kafka topic must be set")
+	}
+
+	sender := &KafkaSender{config: config, persistOnError: persistOnError, acks: kafka.RequireAll}
+
+	if config.Acks != "" {
+		acks, ok := kafkaAcks[strings.ToLower(config.Acks)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported Kafka acks '%s', must be %s, %s or %s", config.Acks, KafkaAcksAll, KafkaAcksOne, KafkaAcksNone)
+		}
+		sender.acks = acks
+	}
+
+	if config.Compression != "" {
+		compression, ok := kafkaCompression[strings.ToLower(config.Compression)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported Kafka compression '%s', must be gzip, snappy, lz4 or zstd", config.Compression)
+		}
+		sender.compression = compression
+	}
+
+	if _, err := util.NewKafkaSASLMechanism(config.SASLMechanism, "", ""); err != nil {
+		return nil, err
+	}
+
+	if config.UseTLS {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		sender.tlsConfig = tlsConfig
+	}
+
+	return sender, nil
+}
+
+func (config KafkaConfig) tlsConfig() (*tls.Config, error) {
+	return util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+}
+
+// getWriter returns the writer, creating it on first use since the SASL credentials are retrieved from the secret
+// store using the context. The writer is shared by all pipelines, so their messages are batched together.
+func (sender *KafkaSender) getWriter(edgexcontext *appcontext.Context) (kafkaWriter, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if sender.writer != nil {
+		return sender.writer, nil
+	}
+
+	var mechanism sasl.Mechanism
+	if sender.config.SASLMechanism != "" {
+		secrets, err := getSecrets(edgexcontext, sender.config.SecretPath, "username", "password")
+		if err != nil {
+			return nil, err
+		}
+		mechanism, err = util.NewKafkaSASLMechanism(sender.config.SASLMechanism, secrets["username"], secrets["password"])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batchTimeout := sender.config.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = defaultKafkaBatchTimeout
+	}
+
+	sender.writer = &kafka.Writer{
+		Addr:         kafka.TCP(sender.config.Brokers...),
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: batchTimeout,
+		WriteTimeout: sender.config.Timeout,
+		RequiredAcks: sender.acks,
+		Compression:  sender.compression,
+		Transport: &kafka.Transport{
+			ClientID: sender.config.ClientID,
+			TLS:      sender.tlsConfig,
+			SASL:     mechanism,
+		},
+	}
+	return sender.writer, nil
+}
+
+// KafkaSend sends data from the previous function to the Kafka topic. If no previous function exists, then the event
+// that triggered the pipeline will be used. The context's ExportHeaders, such as the signature, and the correlation
+// ID are sent as message headers.
+// The data isn't sent and the pipeline stops if a placeholder in the topic or key can't be resolved. If the send fails
+// and persistOnError is true and Store and Forward is enabled, the data will be stored for later retry.
+func (sender *KafkaSender) KafkaSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	exportData, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	message := kafka.Message{Value: exportData}
+	message.Topic, err = resolveDestination(sender.config.Topic, edgexcontext, params[0], "")
+	if err != nil {
+		return false, fmt.Errorf("Could not resolve kafka topic: %s", err.Error())
+	}
+	if sender.config.Key != "" {
+		key, err := resolveDestination(sender.config.Key, edgexcontext, params[0], "")
+		if err != nil {
+			return false, fmt.Errorf("Could not resolve kafka key: %s", err.Error())
+		}
+		message.Key = []byte(key)
+	}
+
+	if edgexcontext.CorrelationID != "" {
+		message.Headers = append(message.Headers, kafka.Header{Key: clients.CorrelationHeader, Value: []byte(edgexcontext.CorrelationID)})
+	}
+	names := make([]string, 0, len(edgexcontext.ExportHeaders))
+	for name := range edgexcontext.ExportHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		message.Headers = append(message.Headers, kafka.Header{Key: name, Value: []byte(edgexcontext.ExportHeaders[name])})
+	}
+
+	writer, err := sender.getWriter(edgexcontext)
+	if err == nil {
+		edgexcontext.LoggingClient.Debug("Sending data to Kafka topic " + message.Topic)
+		err = writer.WriteMessages(stdcontext.Background(), message)
+	}
+	if err != nil {
+		if sender.persistOnError {
+			edgexcontext.RetryData = exportData
+		}
+		return false, fmt.Errorf("Could not send data to kafka: %s", err.Error())
+	}
+
+	edgexcontext.LoggingClient.Debug("Sent data to Kafka")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "Kafka", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
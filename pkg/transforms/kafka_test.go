@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+type fakeKafkaWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (writer *fakeKafkaWriter) WriteMessages(_ stdcontext.Context, messages ...kafka.Message) error {
+	if writer.err != nil {
+		return writer.err
+	}
+	writer.messages = append(writer.messages, messages...)
+	return nil
+}
+
+func TestNewKafkaSender(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Config    KafkaConfig
+		ExpectErr bool
+	}{
+		{"Valid", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export"}, false},
+		{"Valid Options", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", Acks: "ONE", Compression: "zstd", SASLMechanism: KafkaSASLScramSHA512}, false},
+		{"No Brokers", KafkaConfig{Topic: "export"}, true},
+		{"No Topic", KafkaConfig{Brokers: []string{"localhost:9092"}}, true},
+		{"Bad Acks", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", Acks: "some"}, true},
+		{"Bad Compression", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", Compression: "brotli"}, true},
+		{"Bad SASL", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", SASLMechanism: "gssapi"}, true},
+		{"Missing CA", KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", UseTLS: true, CACertFile: "/no/such/ca.pem"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sender, err := NewKafkaSender(test.Config, false)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				assert.Nil(t, sender)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, sender)
+		})
+	}
+}
+
+func TestKafkaSend(t *testing.T) {
+	config := KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "site1.{device}", Key: "{device}-{reading}"}
+	sender, err := NewKafkaSender(config, false)
+	require.NoError(t, err)
+	writer := &fakeKafkaWriter{}
+	sender.writer = writer
+
+	ctx := &appcontext.Context{
+		CorrelationID: "correlation1",
+		LoggingClient: context.LoggingClient,
+		ExportHeaders: map[string]string{DefaultSignatureHeader: "signature", SignatureAlgorithmHeader: HMACSHA256},
+	}
+	event := models.Event{Device: "thermostat", Readings: []models.Reading{{Name: "temperature", Value: "21.5"}}}
+	continuePipeline, result := sender.KafkaSend(ctx, event)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	require.Len(t, writer.messages, 1)
+
+	message := writer.messages[0]
+	assert.Equal(t, "site1.thermostat", message.Topic)
+	assert.Equal(t, "thermostat-temperature", string(message.Key))
+	assert.JSONEq(t, `{"device":"thermostat","readings":[{"name":"temperature","value":"21.5"}]}`, string(message.Value))
+	assert.Equal(t, []kafka.Header{
+		{Key: clients.CorrelationHeader, Value: []byte("correlation1")},
+		{Key: DefaultSignatureHeader, Value: []byte("signature")},
+		{Key: SignatureAlgorithmHeader, Value: []byte(HMACSHA256)},
+	}, message.Headers)
+}
+
+func TestKafkaSendNoData(t *testing.T) {
+	sender, err := NewKafkaSender(KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export"}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := sender.KafkaSend(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestKafkaSendUnresolvedKey(t *testing.T) {
+	sender, err := NewKafkaSender(KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", Key: "{device}"}, true)
+	require.NoError(t, err)
+	sender.writer = &fakeKafkaWriter{}
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := sender.KafkaSend(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Could not resolve kafka key")
+	assert.Nil(t, ctx.RetryData)
+}
+
+func TestKafkaSendPersistOnError(t *testing.T) {
+	tests := []struct {
+		Name           string
+		PersistOnError bool
+	}{
+		{"Persist", true},
+		{"Drop", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sender, err := NewKafkaSender(KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export"}, test.PersistOnError)
+			require.NoError(t, err)
+			sender.writer = &fakeKafkaWriter{err: errors.New("leader not available")}
+
+			ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+			continuePipeline, result := sender.KafkaSend(ctx, clearString)
+			require.False(t, continuePipeline)
+			assert.Contains(t, result.(error).Error(), "leader not available")
+			if test.PersistOnError {
+				assert.Equal(t, []byte(clearString), ctx.RetryData)
+			} else {
+				assert.Nil(t, ctx.RetryData)
+			}
+		})
+	}
+}
+
+func TestKafkaSendSASLSecrets(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	config := KafkaConfig{Brokers: []string{"localhost:9092"}, Topic: "export", SASLMechanism: KafkaSASLPlain, SecretPath: "mqtt"}
+	sender, err := NewKafkaSender(config, false)
+	require.NoError(t, err)
+
+	writer, err := sender.getWriter(mqttSecretsContext())
+	require.NoError(t, err)
+	transport := writer.(*kafka.Writer).Transport.(*kafka.Transport)
+	assert.Equal(t, "mqttuser", transport.SASL.(plain.Mechanism).Username)
+	assert.Equal(t, "mqttpassword", transport.SASL.(plain.Mechanism).Password)
+
+	sender, err = NewKafkaSender(config, false)
+	require.NoError(t, err)
+	_, err = sender.getWriter(&appcontext.Context{LoggingClient: context.LoggingClient})
+	assert.Error(t, err, "The secret provider isn't available")
+}
@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/edgexfoundry/go-mod-core-contracts/clients"
@@ -39,8 +41,39 @@ type MqttConfig struct {
 	SkipCertVerify bool
 	User           string
 	Password       string
+	// ProtocolVersion is the MQTT version, 3 for 3.1, 4 for 3.1.1 or 5, 3.1.1 falling back to 3.1 when not set
+	ProtocolVersion uint
+	// PersistentSession resumes the session, receiving the messages queued while disconnected, rather than starting
+	// a clean session when connecting. SessionExpiry is how long the broker keeps an MQTT 5 session, forever when not set.
+	PersistentSession bool
+	SessionExpiry     time.Duration
+	// KeepAlive is the interval between pings and ConnectTimeout the time allowed to connect, 30 seconds when not set
+	KeepAlive      time.Duration
+	ConnectTimeout time.Duration
+	// WillTopic is the topic on which the broker publishes the WillPayload when the connection is lost, none when not set
+	WillTopic   string
+	WillPayload []byte
+	WillQos     byte
+	WillRetain  bool
+	// CACertFile is the PEM encoded certificate authority used to verify the broker, the system's when not set
+	CACertFile string
+	// SecretPath, when set, is the path in the secret store of the username and password used to connect, rather than
+	// User and Password, or those of the Addressable
+	SecretPath string
+	// ContentType, MessageExpiry and UserProperties are the MQTT 5 properties of the published messages. The
+	// ExportHeaders added by previous functions in the pipeline are sent as user properties as well.
+	ContentType    string
+	MessageExpiry  time.Duration
+	UserProperties map[string]string
+	// OnConnect and OnConnectionLost, when set, are called after the sender connects to and loses the connection to
+	// the broker
+	OnConnect        func()
+	OnConnectionLost func(error)
 }
 
+// defaultMQTTTimeout is the KeepAlive and ConnectTimeout used when they aren't set
+const defaultMQTTTimeout = 30 * time.Second
+
 // KeyCertPair is used to pass key/cert pair to NewMQTTSender
 // KeyPEMBlock and CertPEMBlock will be used if they are not nil
 // then it will fall back to KeyFile and CertFile
@@ -53,9 +86,32 @@ type KeyCertPair struct {
 
 type MQTTSender struct {
 	client         MQTT.Client
+	client5        *mqtt5Client
 	topic          string
 	opts           MqttConfig
 	persistOnError bool
+	credentials    *mqttCredentials
+	mutex          *sync.Mutex
+}
+
+// mqttCredentials holds the username and password used to connect, which are updated from the secret store
+type mqttCredentials struct {
+	mutex    sync.Mutex
+	username string
+	password string
+}
+
+func (credentials *mqttCredentials) get() (string, string) {
+	credentials.mutex.Lock()
+	defer credentials.mutex.Unlock()
+	return credentials.username, credentials.password
+}
+
+func (credentials *mqttCredentials) set(username string, password string) {
+	credentials.mutex.Lock()
+	defer credentials.mutex.Unlock()
+	credentials.username = username
+	credentials.password = password
 }
 
 // NewMQTTSender - create new mqtt sender
@@ -63,51 +119,150 @@ func NewMQTTSender(logging logger.LoggingClient, addr models.Addressable, keyCer
 	mqttConfig MqttConfig, persistOnError bool) *MQTTSender {
 	protocol := strings.ToLower(addr.Protocol)
 
+	username, password := addr.User, addr.Password
+	if mqttConfig.User != "" {
+		username, password = mqttConfig.User, mqttConfig.Password
+	}
+
+	var tlsConfig *tls.Config
+	if protocol == "tcps" || protocol == "ssl" || protocol == "tls" {
+		var err error
+		tlsConfig, err = newMQTTTLSConfig(keyCertPair, mqttConfig)
+		if err != nil {
+			logging.Error("Failed loading x509 data", "error", err)
+			return nil
+		}
+	}
+
+	onConnect := func() {
+		logging.Info("Connected to mqtt server")
+		if mqttConfig.OnConnect != nil {
+			mqttConfig.OnConnect()
+		}
+	}
+	onConnectionLost := func(err error) {
+		logging.Warn(fmt.Sprintf("Lost connection to mqtt server: %s", err.Error()))
+		if mqttConfig.OnConnectionLost != nil {
+			mqttConfig.OnConnectionLost(err)
+		}
+	}
+
+	sender := &MQTTSender{
+		topic:          addr.Topic,
+		opts:           mqttConfig,
+		persistOnError: persistOnError,
+		credentials:    &mqttCredentials{username: username, password: password},
+		mutex:          &sync.Mutex{},
+	}
+
+	switch mqttConfig.ProtocolVersion {
+	case 0, 3, 4:
+	case 5:
+		broker := addr.Address + ":" + strconv.Itoa(addr.Port)
+		sender.client5 = newMQTT5Client(protocol, broker, addr.Publisher, mqttConfig, tlsConfig, sender.credentials,
+			onConnect, onConnectionLost)
+		return sender
+	default:
+		logging.Error(fmt.Sprintf("Unsupported MQTT protocol version %d, must be 3, 4 or 5", mqttConfig.ProtocolVersion))
+		return nil
+	}
+
 	opts := MQTT.NewClientOptions()
 	broker := protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + addr.Path
 	opts.AddBroker(broker)
 	opts.SetClientID(addr.Publisher)
-	opts.SetUsername(addr.User)
-	opts.SetPassword(addr.Password)
+	opts.SetUsername(username)
+	opts.SetPassword(password)
+	opts.SetCredentialsProvider(sender.credentials.get)
 	opts.SetAutoReconnect(mqttConfig.AutoReconnect)
+	opts.SetCleanSession(!mqttConfig.PersistentSession)
+	if mqttConfig.ProtocolVersion != 0 {
+		opts.SetProtocolVersion(mqttConfig.ProtocolVersion)
+	}
+	if mqttConfig.KeepAlive > 0 {
+		opts.SetKeepAlive(mqttConfig.KeepAlive)
+	}
+	if mqttConfig.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(mqttConfig.ConnectTimeout)
+	}
+	if mqttConfig.WillTopic != "" {
+		opts.SetBinaryWill(mqttConfig.WillTopic, mqttConfig.WillPayload, mqttConfig.WillQos, mqttConfig.WillRetain)
+	}
+	opts.SetOnConnectHandler(func(MQTT.Client) {
+		onConnect()
+	})
+	opts.SetConnectionLostHandler(func(_ MQTT.Client, err error) {
+		onConnectionLost(err)
+	})
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
 
-	if (protocol == "tcps" || protocol == "ssl" || protocol == "tls") && keyCertPair != nil {
-		var cert tls.Certificate
-		var err error
+	sender.client = MQTT.NewClient(opts)
+	return sender
+}
 
+// newMQTTTLSConfig returns the TLS configuration using the optional client certificate and CA certificate
+func newMQTTTLSConfig(keyCertPair *KeyCertPair, mqttConfig MqttConfig) (*tls.Config, error) {
+	tlsConfig, err := util.NewTLSConfig("", "", mqttConfig.CACertFile, mqttConfig.SkipCertVerify)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyCertPair != nil {
+		var cert tls.Certificate
 		if keyCertPair.KeyPEMBlock != nil && keyCertPair.CertPEMBlock != nil {
 			cert, err = tls.X509KeyPair(keyCertPair.CertPEMBlock, keyCertPair.KeyPEMBlock)
 		} else {
 			cert, err = tls.LoadX509KeyPair(keyCertPair.CertFile, keyCertPair.KeyFile)
 		}
-
 		if err != nil {
-			logging.Error("Failed loading x509 data")
-			return nil
+			return nil, err
 		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
 
-		tlsConfig := &tls.Config{
-			ClientCAs:          nil,
-			InsecureSkipVerify: mqttConfig.SkipCertVerify,
-			Certificates:       []tls.Certificate{cert},
-		}
+	return tlsConfig, nil
+}
 
-		opts.SetTLSConfig(tlsConfig)
+// Connect connects to the MQTT broker, if not already connected, retrieving the username and password from the
+// secret store when the SecretPath is set. MQTTSend connects when the first data is sent if Connect isn't called.
+func (sender MQTTSender) Connect(edgexcontext *appcontext.Context) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
 
+	if sender.isConnected() {
+		return nil
 	}
 
-	sender := &MQTTSender{
-		client:         MQTT.NewClient(opts),
-		topic:          addr.Topic,
-		opts:           mqttConfig,
-		persistOnError: persistOnError,
+	if sender.opts.SecretPath != "" {
+		secrets, err := getSecrets(edgexcontext, sender.opts.SecretPath, "username", "password")
+		if err != nil {
+			return err
+		}
+		sender.credentials.set(secrets["username"], secrets["password"])
 	}
 
-	return sender
+	edgexcontext.LoggingClient.Info("Connecting to mqtt server")
+	if sender.client5 != nil {
+		return sender.client5.connect()
+	}
+	token := sender.client.Connect()
+	token.Wait()
+	return token.Error()
+}
+
+func (sender MQTTSender) isConnected() bool {
+	if sender.client5 != nil {
+		return sender.client5.isConnected()
+	}
+	return sender.client.IsConnected()
 }
 
 // MQTTSend sends data from the previous function to the specified MQTT broker.
 // If no previous function exists, then the event that triggered the pipeline will be used.
+// The topic can hold placeholders, i.e. site1/{device}/{reading}, which are resolved from the data and the context
+// for each message. The data isn't sent and the pipeline stops if a placeholder can't be resolved.
 func (sender MQTTSender) MQTTSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
 	if len(params) < 1 {
 		// We didn't receive a result
@@ -119,24 +274,30 @@ func (sender MQTTSender) MQTTSend(edgexcontext *appcontext.Context, params ...in
 		return false, err
 	}
 
-	if !sender.client.IsConnected() {
-		edgexcontext.LoggingClient.Info("Connecting to mqtt server")
-		if token := sender.client.Connect(); token.Wait() && token.Error() != nil {
-			sender.setRetryData(edgexcontext, exportData)
-			subMessage := "drop event"
-			if sender.persistOnError {
-				subMessage = "persisting Event for later retry"
-			}
-			return false, fmt.Errorf("Could not connect to mqtt server, %s. Error: %s", subMessage, token.Error().Error())
+	topic, err := resolveTopic(sender.topic, edgexcontext, params[0])
+	if err != nil {
+		return false, fmt.Errorf("Could not resolve mqtt topic: %s", err.Error())
+	}
+
+	if err := sender.Connect(edgexcontext); err != nil {
+		sender.setRetryData(edgexcontext, exportData)
+		subMessage := "drop event"
+		if sender.persistOnError {
+			subMessage = "persisting Event for later retry"
 		}
-		edgexcontext.LoggingClient.Info("Connected to mqtt server")
+		return false, fmt.Errorf("Could not connect to mqtt server, %s. Error: %s", subMessage, err.Error())
 	}
 
-	token := sender.client.Publish(sender.topic, sender.opts.Qos, sender.opts.Retain, exportData)
-	token.Wait()
-	if token.Error() != nil {
+	if sender.client5 != nil {
+		err = sender.client5.publish(topic, exportData, edgexcontext.ExportHeaders)
+	} else {
+		token := sender.client.Publish(topic, sender.opts.Qos, sender.opts.Retain, exportData)
+		token.Wait()
+		err = token.Error()
+	}
+	if err != nil {
 		sender.setRetryData(edgexcontext, exportData)
-		return false, token.Error()
+		return false, err
 	}
 
 	edgexcontext.LoggingClient.Debug("Sent data to MQTT Broker")
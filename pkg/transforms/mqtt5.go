@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// mqtt5Client publishes to the broker using MQTT 5. Unlike the MQTT 3 client it doesn't reconnect by itself, so the
+// connection is dropped when lost and MQTTSend connects again when the next data is sent.
+type mqtt5Client struct {
+	protocol         string
+	broker           string
+	clientID         string
+	config           MqttConfig
+	tlsConfig        *tls.Config
+	credentials      *mqttCredentials
+	onConnect        func()
+	onConnectionLost func(error)
+	mutex            sync.Mutex
+	client           *paho.Client
+	// publishMutex serializes the publishing since paho doesn't synchronize writing QoS 0 messages
+	publishMutex sync.Mutex
+}
+
+func newMQTT5Client(protocol string, broker string, clientID string, config MqttConfig, tlsConfig *tls.Config,
+	credentials *mqttCredentials, onConnect func(), onConnectionLost func(error)) *mqtt5Client {
+	return &mqtt5Client{
+		protocol:         protocol,
+		broker:           broker,
+		clientID:         clientID,
+		config:           config,
+		tlsConfig:        tlsConfig,
+		credentials:      credentials,
+		onConnect:        onConnect,
+		onConnectionLost: onConnectionLost,
+	}
+}
+
+func (client *mqtt5Client) isConnected() bool {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.client != nil
+}
+
+func (client *mqtt5Client) connect() error {
+	timeout := client.config.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultMQTTTimeout
+	}
+	keepAlive := client.config.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = defaultMQTTTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	switch client.protocol {
+	case "tcp", "mqtt":
+		conn, err = dialer.Dial("tcp", client.broker)
+	case "tcps", "ssl", "tls", "mqtts":
+		conn, err = tls.DialWithDialer(dialer, "tcp", client.broker, client.tlsConfig)
+	default:
+		return fmt.Errorf("unsupported protocol '%s' for MQTT 5, must be tcp or tls", client.protocol)
+	}
+	if err != nil {
+		return err
+	}
+
+	var pahoClient *paho.Client
+	pahoClient = paho.NewClient(paho.ClientConfig{
+		Conn: conn,
+		OnClientError: func(err error) {
+			client.connectionLost(pahoClient, err)
+		},
+		OnServerDisconnect: func(disconnect *paho.Disconnect) {
+			client.connectionLost(pahoClient, fmt.Errorf("disconnected by the broker with reason code %d", disconnect.ReasonCode))
+		},
+	})
+
+	username, password := client.credentials.get()
+	connect := &paho.Connect{
+		ClientID:     client.clientID,
+		KeepAlive:    uint16(keepAlive / time.Second),
+		CleanStart:   !client.config.PersistentSession,
+		Username:     username,
+		UsernameFlag: username != "",
+		Password:     []byte(password),
+		PasswordFlag: password != "",
+	}
+	if client.config.PersistentSession {
+		// The maximum expiry interval means the session never expires
+		expiry := uint32(math.MaxUint32)
+		if client.config.SessionExpiry > 0 {
+			expiry = uint32(client.config.SessionExpiry / time.Second)
+		}
+		connect.Properties = &paho.ConnectProperties{SessionExpiryInterval: &expiry}
+	}
+	if client.config.WillTopic != "" {
+		connect.WillMessage = &paho.WillMessage{
+			Topic:   client.config.WillTopic,
+			Payload: client.config.WillPayload,
+			QoS:     client.config.WillQos,
+			Retain:  client.config.WillRetain,
+		}
+	}
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), timeout)
+	defer cancel()
+	connack, err := pahoClient.Connect(ctx, connect)
+	if err != nil {
+		if connack != nil {
+			return fmt.Errorf("%s, reason code %d", err.Error(), connack.ReasonCode)
+		}
+		return err
+	}
+
+	client.mutex.Lock()
+	client.client = pahoClient
+	client.mutex.Unlock()
+	client.onConnect()
+	return nil
+}
+
+// connectionLost drops the connection, unless it has already been replaced by a new one
+func (client *mqtt5Client) connectionLost(pahoClient *paho.Client, err error) {
+	client.mutex.Lock()
+	if client.client != pahoClient {
+		client.mutex.Unlock()
+		return
+	}
+	client.client = nil
+	client.mutex.Unlock()
+	client.onConnectionLost(err)
+}
+
+// publish publishes the data with the configured properties and the headers as user properties
+func (client *mqtt5Client) publish(topic string, data []byte, headers map[string]string) error {
+	client.mutex.Lock()
+	pahoClient := client.client
+	client.mutex.Unlock()
+	if pahoClient == nil {
+		return errors.New("not connected to mqtt server")
+	}
+
+	properties := &paho.PublishProperties{ContentType: client.config.ContentType}
+	if client.config.MessageExpiry > 0 {
+		expiry := uint32(client.config.MessageExpiry / time.Second)
+		properties.MessageExpiry = &expiry
+	}
+	userProperties := make(map[string]string, len(client.config.UserProperties)+len(headers))
+	for key, value := range client.config.UserProperties {
+		userProperties[key] = value
+	}
+	for key, value := range headers {
+		userProperties[key] = value
+	}
+	keys := make([]string, 0, len(userProperties))
+	for key := range userProperties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		properties.User.Add(key, userProperties[key])
+	}
+
+	client.publishMutex.Lock()
+	defer client.publishMutex.Unlock()
+	_, err := pahoClient.Publish(stdcontext.Background(), &paho.Publish{
+		Topic:      topic,
+		QoS:        client.config.Qos,
+		Retain:     client.config.Retain,
+		Payload:    data,
+		Properties: properties,
+	})
+	return err
+}
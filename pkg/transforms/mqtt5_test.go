@@ -0,0 +1,368 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	MQTTPackets "github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+)
+
+// fakeBroker accepts connections and passes each to the handler, which implements just enough of the protocol
+type fakeBroker struct {
+	listener net.Listener
+}
+
+func newFakeBroker(t *testing.T, listener net.Listener, handler func(conn net.Conn)) *fakeBroker {
+	broker := &fakeBroker{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handler(conn)
+			}()
+		}
+	}()
+	return broker
+}
+
+func (broker *fakeBroker) addressable(protocol string) models.Addressable {
+	host, port, _ := net.SplitHostPort(broker.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return models.Addressable{
+		Address:   host,
+		Port:      portNumber,
+		Protocol:  protocol,
+		Publisher: "publisher",
+		Topic:     "export",
+	}
+}
+
+func (broker *fakeBroker) close() {
+	broker.listener.Close()
+}
+
+// mqtt5Handler acknowledges the connection with the reasonCode and the QoS 1 messages published, passing the
+// packets received on the channels. The connection is closed after closeAfter messages when it is set.
+func mqtt5Handler(connects chan<- *packets.Connect, publishes chan<- *packets.Publish, reasonCode byte, closeAfter int) func(conn net.Conn) {
+	return func(conn net.Conn) {
+		published := 0
+		for {
+			received, err := packets.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			switch packet := received.Content.(type) {
+			case *packets.Connect:
+				connects <- packet
+				connack := packets.NewControlPacket(packets.CONNACK)
+				connack.Content.(*packets.Connack).ReasonCode = reasonCode
+				_, _ = connack.WriteTo(conn)
+				if reasonCode != 0 {
+					return
+				}
+			case *packets.Publish:
+				publishes <- packet
+				if packet.QoS == 1 {
+					puback := packets.NewControlPacket(packets.PUBACK)
+					puback.Content.(*packets.Puback).PacketID = packet.PacketID
+					_, _ = puback.WriteTo(conn)
+				}
+				published++
+				if closeAfter > 0 && published >= closeAfter {
+					return
+				}
+			case *packets.Pingreq:
+				_, _ = packets.NewControlPacket(packets.PINGRESP).WriteTo(conn)
+			}
+		}
+	}
+}
+
+func mqttSecretsContext() *appcontext.Context {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+
+	config := &common.ConfigurationStruct{}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"MQTT": common.InsecureSecretsInfo{
+			Path:    "mqtt",
+			Secrets: map[string]string{"username": "mqttuser", "password": "mqttpassword"},
+		},
+	}
+	return &appcontext.Context{
+		LoggingClient:  context.LoggingClient,
+		SecretProvider: security.NewSecretProvider(context.LoggingClient, config),
+	}
+}
+
+func localListener(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	return listener
+}
+
+func TestMQTT5Send(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	connects := make(chan *packets.Connect, 1)
+	publishes := make(chan *packets.Publish, 1)
+	broker := newFakeBroker(t, localListener(t), mqtt5Handler(connects, publishes, 0, 0))
+	defer broker.close()
+
+	connected := make(chan bool, 1)
+	mqttConfig := MqttConfig{
+		ProtocolVersion:   5,
+		Qos:               1,
+		PersistentSession: true,
+		SessionExpiry:     time.Hour,
+		KeepAlive:         10 * time.Second,
+		WillTopic:         "status",
+		WillPayload:       []byte("offline"),
+		SecretPath:        "mqtt",
+		ContentType:       "application/json",
+		MessageExpiry:     time.Minute,
+		UserProperties:    map[string]string{"site": "plant1"},
+		OnConnect:         func() { connected <- true },
+	}
+	sender := NewMQTTSender(context.LoggingClient, broker.addressable("tcp"), nil, mqttConfig, false)
+	require.NotNil(t, sender)
+
+	ctx := mqttSecretsContext()
+	ctx.ExportHeaders = map[string]string{SignatureAlgorithmHeader: HMACSHA256}
+	continuePipeline, result := sender.MQTTSend(ctx, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.True(t, <-connected)
+
+	connect := <-connects
+	assert.Equal(t, byte(5), connect.ProtocolVersion)
+	assert.Equal(t, "publisher", connect.ClientID)
+	assert.Equal(t, "mqttuser", connect.Username)
+	assert.Equal(t, "mqttpassword", string(connect.Password))
+	assert.False(t, connect.CleanStart)
+	assert.Equal(t, uint32(3600), *connect.Properties.SessionExpiryInterval)
+	assert.Equal(t, uint16(10), connect.KeepAlive)
+	assert.Equal(t, "status", connect.WillTopic)
+	assert.Equal(t, "offline", string(connect.WillMessage))
+
+	publish := <-publishes
+	assert.Equal(t, "export", publish.Topic)
+	assert.Equal(t, byte(1), publish.QoS)
+	assert.Equal(t, clearString, string(publish.Payload))
+	assert.Equal(t, "application/json", publish.Properties.ContentType)
+	assert.Equal(t, uint32(60), *publish.Properties.MessageExpiry)
+	assert.Equal(t, []packets.User{{Key: SignatureAlgorithmHeader, Value: HMACSHA256}, {Key: "site", Value: "plant1"}}, publish.Properties.User)
+}
+
+func TestMQTT5SendReconnects(t *testing.T) {
+	connects := make(chan *packets.Connect, 2)
+	publishes := make(chan *packets.Publish, 2)
+	broker := newFakeBroker(t, localListener(t), mqtt5Handler(connects, publishes, 0, 1))
+	defer broker.close()
+
+	lost := make(chan error, 1)
+	mqttConfig := MqttConfig{ProtocolVersion: 5, Qos: 1, OnConnectionLost: func(err error) { lost <- err }}
+	sender := NewMQTTSender(context.LoggingClient, broker.addressable("tcp"), nil, mqttConfig, false)
+	require.NotNil(t, sender)
+
+	continuePipeline, result := sender.MQTTSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	select {
+	case err := <-lost:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Connection lost handler wasn't called")
+	}
+
+	continuePipeline, result = sender.MQTTSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Len(t, connects, 2, "The sender should connect again")
+}
+
+func TestMQTT5SendDynamicTopic(t *testing.T) {
+	connects := make(chan *packets.Connect, 1)
+	publishes := make(chan *packets.Publish, 1)
+	broker := newFakeBroker(t, localListener(t), mqtt5Handler(connects, publishes, 0, 0))
+	defer broker.close()
+
+	addr := broker.addressable("tcp")
+	addr.Topic = "site1/{device}/{reading}"
+	sender := NewMQTTSender(context.LoggingClient, addr, nil, MqttConfig{ProtocolVersion: 5, Qos: 1}, true)
+	require.NotNil(t, sender)
+
+	event := models.Event{Device: "thermostat", Readings: []models.Reading{{Name: "temperature", Value: "21.5"}}}
+	continuePipeline, result := sender.MQTTSend(context, event)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, "site1/thermostat/temperature", (<-publishes).Topic)
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result = sender.MQTTSend(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Could not resolve mqtt topic")
+	assert.Nil(t, ctx.RetryData, "Data with an unresolved topic shouldn't be retried")
+}
+
+func TestMQTT5ConnectRefused(t *testing.T) {
+	// 0x86 is the bad user name or password reason code
+	connects := make(chan *packets.Connect, 1)
+	broker := newFakeBroker(t, localListener(t), mqtt5Handler(connects, nil, 0x86, 0))
+	defer broker.close()
+
+	sender := NewMQTTSender(context.LoggingClient, broker.addressable("tcp"), nil, MqttConfig{ProtocolVersion: 5}, true)
+	require.NotNil(t, sender)
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := sender.MQTTSend(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Could not connect to mqtt server")
+	assert.NotNil(t, ctx.RetryData)
+}
+
+func TestMQTTSendV3Session(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	connects := make(chan *MQTTPackets.ConnectPacket, 1)
+	publishes := make(chan *MQTTPackets.PublishPacket, 1)
+	broker := newFakeBroker(t, localListener(t), func(conn net.Conn) {
+		for {
+			received, err := MQTTPackets.ReadPacket(conn)
+			if err != nil {
+				return
+			}
+			switch packet := received.(type) {
+			case *MQTTPackets.ConnectPacket:
+				connects <- packet
+				_ = MQTTPackets.NewControlPacket(MQTTPackets.Connack).Write(conn)
+			case *MQTTPackets.PublishPacket:
+				publishes <- packet
+			case *MQTTPackets.PingreqPacket:
+				_ = MQTTPackets.NewControlPacket(MQTTPackets.Pingresp).Write(conn)
+			}
+		}
+	})
+	defer broker.close()
+
+	mqttConfig := MqttConfig{
+		ProtocolVersion:   4,
+		PersistentSession: true,
+		KeepAlive:         20 * time.Second,
+		WillTopic:         "status",
+		WillPayload:       []byte("offline"),
+		WillQos:           1,
+		SecretPath:        "mqtt",
+	}
+	sender := NewMQTTSender(context.LoggingClient, broker.addressable("tcp"), nil, mqttConfig, false)
+	require.NotNil(t, sender)
+
+	continuePipeline, result := sender.MQTTSend(mqttSecretsContext(), clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	connect := <-connects
+	assert.Equal(t, byte(4), connect.ProtocolVersion)
+	assert.False(t, connect.CleanSession)
+	assert.Equal(t, uint16(20), connect.Keepalive)
+	assert.Equal(t, "mqttuser", connect.Username)
+	assert.Equal(t, "mqttpassword", string(connect.Password))
+	assert.True(t, connect.WillFlag)
+	assert.Equal(t, "status", connect.WillTopic)
+	assert.Equal(t, byte(1), connect.WillQos)
+
+	publish := <-publishes
+	assert.Equal(t, "export", publish.TopicName)
+	assert.Equal(t, clearString, string(publish.Payload))
+}
+
+func TestMQTTSendCAOnlyTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mqtt")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "broker"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0644))
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{certDER}, PrivateKey: key}},
+	})
+	require.NoError(t, err)
+	connects := make(chan *packets.Connect, 2)
+	publishes := make(chan *packets.Publish, 2)
+	broker := newFakeBroker(t, listener, mqtt5Handler(connects, publishes, 0, 0))
+	defer broker.close()
+
+	// The broker's certificate isn't trusted without the CA certificate
+	sender := NewMQTTSender(context.LoggingClient, broker.addressable("tls"), nil, MqttConfig{ProtocolVersion: 5}, false)
+	require.NotNil(t, sender)
+	continuePipeline, _ := sender.MQTTSend(context, clearString)
+	require.False(t, continuePipeline)
+
+	sender = NewMQTTSender(context.LoggingClient, broker.addressable("tls"), nil, MqttConfig{ProtocolVersion: 5, CACertFile: caFile}, false)
+	require.NotNil(t, sender)
+	continuePipeline, result := sender.MQTTSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, clearString, string((<-publishes).Payload))
+
+	sender = NewMQTTSender(context.LoggingClient, broker.addressable("tls"), nil, MqttConfig{CACertFile: filepath.Join(dir, "missing.pem")}, false)
+	assert.Nil(t, sender, "Sender shouldn't be created when the CA certificate can't be read")
+}
+
+func TestNewMQTTSenderProtocolVersion(t *testing.T) {
+	addressable := models.Addressable{Address: "localhost", Port: 1883, Protocol: "tcp", Topic: "export"}
+	for _, version := range []uint{0, 3, 4, 5} {
+		assert.NotNil(t, NewMQTTSender(context.LoggingClient, addressable, nil, MqttConfig{ProtocolVersion: version}, false))
+	}
+	assert.Nil(t, NewMQTTSender(context.LoggingClient, addressable, nil, MqttConfig{ProtocolVersion: 6}, false))
+}
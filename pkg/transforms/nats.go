@@ -0,0 +1,248 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/nats-io/nats.go"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+// defaultNATSTimeout is how long to wait for the server to receive a message when Timeout isn't set
+const defaultNATSTimeout = 5 * time.Second
+
+// NATSConfig contains the settings of the NATSSender
+type NATSConfig struct {
+	// URL is the nats:// URL of the server, or a comma separated list of the URLs of a cluster
+	URL string
+	// Subject is the subject the data is published to. It can hold placeholders, i.e. site1.{device}, which are
+	// resolved from the data and the context for each message.
+	Subject string
+	// JetStream publishes the data to the stream holding the subject and waits for the stream to store it
+	JetStream bool
+	// SecretPath is the path in the secret store holding the username and password, or the token, when set
+	SecretPath string
+	// CredentialsFile is the NATS credentials file holding the user JWT and NKey seed, when set
+	CredentialsFile string
+	// UseTLS connects to the server using TLS, with the optional client certificate and CA certificate
+	UseTLS         bool
+	ClientCertFile string
+	ClientKeyFile  string
+	CACertFile     string
+	SkipCertVerify bool
+	// Timeout is how long to wait for the server, or the stream, to receive a message, 5 seconds when not set
+	Timeout time.Duration
+}
+
+// natsPublisher publishes a message and waits for the server, or the stream when using JetStream, to receive it
+type natsPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+	IsClosed() bool
+}
+
+// natsConnection publishes using core NATS, or JetStream when set
+type natsConnection struct {
+	*nats.Conn
+	jetStream nats.JetStreamContext
+	timeout   time.Duration
+}
+
+func (connection *natsConnection) PublishMsg(msg *nats.Msg) error {
+	if !connection.HeadersSupported() {
+		msg.Header = nil
+	}
+
+	if connection.jetStream != nil {
+		_, err := connection.jetStream.PublishMsg(msg, nats.AckWait(connection.timeout))
+		return err
+	}
+
+	if err := connection.Conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	// Publishing only buffers the message, flushing returns once the server has received it
+	return connection.FlushTimeout(connection.timeout)
+}
+
+// NATSSender publishes data to a NATS server, or a JetStream stream
+type NATSSender struct {
+	config         NATSConfig
+	persistOnError bool
+	tlsConfig      *tls.Config
+	mutex          sync.Mutex
+	publisher      natsPublisher
+	// connect connects to the server, returning the publisher used to send the data
+	connect func(edgexcontext *appcontext.Context) (natsPublisher, error)
+}
+
+// NewNATSSender creates, initializes and returns a new instance of NATSSender. An error is returned if the URL or
+// subject isn't set or the certificates can't be loaded.
+func NewNATSSender(config NATSConfig, persistOnError bool) (*NATSSender, error) {
+	if config.URL == "" {
+		return nil, errors.New("the NATS server URL must be set")
+	}
+	if config.Subject == "" {
+		return nil, errors.New("the NATS subject must be set")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultNATSTimeout
+	}
+
+	sender := &NATSSender{config: config, persistOnError: persistOnError}
+	sender.connect = sender.dial
+
+	if config.UseTLS {
+		var err error
+		sender.tlsConfig, err = config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sender, nil
+}
+
+func (config NATSConfig) tlsConfig() (*tls.Config, error) {
+	return util.NewTLSConfig(config.ClientCertFile, config.ClientKeyFile, config.CACertFile, config.SkipCertVerify)
+}
+
+// options returns the options used to connect to the server, with the credentials from the secret store when the
+// SecretPath is set
+func (sender *NATSSender) options(edgexcontext *appcontext.Context) ([]nats.Option, error) {
+	options := []nats.Option{nats.Timeout(sender.config.Timeout)}
+
+	if sender.tlsConfig != nil {
+		options = append(options, nats.Secure(sender.tlsConfig.Clone()))
+	}
+
+	if sender.config.CredentialsFile != "" {
+		options = append(options, nats.UserCredentials(sender.config.CredentialsFile))
+	}
+
+	if sender.config.SecretPath != "" {
+		secrets, err := getSecrets(edgexcontext, sender.config.SecretPath)
+		if err != nil {
+			return nil, err
+		}
+		if token, ok := secrets["token"]; ok {
+			options = append(options, nats.Token(token))
+		} else {
+			options = append(options, nats.UserInfo(secrets["username"], secrets["password"]))
+		}
+	}
+
+	return options, nil
+}
+
+func (sender *NATSSender) dial(edgexcontext *appcontext.Context) (natsPublisher, error) {
+	options, err := sender.options(edgexcontext)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := nats.Connect(sender.config.URL, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &natsConnection{Conn: conn, timeout: sender.config.Timeout}
+	if sender.config.JetStream {
+		connection.jetStream, err = conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return connection, nil
+}
+
+// getPublisher returns the publisher, connecting to the server unless already connected. The client reconnects on
+// its own when the connection is lost, so a new connection is only made once the client gives up.
+func (sender *NATSSender) getPublisher(edgexcontext *appcontext.Context) (natsPublisher, error) {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	if sender.publisher != nil && !sender.publisher.IsClosed() {
+		return sender.publisher, nil
+	}
+
+	edgexcontext.LoggingClient.Info("Connecting to NATS server")
+	publisher, err := sender.connect(edgexcontext)
+	if err != nil {
+		return nil, err
+	}
+	sender.publisher = publisher
+	return publisher, nil
+}
+
+// NATSSend publishes data from the previous function to the NATS subject. If no previous function exists, then the
+// event that triggered the pipeline will be used. The context's ExportHeaders, such as the signature, and the
+// correlation ID are sent as message headers to servers supporting them.
+// The data isn't sent and the pipeline stops if a placeholder in the subject can't be resolved. If the server, or the
+// stream when using JetStream, doesn't receive the data and persistOnError is true and Store and Forward is enabled,
+// the data will be stored for later retry.
+func (sender *NATSSender) NATSSend(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	exportData, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// Wildcards and whitespace aren't allowed in the subjects messages are published to
+	subject, err := resolveDestination(sender.config.Subject, edgexcontext, params[0], "*> ")
+	if err != nil {
+		return false, fmt.Errorf("Could not resolve NATS subject: %s", err.Error())
+	}
+
+	message := &nats.Msg{Subject: subject, Data: exportData, Header: nats.Header{}}
+	if edgexcontext.CorrelationID != "" {
+		message.Header.Set(clients.CorrelationHeader, edgexcontext.CorrelationID)
+	}
+	for name, value := range edgexcontext.ExportHeaders {
+		message.Header.Set(name, value)
+	}
+
+	publisher, err := sender.getPublisher(edgexcontext)
+	if err == nil {
+		edgexcontext.LoggingClient.Debug("Publishing data to NATS subject " + subject)
+		err = publisher.PublishMsg(message)
+	}
+	if err != nil {
+		if sender.persistOnError {
+			edgexcontext.RetryData = exportData
+		}
+		return false, fmt.Errorf("Could not send data to NATS: %s", err.Error())
+	}
+
+	edgexcontext.LoggingClient.Debug("Sent data to NATS")
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "NATS", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+type fakeNATSPublisher struct {
+	published []*nats.Msg
+	err       error
+	closed    bool
+}
+
+func (publisher *fakeNATSPublisher) PublishMsg(msg *nats.Msg) error {
+	if publisher.err != nil {
+		return publisher.err
+	}
+	publisher.published = append(publisher.published, msg)
+	return nil
+}
+
+func (publisher *fakeNATSPublisher) IsClosed() bool {
+	return publisher.closed
+}
+
+// newFakeNATSSender returns a sender using the publisher, counting the connections made
+func newFakeNATSSender(t *testing.T, config NATSConfig, persistOnError bool, publisher *fakeNATSPublisher) (*NATSSender, *int) {
+	sender, err := NewNATSSender(config, persistOnError)
+	require.NoError(t, err)
+
+	connections := 0
+	sender.connect = func(edgexcontext *appcontext.Context) (natsPublisher, error) {
+		connections++
+		return publisher, nil
+	}
+	return sender, &connections
+}
+
+func TestNewNATSSender(t *testing.T) {
+	tests := []struct {
+		Name      string
+		Config    NATSConfig
+		ExpectErr bool
+	}{
+		{"Valid", NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export"}, false},
+		{"Valid TLS", NATSConfig{URL: "tls://localhost:4222", Subject: "edgex.export", UseTLS: true, SkipCertVerify: true}, false},
+		{"No URL", NATSConfig{Subject: "edgex.export"}, true},
+		{"No Subject", NATSConfig{URL: "nats://localhost:4222"}, true},
+		{"Missing CA", NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export", UseTLS: true, CACertFile: "/no/such/ca.pem"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sender, err := NewNATSSender(test.Config, false)
+			if test.ExpectErr {
+				assert.Error(t, err)
+				assert.Nil(t, sender)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, defaultNATSTimeout, sender.config.Timeout)
+		})
+	}
+}
+
+func TestNATSSend(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	sender, connections := newFakeNATSSender(t, NATSConfig{URL: "nats://localhost:4222", Subject: "site1.{device}.{reading}"}, true, publisher)
+
+	ctx := &appcontext.Context{
+		CorrelationID: "correlation1",
+		LoggingClient: context.LoggingClient,
+		ExportHeaders: map[string]string{SignatureAlgorithmHeader: HMACSHA256},
+	}
+	event := models.Event{Device: "thermostat", Readings: []models.Reading{{Name: "temperature", Value: "21.5"}}}
+	continuePipeline, result := sender.NATSSend(ctx, event)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Nil(t, ctx.RetryData)
+
+	continuePipeline, result = sender.NATSSend(ctx, event)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 1, *connections, "The connection should be reused")
+
+	require.Len(t, publisher.published, 2)
+	message := publisher.published[0]
+	assert.Equal(t, "site1.thermostat.temperature", message.Subject)
+	assert.Equal(t, "correlation1", message.Header.Get(clients.CorrelationHeader))
+	assert.Equal(t, HMACSHA256, message.Header.Get(SignatureAlgorithmHeader))
+}
+
+func TestNATSSendNoData(t *testing.T) {
+	sender, err := NewNATSSender(NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export"}, false)
+	require.NoError(t, err)
+
+	continuePipeline, result := sender.NATSSend(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestNATSSendInvalidSubject(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	sender, _ := newFakeNATSSender(t, NATSConfig{URL: "nats://localhost:4222", Subject: "site1.{device}"}, true, publisher)
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := sender.NATSSend(ctx, models.Event{Device: "thermo*"})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Could not resolve NATS subject")
+	assert.Nil(t, ctx.RetryData)
+	assert.Empty(t, publisher.published)
+}
+
+func TestNATSSendError(t *testing.T) {
+	tests := []struct {
+		Name           string
+		PersistOnError bool
+	}{
+		{"Persist", true},
+		{"Drop", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			publisher := &fakeNATSPublisher{err: errors.New("nats: timeout")}
+			sender, _ := newFakeNATSSender(t, NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export"}, test.PersistOnError, publisher)
+
+			ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+			continuePipeline, result := sender.NATSSend(ctx, clearString)
+			require.False(t, continuePipeline)
+			assert.Contains(t, result.(error).Error(), "Could not send data to NATS")
+			if test.PersistOnError {
+				assert.Equal(t, []byte(clearString), ctx.RetryData)
+			} else {
+				assert.Nil(t, ctx.RetryData)
+			}
+		})
+	}
+}
+
+func TestNATSSendReconnectsWhenClosed(t *testing.T) {
+	publisher := &fakeNATSPublisher{}
+	sender, connections := newFakeNATSSender(t, NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export"}, false, publisher)
+
+	continuePipeline, result := sender.NATSSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	publisher.closed = true
+	continuePipeline, result = sender.NATSSend(context, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 2, *connections, "The sender should reconnect once the connection is closed")
+}
+
+func TestNATSOptions(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	sender, err := NewNATSSender(NATSConfig{URL: "nats://localhost:4222", Subject: "edgex.export", SecretPath: "mqtt"}, false)
+	require.NoError(t, err)
+
+	options, err := sender.options(mqttSecretsContext())
+	require.NoError(t, err)
+
+	natsOptions := nats.GetDefaultOptions()
+	for _, option := range options {
+		require.NoError(t, option(&natsOptions))
+	}
+	assert.Equal(t, "mqttuser", natsOptions.User)
+	assert.Equal(t, "mqttpassword", natsOptions.Password)
+	assert.Equal(t, defaultNATSTimeout, natsOptions.Timeout)
+
+	sender.config.SecretPath = "missing"
+	_, err = sender.options(mqttSecretsContext())
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+// tokenExpiryDelta is how long before its expiry a cached token is refreshed, allowing for clock skew and the time
+// taken by the request using it
+const tokenExpiryDelta = 10 * time.Second
+
+// oauth2Token fetches access tokens from the token URL using the OAuth2 client credentials grant and caches them
+// until they expire. It is shared by the copies of the HTTPSender so the token is reused across pipeline executions.
+type oauth2Token struct {
+	tokenURL    string
+	scopes      []string
+	secretPath  string
+	mutex       sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newOAuth2Token(tokenURL string, scopes []string, secretPath string) *oauth2Token {
+	return &oauth2Token{
+		tokenURL:   tokenURL,
+		scopes:     scopes,
+		secretPath: secretPath,
+	}
+}
+
+// get returns the cached access token, fetching a new one when there isn't one or it has expired
+func (token *oauth2Token) get(ctx stdcontext.Context, edgexcontext *appcontext.Context, client *http.Client) (string, error) {
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+
+	if token.accessToken != "" && (token.expiry.IsZero() || time.Now().Before(token.expiry)) {
+		return token.accessToken, nil
+	}
+
+	secrets, err := getSecrets(edgexcontext, token.secretPath, "clientid", "clientsecret")
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve OAuth2 client credentials: %s", err.Error())
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(token.scopes) > 0 {
+		form.Set("scope", strings.Join(token.scopes, " "))
+	}
+	request, err := http.NewRequest(http.MethodPost, token.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(url.QueryEscape(secrets["clientid"]), url.QueryEscape(secrets["clientsecret"]))
+
+	edgexcontext.LoggingClient.Debug("Requesting OAuth2 access token", "url", token.tokenURL)
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("unable to request OAuth2 access token: %s", err.Error())
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read OAuth2 token response: %s", err.Error())
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("OAuth2 token request failed with %d HTTP status code", response.StatusCode)
+	}
+
+	var tokenResponse oauth2TokenResponse
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("unable to parse OAuth2 token response: %s", err.Error())
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", errors.New("OAuth2 token response doesn't contain an access token")
+	}
+
+	token.accessToken = tokenResponse.AccessToken
+	token.expiry = time.Time{}
+	if tokenResponse.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - tokenExpiryDelta)
+	}
+	return token.accessToken, nil
+}
+
+// invalidate discards the cached access token, if it is still the one rejected, so the next request fetches a new one
+func (token *oauth2Token) invalidate(accessToken string) {
+	token.mutex.Lock()
+	defer token.mutex.Unlock()
+
+	if token.accessToken == accessToken {
+		token.accessToken = ""
+		token.expiry = time.Time{}
+	}
+}
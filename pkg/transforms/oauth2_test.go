@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+)
+
+type oauth2Server struct {
+	tokenRequests int
+	unauthorized  int
+	expiresIn     int
+	tokenStatus   int
+	validToken    string
+}
+
+func (server *oauth2Server) token(w http.ResponseWriter, r *http.Request) {
+	server.tokenRequests++
+	clientID, clientSecret, _ := r.BasicAuth()
+	if r.FormValue("grant_type") != "client_credentials" || clientID != "myclient" || clientSecret != "mysecret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if server.tokenStatus != 0 {
+		w.WriteHeader(server.tokenStatus)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = fmt.Fprintf(w, `{"access_token":"token%d","token_type":"bearer","expires_in":%d,"scope":"%s"}`,
+		server.tokenRequests, server.expiresIn, r.FormValue("scope"))
+}
+
+func (server *oauth2Server) export(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+server.validToken {
+		server.unauthorized++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func oauth2Context() *appcontext.Context {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+
+	config := &common.ConfigurationStruct{}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"OAuth2": common.InsecureSecretsInfo{
+			Path:    "oauth2",
+			Secrets: map[string]string{"clientid": "myclient", "clientsecret": "mysecret"},
+		},
+	}
+	return &appcontext.Context{
+		LoggingClient:  context.LoggingClient,
+		SecretProvider: security.NewSecretProvider(context.LoggingClient, config),
+	}
+}
+
+func newOAuth2Sender(t *testing.T, server *oauth2Server) (HTTPSender, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", server.token)
+	mux.HandleFunc("/export", server.export)
+	ts := httptest.NewServer(mux)
+
+	options := HTTPSenderOptions{AuthMode: OAuth2Auth, SecretPath: "oauth2", TokenURL: ts.URL + "/token", Scopes: []string{"write"}}
+	sender, err := NewHTTPSenderWithOptions(ts.URL+"/export", "", true, options)
+	require.NoError(t, err)
+	return sender, ts.Close
+}
+
+func TestHTTPSenderOAuth2CachesToken(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+	ctx := oauth2Context()
+
+	server := &oauth2Server{expiresIn: 3600, validToken: "token1"}
+	sender, closeServer := newOAuth2Sender(t, server)
+	defer closeServer()
+
+	for i := 0; i < 3; i++ {
+		continuePipeline, result := sender.HTTPPost(ctx, clearString)
+		require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	}
+	assert.Equal(t, 1, server.tokenRequests, "Token should be requested once and cached")
+}
+
+func TestHTTPSenderOAuth2TokenExpired(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+	ctx := oauth2Context()
+
+	// Tokens expiring within the expiry delta are treated as already expired
+	server := &oauth2Server{expiresIn: 5, validToken: "token1"}
+	sender, closeServer := newOAuth2Sender(t, server)
+	defer closeServer()
+
+	continuePipeline, result := sender.HTTPPost(ctx, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	server.validToken = "token2"
+	continuePipeline, result = sender.HTTPPost(ctx, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 2, server.tokenRequests)
+	assert.Equal(t, 0, server.unauthorized, "The expired token shouldn't be sent")
+}
+
+func TestHTTPSenderOAuth2RefreshOnUnauthorized(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+	ctx := oauth2Context()
+
+	server := &oauth2Server{expiresIn: 3600, validToken: "token1"}
+	sender, closeServer := newOAuth2Sender(t, server)
+	defer closeServer()
+
+	continuePipeline, result := sender.HTTPPost(ctx, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	// The token is revoked, so the rejected token is replaced and the request sent again
+	server.validToken = "token2"
+	continuePipeline, result = sender.HTTPPost(ctx, clearString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, 2, server.tokenRequests)
+	assert.Equal(t, 1, server.unauthorized)
+
+	// The request is only sent again once
+	server.validToken = "invalid"
+	ctx.RetryData = nil
+	continuePipeline, result = sender.HTTPPost(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.Equal(t, 3, server.tokenRequests)
+	assert.NotNil(t, ctx.RetryData, "Retry data should be set when the request is unauthorized")
+}
+
+func TestHTTPSenderOAuth2TokenFailure(t *testing.T) {
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+	ctx := oauth2Context()
+
+	server := &oauth2Server{tokenStatus: http.StatusServiceUnavailable, validToken: "token1"}
+	sender, closeServer := newOAuth2Sender(t, server)
+	defer closeServer()
+
+	continuePipeline, result := sender.HTTPPost(ctx, clearString)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+	assert.NotNil(t, ctx.RetryData, "Retry data should be set when the token can't be retrieved")
+
+	// The retried data is sent once the token endpoint recovers
+	server.tokenStatus = 0
+	server.validToken = "token2"
+	continuePipeline, result = sender.HTTPPost(ctx, ctx.RetryData)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+}
+
+func TestNewHTTPSenderOAuth2Options(t *testing.T) {
+	_, err := NewHTTPSenderWithOptions("http://url", "", false, HTTPSenderOptions{AuthMode: OAuth2Auth, SecretPath: "oauth2"})
+	assert.Error(t, err, "Token URL is required")
+	_, err = NewHTTPSenderWithOptions("http://url", "", false, HTTPSenderOptions{AuthMode: OAuth2Auth, TokenURL: "http://url/token"})
+	assert.Error(t, err, "Secret path is required")
+}
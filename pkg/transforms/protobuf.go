@@ -0,0 +1,197 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Protobuf houses the message descriptor used to encode and decode Protocol Buffers
+type Protobuf struct {
+	// OutputEvent makes DecodeWithProtobuf return a models.Event rather than JSON, for messages that follow the
+	// structure of an EdgeX Event
+	OutputEvent bool
+	descriptor  protoreflect.MessageDescriptor
+}
+
+// NewProtobuf creates, initializes and returns a new instance of Protobuf for the message with the fully qualified
+// name, i.e. mypackage.Event, from the descriptor set file. The descriptor set file is created by protoc using
+// --descriptor_set_out along with --include_imports.
+func NewProtobuf(descriptorSetFile string, messageName string) (*Protobuf, error) {
+	descriptorSet, err := ioutil.ReadFile(descriptorSetFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read descriptor set file: %s", err.Error())
+	}
+	return NewProtobufFromDescriptorSet(descriptorSet, messageName)
+}
+
+// NewProtobufFromDescriptorSet creates, initializes and returns a new instance of Protobuf for the message with the
+// fully qualified name from the serialized FileDescriptorSet.
+func NewProtobufFromDescriptorSet(descriptorSet []byte, messageName string) (*Protobuf, error) {
+	var fileDescriptorSet descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &fileDescriptorSet); err != nil {
+		return nil, fmt.Errorf("unable to parse descriptor set: %s", err.Error())
+	}
+
+	files, err := protodesc.NewFiles(&fileDescriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %s", err.Error())
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find message '%s' in descriptor set: %s", messageName, err.Error())
+	}
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a message", messageName)
+	}
+
+	return &Protobuf{descriptor: messageDescriptor}, nil
+}
+
+// EncodeWithProtobuf encodes the data received from the previous function as the Protocol Buffers message and
+// returns the binary encoding as a []byte. A proto.Message is encoded as is. An EdgeX Event or any other value that
+// can be marshaled to JSON is converted to the message by matching its JSON field names, a string or []byte must hold
+// JSON. Fields that aren't part of the message are ignored.
+// This function will return an error and stop the pipeline if no data is received or the data doesn't match the
+// message.
+func (p *Protobuf) EncodeWithProtobuf(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Encoding with Protobuf")
+
+	if message, ok := params[0].(proto.Message); ok {
+		encoded, err := proto.Marshal(message)
+		if err != nil {
+			return false, fmt.Errorf("unable to encode %s: %s", message.ProtoReflect().Descriptor().FullName(), err.Error())
+		}
+		return true, encoded
+	}
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	message := dynamicpb.NewMessage(p.descriptor)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, message); err != nil {
+		return false, fmt.Errorf("unable to convert data to %s: %s", p.descriptor.FullName(), err.Error())
+	}
+
+	encoded, err := proto.Marshal(message)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode %s: %s", p.descriptor.FullName(), err.Error())
+	}
+	return true, encoded
+}
+
+// DecodeWithProtobuf decodes the binary Protocol Buffers message received from the previous function and returns it
+// as JSON in a []byte, using the JSON names of the fields, or as a models.Event when OutputEvent is set.
+// This function will return an error and stop the pipeline if no data is received or the data can't be decoded.
+func (p *Protobuf) DecodeWithProtobuf(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Decoding with Protobuf")
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	message := dynamicpb.NewMessage(p.descriptor)
+	if err := proto.Unmarshal(data, message); err != nil {
+		return false, fmt.Errorf("unable to decode %s: %s", p.descriptor.FullName(), err.Error())
+	}
+
+	return decodedOutput(protoMessageToMap(message), p.OutputEvent)
+}
+
+// protoMessageToMap converts the message to a map keyed by the JSON names of the populated fields. Unlike protojson,
+// 64 bit integers are kept as numbers so the result can be unmarshaled into an EdgeX Event.
+func protoMessageToMap(message protoreflect.Message) map[string]interface{} {
+	result := make(map[string]interface{})
+	message.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsList():
+			list := value.List()
+			items := make([]interface{}, list.Len())
+			for index := range items {
+				items[index] = protoValue(field, list.Get(index))
+			}
+			result[field.JSONName()] = items
+		case field.IsMap():
+			entries := make(map[string]interface{})
+			value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				entries[key.String()] = protoValue(field.MapValue(), value)
+				return true
+			})
+			result[field.JSONName()] = entries
+		default:
+			result[field.JSONName()] = protoValue(field, value)
+		}
+		return true
+	})
+	return result
+}
+
+func protoValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoMessageToMap(value.Message())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+		return int32(value.Enum())
+	}
+	return value.Interface()
+}
+
+// decodedOutput returns the decoded data as JSON in a []byte or, when outputEvent is set, as a models.Event
+func decodedOutput(decoded interface{}, outputEvent bool) (bool, interface{}) {
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert decoded data to JSON: %s", err.Error())
+	}
+	if !outputEvent {
+		return true, data
+	}
+
+	var event models.Event
+	if err := json.Unmarshal(data, &event); err != nil {
+		return false, fmt.Errorf("unable to convert decoded data to models.Event: %s", err.Error())
+	}
+	return true, event
+}
@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// eventDescriptorSet returns a serialized FileDescriptorSet, as created by protoc, for:
+//
+//	package edgex;
+//	message Reading { string device = 1; string name = 2; string value = 3; int64 origin = 4; bytes binary_value = 5;
+//	                  string media_type = 6; }
+//	message Event { string device = 1; int64 origin = 2; repeated Reading readings = 3; map<string, string> tags = 4; }
+func eventDescriptorSet(t *testing.T) []byte {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		descriptor := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   fieldType.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			descriptor.TypeName = proto.String(typeName)
+		}
+		return descriptor
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("edgex.proto"),
+		Package: proto.String("edgex"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Reading"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("device", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("name", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("value", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("origin", 4, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("binary_value", 5, descriptorpb.FieldDescriptorProto_TYPE_BYTES, optional, ""),
+					field("media_type", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				},
+			},
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("device", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("origin", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("readings", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".edgex.Reading"),
+					field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".edgex.Event.TagsEntry"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("TagsEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+							field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	require.NoError(t, err)
+	return descriptorSet
+}
+
+func TestNewProtobufInvalid(t *testing.T) {
+	_, err := NewProtobufFromDescriptorSet([]byte("not a descriptor set"), "edgex.Event")
+	assert.Error(t, err)
+
+	_, err = NewProtobufFromDescriptorSet(eventDescriptorSet(t), "edgex.Missing")
+	assert.Error(t, err)
+
+	_, err = NewProtobuf("/does/not/exist.pb", "edgex.Event")
+	assert.Error(t, err)
+}
+
+func TestProtobufEventRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "protobuf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "edgex.pb")
+	require.NoError(t, ioutil.WriteFile(path, eventDescriptorSet(t), 0644))
+
+	encoder, err := NewProtobuf(path, "edgex.Event")
+	require.NoError(t, err)
+
+	event := models.Event{
+		ID:     "ignored",
+		Device: devID1,
+		Origin: 1577836800123456789,
+		Readings: []models.Reading{
+			{Device: devID1, Name: descriptor1, Value: "21.5", Origin: 1577836800123456789},
+			{Device: devID1, Name: descriptor2, BinaryValue: []byte{1, 2, 3}, MediaType: "application/octet-stream"},
+		},
+	}
+	continuePipeline, result := encoder.EncodeWithProtobuf(context, event)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	encoded := result.([]byte)
+
+	decoder, err := NewProtobuf(path, "edgex.Event")
+	require.NoError(t, err)
+	continuePipeline, result = decoder.DecodeWithProtobuf(context, encoded)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	expected := `{"device":"id1","origin":1577836800123456789,"readings":[` +
+		`{"device":"id1","name":"Descriptor1","value":"21.5","origin":1577836800123456789},` +
+		`{"device":"id1","name":"Descriptor2","binaryValue":"AQID","mediaType":"application/octet-stream"}]}`
+	assert.JSONEq(t, expected, string(result.([]byte)))
+
+	decoder.OutputEvent = true
+	continuePipeline, result = decoder.DecodeWithProtobuf(context, encoded)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	decoded := result.(models.Event)
+	assert.Empty(t, decoded.ID)
+	assert.Equal(t, event.Device, decoded.Device)
+	assert.Equal(t, event.Origin, decoded.Origin)
+	require.Len(t, decoded.Readings, 2)
+	assert.Equal(t, event.Readings[0].Origin, decoded.Readings[0].Origin)
+	assert.Equal(t, event.Readings[1].BinaryValue, decoded.Readings[1].BinaryValue)
+}
+
+func TestProtobufEncodeMap(t *testing.T) {
+	protobuf, err := NewProtobufFromDescriptorSet(eventDescriptorSet(t), "edgex.Event")
+	require.NoError(t, err)
+
+	data := map[string]interface{}{"device": devID2, "tags": map[string]string{"site": "plant1"}, "unknown": true}
+	continuePipeline, result := protobuf.EncodeWithProtobuf(context, data)
+	require.True(t, continuePipeline, "Pipeline should continue")
+
+	continuePipeline, result = protobuf.DecodeWithProtobuf(context, result)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	assert.JSONEq(t, `{"device":"id2","tags":{"site":"plant1"}}`, string(result.([]byte)))
+}
+
+func TestProtobufEncodeProtoMessage(t *testing.T) {
+	protobuf, err := NewProtobufFromDescriptorSet(eventDescriptorSet(t), "edgex.Event")
+	require.NoError(t, err)
+
+	continuePipeline, result := protobuf.EncodeWithProtobuf(context, wrapperspb.String("hi"))
+	require.True(t, continuePipeline, "Pipeline should continue")
+	assert.Equal(t, []byte{0x0a, 0x02, 'h', 'i'}, result)
+}
+
+func TestProtobufErrors(t *testing.T) {
+	protobuf, err := NewProtobufFromDescriptorSet(eventDescriptorSet(t), "edgex.Event")
+	require.NoError(t, err)
+
+	continuePipeline, result := protobuf.EncodeWithProtobuf(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = protobuf.EncodeWithProtobuf(context, `{"origin":"not a number"}`)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+
+	continuePipeline, result = protobuf.DecodeWithProtobuf(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = protobuf.DecodeWithProtobuf(context, []byte{0xff, 0xff, 0xff})
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
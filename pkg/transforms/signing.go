@@ -0,0 +1,380 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	// HMACSHA256 signs using HMAC with SHA-256 and a shared secret
+	HMACSHA256 = "HMAC-SHA256"
+	// ECDSASHA256 signs the SHA-256 hash using ECDSA with a PEM encoded private key, the signature is ASN.1 DER encoded
+	ECDSASHA256 = "ECDSA-SHA256"
+	// Ed25519 signs using Ed25519 with a PEM encoded or base64 encoded private key
+	Ed25519 = "Ed25519"
+
+	// DefaultSignatureHeader is the name of the header holding the base64 encoded signature
+	DefaultSignatureHeader = "X-Signature"
+	// SignatureAlgorithmHeader is the name of the header holding the signature algorithm
+	SignatureAlgorithmHeader = "X-Signature-Algorithm"
+)
+
+// Signature houses the algorithm and key used to sign data and verify signatures
+type Signature struct {
+	Algorithm string
+	// Key is the HMAC secret or PEM encoded key, it is used when SecretPath isn't set. Verifying ECDSA and Ed25519
+	// signatures accepts the public key.
+	Key string
+	// SecretPath locates the key in the secret store, it is used instead of Key when set
+	SecretPath string
+	// SecretName is the name of the key at the SecretPath, "key" when not set
+	SecretName string
+	// Envelope wraps the data and its signature in a SignedEnvelope rather than adding the signature to the
+	// ExportHeaders of the context. VerifySignature expects a SignedEnvelope rather than the signature in the
+	// ReceivedHeaders of the context when set.
+	Envelope bool
+	// HeaderName is the name of the header holding the signature, DefaultSignatureHeader when not set
+	HeaderName string
+}
+
+// SignedEnvelope is the JSON envelope holding the signed data along with its signature, both base64 encoded
+type SignedEnvelope struct {
+	Algorithm string `json:"algorithm"`
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// NewSignature creates, initializes and returns a new instance of Signature for the algorithm, HMAC-SHA256,
+// ECDSA-SHA256 or Ed25519, using the key.
+func NewSignature(algorithm string, key string) (*Signature, error) {
+	algorithm, err := parseSignatureAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{Algorithm: algorithm, Key: key}, nil
+}
+
+// NewSignatureWithSecret creates, initializes and returns a new instance of Signature for the algorithm that retrieves
+// the key with the secretName from the secretPath in the secret store.
+func NewSignatureWithSecret(algorithm string, secretPath string, secretName string) (*Signature, error) {
+	algorithm, err := parseSignatureAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{Algorithm: algorithm, SecretPath: secretPath, SecretName: secretName}, nil
+}
+
+func parseSignatureAlgorithm(algorithm string) (string, error) {
+	for _, supported := range []string{HMACSHA256, ECDSASHA256, Ed25519} {
+		if strings.EqualFold(strings.TrimSpace(algorithm), supported) {
+			return supported, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported signature algorithm '%s', must be %s, %s or %s", algorithm, HMACSHA256, ECDSASHA256, Ed25519)
+}
+
+// Sign signs the data received from the previous function as either a string, []byte, or json.Marshaler. The base64
+// encoded signature is added to the ExportHeaders of the context, sent as headers by HTTPSender, and the data is
+// returned as a []byte. When Envelope is set, a SignedEnvelope holding the data and signature is returned as JSON in
+// a []byte instead.
+// This function will return an error and stop the pipeline if no data is received or the data can't be signed.
+func (signature *Signature) Sign(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Signing with " + signature.Algorithm)
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	key, err := signature.key(edgexcontext)
+	if err != nil {
+		return false, err
+	}
+
+	var signed []byte
+	switch signature.Algorithm {
+	case HMACSHA256:
+		signed = hmacSHA256([]byte(key), data)
+	case ECDSASHA256, Ed25519:
+		privateKey, err := parsePrivateKey(signature.Algorithm, key)
+		if err != nil {
+			return false, err
+		}
+		signed, err = privateKey.Sign(rand.Reader, signedDigest(signature.Algorithm, data), signerOpts(signature.Algorithm))
+		if err != nil {
+			return false, fmt.Errorf("unable to sign with %s: %s", signature.Algorithm, err.Error())
+		}
+	default:
+		return false, fmt.Errorf("unsupported signature algorithm '%s'", signature.Algorithm)
+	}
+
+	if signature.Envelope {
+		envelope, err := json.Marshal(SignedEnvelope{Algorithm: signature.Algorithm, Payload: data, Signature: signed})
+		if err != nil {
+			return false, err
+		}
+		return true, envelope
+	}
+
+	headerName := signature.HeaderName
+	if headerName == "" {
+		headerName = DefaultSignatureHeader
+	}
+	if edgexcontext.ExportHeaders == nil {
+		edgexcontext.ExportHeaders = make(map[string]string)
+	}
+	edgexcontext.ExportHeaders[headerName] = base64.StdEncoding.EncodeToString(signed)
+	edgexcontext.ExportHeaders[SignatureAlgorithmHeader] = signature.Algorithm
+
+	return true, data
+}
+
+// VerifySignature verifies the signature of the data received from the previous function as either a string or
+// []byte and returns the data as a []byte. The base64 encoded signature is taken from the HeaderName header, and the
+// algorithm from the SignatureAlgorithmHeader header when present, received by the trigger along with the data. Such
+// data must reach this function as received, i.e. using a []byte TargetType. When Envelope is set, the data is a
+// SignedEnvelope and its payload is returned.
+// This function will return an error and stop the pipeline if no data is received, the signature header isn't
+// received or the data isn't a SignedEnvelope, the algorithm doesn't match or the signature isn't valid for the data.
+func (signature *Signature) VerifySignature(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Verifying signature with " + signature.Algorithm)
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	if !signature.Envelope {
+		return signature.verifyHeaders(edgexcontext, data)
+	}
+
+	var envelope SignedEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return false, fmt.Errorf("unable to verify signature, expecting a signed envelope: %s", err.Error())
+	}
+	if envelope.Algorithm != signature.Algorithm {
+		return false, fmt.Errorf("unable to verify signature, expecting algorithm %s but received '%s'", signature.Algorithm, envelope.Algorithm)
+	}
+
+	if err := signature.Verify(edgexcontext, envelope.Payload, envelope.Signature); err != nil {
+		return false, err
+	}
+	return true, envelope.Payload
+}
+
+// verifyHeaders verifies the data using the signature received in the headers along with the data
+func (signature *Signature) verifyHeaders(edgexcontext *appcontext.Context, data []byte) (bool, interface{}) {
+	headerName := signature.HeaderName
+	if headerName == "" {
+		headerName = DefaultSignatureHeader
+	}
+
+	encoded, ok := receivedHeader(edgexcontext, headerName)
+	if !ok {
+		return false, fmt.Errorf("unable to verify signature, no %s header received", headerName)
+	}
+	if algorithm, ok := receivedHeader(edgexcontext, SignatureAlgorithmHeader); ok && algorithm != signature.Algorithm {
+		return false, fmt.Errorf("unable to verify signature, expecting algorithm %s but received '%s'", signature.Algorithm, algorithm)
+	}
+	signed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, fmt.Errorf("unable to verify signature, expecting a base64 encoded %s header: %s", headerName, err.Error())
+	}
+
+	if err := signature.Verify(edgexcontext, data, signed); err != nil {
+		return false, err
+	}
+	return true, data
+}
+
+// receivedHeader returns the value of the header received along with the data, ignoring the case of its name
+func receivedHeader(edgexcontext *appcontext.Context, name string) (string, bool) {
+	for key, value := range edgexcontext.ReceivedHeaders {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Verify verifies the signature of the data, returning an error when the signature isn't valid
+func (signature *Signature) Verify(edgexcontext *appcontext.Context, data []byte, signed []byte) error {
+	key, err := signature.key(edgexcontext)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	switch signature.Algorithm {
+	case HMACSHA256:
+		valid = hmac.Equal(signed, hmacSHA256([]byte(key), data))
+	case ECDSASHA256:
+		publicKey, err := parsePublicKey(signature.Algorithm, key)
+		if err != nil {
+			return err
+		}
+		ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("the key is not an %s key", signature.Algorithm)
+		}
+		valid = ecdsa.VerifyASN1(ecdsaKey, signedDigest(signature.Algorithm, data), signed)
+	case Ed25519:
+		publicKey, err := parsePublicKey(signature.Algorithm, key)
+		if err != nil {
+			return err
+		}
+		ed25519Key, ok := publicKey.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("the key is not an %s key", signature.Algorithm)
+		}
+		valid = ed25519.Verify(ed25519Key, data, signed)
+	default:
+		return fmt.Errorf("unsupported signature algorithm '%s'", signature.Algorithm)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid %s signature", signature.Algorithm)
+	}
+	return nil
+}
+
+// key returns the key retrieved from the secret store, when SecretPath is set, or the Key
+func (signature *Signature) key(edgexcontext *appcontext.Context) (string, error) {
+	if signature.SecretPath == "" {
+		if signature.Key == "" {
+			return "", fmt.Errorf("no key specified for %s", signature.Algorithm)
+		}
+		return signature.Key, nil
+	}
+	key, err := getSecret(edgexcontext, signature.SecretPath, signature.SecretName)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve the %s key: %s", signature.Algorithm, err.Error())
+	}
+	return key, nil
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// signedDigest returns the data to be signed, ECDSA signs the SHA-256 hash and Ed25519 the data itself
+func signedDigest(algorithm string, data []byte) []byte {
+	if algorithm == ECDSASHA256 {
+		digest := sha256.Sum256(data)
+		return digest[:]
+	}
+	return data
+}
+
+func signerOpts(algorithm string) crypto.SignerOpts {
+	if algorithm == ECDSASHA256 {
+		return crypto.SHA256
+	}
+	return crypto.Hash(0)
+}
+
+// parsePrivateKey parses the PEM encoded EC or PKCS #8 private key, or for Ed25519 the base64 encoded 32 byte seed or
+// 64 byte private key
+func parsePrivateKey(algorithm string, key string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		if algorithm == Ed25519 {
+			if decoded, err := base64Decode([]byte(key)); err == nil {
+				switch len(decoded) {
+				case ed25519.SeedSize:
+					return ed25519.NewKeyFromSeed(decoded), nil
+				case ed25519.PrivateKeySize:
+					return ed25519.PrivateKey(decoded), nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("unable to parse %s private key, expecting PEM encoding", algorithm)
+	}
+
+	var privateKey interface{}
+	var err error
+	if block.Type == "EC PRIVATE KEY" {
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s private key: %s", algorithm, err.Error())
+	}
+
+	switch typed := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if algorithm == ECDSASHA256 {
+			return typed, nil
+		}
+	case ed25519.PrivateKey:
+		if algorithm == Ed25519 {
+			return typed, nil
+		}
+	}
+	return nil, fmt.Errorf("the private key is not an %s key", algorithm)
+}
+
+// parsePublicKey parses the PEM encoded PKIX public key, or for Ed25519 the base64 encoded 32 byte public key. The
+// public key is derived when a private key is provided.
+func parsePublicKey(algorithm string, key string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block != nil && block.Type == "PUBLIC KEY" {
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s public key: %s", algorithm, err.Error())
+		}
+		return publicKey, nil
+	}
+	if block == nil && algorithm == Ed25519 {
+		if decoded, err := base64Decode([]byte(key)); err == nil && len(decoded) == ed25519.PublicKeySize {
+			return ed25519.PublicKey(decoded), nil
+		}
+	}
+
+	privateKey, err := parsePrivateKey(algorithm, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s public key", algorithm)
+	}
+	return privateKey.Public(), nil
+}
@@ -0,0 +1,259 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/common"
+	"github.com/tuanldchainos/app-functions-sdk-go/internal/security"
+)
+
+const hmacSecret = "my shared secret"
+
+// signingKeys returns PEM encoded private and public keys for the algorithm
+func signingKeys(t *testing.T, algorithm string) (string, string) {
+	var privateKey, publicKey interface{}
+	switch algorithm {
+	case ECDSASHA256:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		privateKey, publicKey = key, key.Public()
+	case Ed25519:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		privateKey, publicKey = private, public
+	default:
+		return hmacSecret, hmacSecret
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+}
+
+func TestNewSignatureInvalidAlgorithm(t *testing.T) {
+	_, err := NewSignature("RSA", "key")
+	assert.Error(t, err)
+
+	_, err = NewSignatureWithSecret("", "signing", "")
+	assert.Error(t, err)
+
+	signature, err := NewSignature("hmac-sha256", hmacSecret)
+	require.NoError(t, err)
+	assert.Equal(t, HMACSHA256, signature.Algorithm)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{HMACSHA256, ECDSASHA256, Ed25519} {
+		t.Run(algorithm, func(t *testing.T) {
+			privateKey, publicKey := signingKeys(t, algorithm)
+			signer, err := NewSignature(algorithm, privateKey)
+			require.NoError(t, err)
+			verifier, err := NewSignature(algorithm, publicKey)
+			require.NoError(t, err)
+
+			ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+			continuePipeline, result := signer.Sign(ctx, plainString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Equal(t, []byte(plainString), result)
+			assert.Equal(t, algorithm, ctx.ExportHeaders[SignatureAlgorithmHeader])
+
+			signed, err := base64.StdEncoding.DecodeString(ctx.ExportHeaders[DefaultSignatureHeader])
+			require.NoError(t, err)
+			assert.NoError(t, verifier.Verify(ctx, []byte(plainString), signed))
+			assert.Error(t, verifier.Verify(ctx, []byte(plainString+"!"), signed), "Tampered data should be rejected")
+
+			signer.Envelope = true
+			verifier.Envelope = true
+			continuePipeline, envelope := signer.Sign(ctx, plainString)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", envelope)
+
+			continuePipeline, result = verifier.VerifySignature(ctx, envelope)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+			assert.Equal(t, []byte(plainString), result)
+
+			// The private key can also be used to verify
+			continuePipeline, result = signer.VerifySignature(ctx, envelope)
+			require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+			var tampered SignedEnvelope
+			require.NoError(t, json.Unmarshal(envelope.([]byte), &tampered))
+			tampered.Payload = []byte(plainString + "!")
+			tamperedEnvelope, _ := json.Marshal(tampered)
+			continuePipeline, result = verifier.VerifySignature(ctx, tamperedEnvelope)
+			assert.False(t, continuePipeline, "Pipeline should stop when the payload has been tampered with")
+			assert.Error(t, result.(error))
+		})
+	}
+}
+
+func TestSignHeaderName(t *testing.T) {
+	signer, err := NewSignature(HMACSHA256, hmacSecret)
+	require.NoError(t, err)
+	signer.HeaderName = "X-Gateway-Signature"
+
+	ctx := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := signer.Sign(ctx, plainString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	// HMAC-SHA256 of the plain string with the shared secret
+	assert.Equal(t, "FPUiZnCHwN1v7QmrkCqBZxtLcSr4kVvaVq2+V1rtbvw=", ctx.ExportHeaders["X-Gateway-Signature"])
+	assert.NotContains(t, ctx.ExportHeaders, DefaultSignatureHeader)
+}
+
+func TestVerifySignatureHeaders(t *testing.T) {
+	signer, err := NewSignature(HMACSHA256, hmacSecret)
+	require.NoError(t, err)
+	signer.HeaderName = "X-Gateway-Signature"
+
+	signed := &appcontext.Context{LoggingClient: context.LoggingClient}
+	continuePipeline, result := signer.Sign(signed, plainString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	// The headers are received by the trigger of the receiving service, whose names may differ in case
+	received := &appcontext.Context{LoggingClient: context.LoggingClient, ReceivedHeaders: map[string]string{
+		"x-gateway-signature":    signed.ExportHeaders["X-Gateway-Signature"],
+		SignatureAlgorithmHeader: signed.ExportHeaders[SignatureAlgorithmHeader],
+	}}
+	continuePipeline, result = signer.VerifySignature(received, plainString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, []byte(plainString), result)
+
+	continuePipeline, result = signer.VerifySignature(received, plainString+"!")
+	assert.False(t, continuePipeline, "Pipeline should stop when the data has been tampered with")
+	assert.Error(t, result.(error))
+
+	ed25519Key, _ := signingKeys(t, Ed25519)
+	verifier, _ := NewSignature(Ed25519, ed25519Key)
+	verifier.HeaderName = "X-Gateway-Signature"
+	continuePipeline, result = verifier.VerifySignature(received, plainString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the algorithm doesn't match")
+	assert.Error(t, result.(error))
+
+	received.ReceivedHeaders["x-gateway-signature"] = "not base64"
+	continuePipeline, result = signer.VerifySignature(received, plainString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the signature isn't base64 encoded")
+	assert.Error(t, result.(error))
+}
+
+func TestEd25519Base64Keys(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signer, _ := NewSignature(Ed25519, base64.StdEncoding.EncodeToString(private.Seed()))
+	signer.Envelope = true
+	verifier, _ := NewSignature(Ed25519, base64.StdEncoding.EncodeToString(public))
+	verifier.Envelope = true
+
+	continuePipeline, envelope := signer.Sign(context, plainString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", envelope)
+	continuePipeline, result := verifier.VerifySignature(context, envelope)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, []byte(plainString), result)
+}
+
+func TestSignWithSecret(t *testing.T) {
+	os.Setenv("EDGEX_SECURITY_SECRET_STORE", "false")
+	defer os.Unsetenv("EDGEX_SECURITY_SECRET_STORE")
+
+	privateKey, publicKey := signingKeys(t, ECDSASHA256)
+	config := &common.ConfigurationStruct{}
+	config.Writable.InsecureSecrets = common.InsecureSecrets{
+		"Signing": common.InsecureSecretsInfo{
+			Path:    "signing",
+			Secrets: map[string]string{"key": privateKey, "public": publicKey},
+		},
+	}
+	ctx := &appcontext.Context{
+		LoggingClient:  context.LoggingClient,
+		SecretProvider: security.NewSecretProvider(context.LoggingClient, config),
+	}
+
+	signer, err := NewSignatureWithSecret(ECDSASHA256, "signing", "")
+	require.NoError(t, err)
+	signer.Envelope = true
+	verifier, err := NewSignatureWithSecret(ECDSASHA256, "signing", "public")
+	require.NoError(t, err)
+	verifier.Envelope = true
+
+	continuePipeline, envelope := signer.Sign(ctx, plainString)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", envelope)
+	continuePipeline, result := verifier.VerifySignature(ctx, envelope)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	assert.Equal(t, []byte(plainString), result)
+
+	missing, _ := NewSignatureWithSecret(ECDSASHA256, "missing", "")
+	continuePipeline, result = missing.Sign(ctx, plainString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
+func TestSignErrors(t *testing.T) {
+	signer, _ := NewSignature(HMACSHA256, hmacSecret)
+
+	continuePipeline, result := signer.Sign(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = signer.VerifySignature(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = signer.VerifySignature(context, plainString)
+	assert.False(t, continuePipeline, "Pipeline should stop when no signature header is received")
+	assert.Error(t, result.(error))
+
+	signer.Envelope = true
+	continuePipeline, result = signer.VerifySignature(context, plainString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the data isn't a signed envelope")
+	assert.Error(t, result.(error))
+
+	ecdsaKey, _ := signingKeys(t, ECDSASHA256)
+	ecdsaSigner, _ := NewSignature(ECDSASHA256, ecdsaKey)
+	ecdsaSigner.Envelope = true
+	_, envelope := ecdsaSigner.Sign(context, plainString)
+	continuePipeline, result = signer.VerifySignature(context, envelope)
+	assert.False(t, continuePipeline, "Pipeline should stop when the algorithm doesn't match")
+	assert.Error(t, result.(error))
+
+	ed25519Key, _ := signingKeys(t, Ed25519)
+	wrongKey, _ := NewSignature(ECDSASHA256, ed25519Key)
+	continuePipeline, result = wrongKey.Sign(context, plainString)
+	assert.False(t, continuePipeline, "Pipeline should stop when the key doesn't match the algorithm")
+	assert.Error(t, result.(error))
+
+	noKey, _ := NewSignature(ECDSASHA256, "")
+	continuePipeline, result = noKey.Sign(context, plainString)
+	assert.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
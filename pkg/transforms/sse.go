@@ -0,0 +1,307 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	// SSEDeviceParameter is the query parameter holding the devices a client receives the data of, as a comma
+	// separated list
+	SSEDeviceParameter = "device"
+	// SSEReadingParameter is the query parameter holding the readings a client receives the data of, as a comma
+	// separated list
+	SSEReadingParameter = "reading"
+	// SSELastEventIDHeader is the header sent by browsers when reconnecting, holding the ID of the last event received
+	SSELastEventIDHeader = "Last-Event-ID"
+
+	defaultSSEReplayBufferSize = 50
+	defaultSSESendBufferSize   = 16
+	sseKeepAliveInterval       = 30 * time.Second
+)
+
+// SSEConfig contains the settings of the SSEBroadcaster
+type SSEConfig struct {
+	// AllowedOrigins are the origins of the pages browsers can connect from, in addition to the service's own. All
+	// origins are allowed when it holds "*".
+	AllowedOrigins []string
+	// ReplayBufferSize is the number of events kept to be replayed to the clients reconnecting, 50 when not set
+	ReplayBufferSize int
+	// SendBufferSize is the number of events queued for each client, 16 when not set. Events are dropped for the
+	// clients too slow to receive them.
+	SendBufferSize int
+}
+
+// sseEvent is an event sent to the clients along with what they are filtered by
+type sseEvent struct {
+	id       uint64
+	device   string
+	readings []string
+	message  []byte
+}
+
+type sseClient struct {
+	address string
+	// devices and readings are the devices and readings whose data the client receives, all when nil
+	devices  map[string]bool
+	readings map[string]bool
+	send     chan []byte
+}
+
+// matches returns whether the client receives the event
+func (client *sseClient) matches(event sseEvent) bool {
+	if client.devices != nil && !client.devices[event.device] {
+		return false
+	}
+	if client.readings == nil {
+		return true
+	}
+	for _, reading := range event.readings {
+		if client.readings[reading] {
+			return true
+		}
+	}
+	return false
+}
+
+// SSEBroadcaster pushes data to the clients connected to it as Server-Sent Events. The clients can choose the devices
+// and readings whose data they receive, and get the events they missed replayed when reconnecting.
+type SSEBroadcaster struct {
+	config  SSEConfig
+	lc      logger.LoggingClient
+	mutex   sync.RWMutex
+	clients map[*sseClient]bool
+	// replay holds the latest events, oldest first
+	replay []sseEvent
+	lastID uint64
+}
+
+// NewSSEBroadcaster creates, initializes and returns a new instance of SSEBroadcaster. Its ServeHTTP function must be
+// added as a streaming route of the web server for clients to connect.
+func NewSSEBroadcaster(config SSEConfig, lc logger.LoggingClient) *SSEBroadcaster {
+	if config.ReplayBufferSize <= 0 {
+		config.ReplayBufferSize = defaultSSEReplayBufferSize
+	}
+	if config.SendBufferSize <= 0 {
+		config.SendBufferSize = defaultSSESendBufferSize
+	}
+
+	return &SSEBroadcaster{
+		config:  config,
+		lc:      lc,
+		clients: make(map[*sseClient]bool),
+	}
+}
+
+// queryValues returns the set of comma separated values of the query parameter, nil when it isn't set
+func queryValues(request *http.Request, parameter string) map[string]bool {
+	var values map[string]bool
+	for _, value := range request.URL.Query()[parameter] {
+		for _, item := range util.DeleteEmptyAndTrim(strings.FieldsFunc(value, util.SplitComma)) {
+			if values == nil {
+				values = make(map[string]bool)
+			}
+			values[item] = true
+		}
+	}
+	return values
+}
+
+// ServeHTTP streams the events to the client until it disconnects. The device and reading query parameters, when
+// set, hold the devices and readings whose data the client receives, i.e. ?device=thermostat1&reading=temperature.
+// The events after the one in the Last-Event-ID header are replayed first, if still held by the replay buffer.
+func (broadcaster *SSEBroadcaster) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "Streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	if origin := request.Header.Get("Origin"); origin != "" {
+		for _, allowed := range broadcaster.config.AllowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				writer.Header().Set("Access-Control-Allow-Origin", origin)
+				break
+			}
+		}
+	}
+
+	var lastID uint64
+	if header := request.Header.Get(SSELastEventIDHeader); header != "" {
+		id, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			http.Error(writer, fmt.Sprintf("Invalid %s header '%s'", SSELastEventIDHeader, header), http.StatusBadRequest)
+			return
+		}
+		lastID = id
+	}
+
+	client := &sseClient{
+		address:  request.RemoteAddr,
+		devices:  queryValues(request, SSEDeviceParameter),
+		readings: queryValues(request, SSEReadingParameter),
+		send:     make(chan []byte, broadcaster.config.SendBufferSize),
+	}
+
+	// The events to replay are collected while adding the client, so none are missed or sent twice
+	var replay [][]byte
+	broadcaster.mutex.Lock()
+	if lastID > 0 {
+		for _, event := range broadcaster.replay {
+			if event.id > lastID && client.matches(event) {
+				replay = append(replay, event.message)
+			}
+		}
+	}
+	broadcaster.clients[client] = true
+	broadcaster.mutex.Unlock()
+	broadcaster.lc.Debug("SSE client connected", "remote address", client.address)
+
+	defer func() {
+		broadcaster.mutex.Lock()
+		delete(broadcaster.clients, client)
+		broadcaster.mutex.Unlock()
+		broadcaster.lc.Debug("SSE client disconnected", "remote address", client.address)
+	}()
+
+	writer.Header().Set(clients.ContentType, "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	// Keeps proxies, such as nginx, from buffering the events
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+
+	for _, message := range replay {
+		if _, err := writer.Write(message); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-request.Context().Done():
+			return
+		case message := <-client.send:
+			_, err = writer.Write(message)
+		case <-keepAlive.C:
+			// Comments are ignored by the clients, but keep idle connections from being closed
+			_, err = writer.Write([]byte(":\n\n"))
+		}
+		if err != nil {
+			broadcaster.lc.Debug(fmt.Sprintf("Failed to write to SSE client: %s", err.Error()))
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// formatSSEMessage formats the data as an event with the id. Data that isn't valid UTF-8 is sent base64 encoded as a
+// base64 event, while other data is sent as a message event.
+func formatSSEMessage(id uint64, data []byte) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "id: %d\n", id)
+	if !utf8.Valid(data) {
+		message.WriteString("event: base64\n")
+		data = []byte(base64.StdEncoding.EncodeToString(data))
+	}
+	// Each line is a data field, which the clients join back with newlines
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		message.WriteString("data: ")
+		message.WriteString(line)
+		message.WriteString("\n")
+	}
+	message.WriteString("\n")
+	return message.Bytes()
+}
+
+// Broadcast pushes data from the previous function to the connected SSE clients. If no previous function exists,
+// then the event that triggered the pipeline will be used. The clients that chose the devices or readings whose data
+// they receive only get the data of those devices, or holding one of those readings, which are resolved the same way
+// as the {device} and {reading} placeholders of the MQTTSender topic, while the others get all data. The data is also
+// kept in the replay buffer for the clients reconnecting.
+func (broadcaster *SSEBroadcaster) Broadcast(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	event := sseEvent{}
+	// Data without a device or readings is only sent to the clients receiving all data
+	event.device, _ = resolvePlaceholders("{"+TopicDevice+"}", edgexcontext, params[0], "")
+	if reading, ok := edgexcontext.Values[TopicReading]; ok {
+		event.readings = []string{reading}
+	} else {
+		for _, reading := range topicEvent(params[0]).Readings {
+			event.readings = append(event.readings, reading.Name)
+		}
+	}
+
+	broadcaster.mutex.Lock()
+	broadcaster.lastID++
+	event.id = broadcaster.lastID
+	event.message = formatSSEMessage(event.id, data)
+
+	if len(broadcaster.replay) == broadcaster.config.ReplayBufferSize {
+		broadcaster.replay = broadcaster.replay[1:]
+	}
+	broadcaster.replay = append(broadcaster.replay, event)
+
+	sent := 0
+	for client := range broadcaster.clients {
+		if !client.matches(event) {
+			continue
+		}
+		select {
+		case client.send <- event.message:
+			sent++
+		default:
+			broadcaster.lc.Warn("SSE client too slow, dropping event", "remote address", client.address)
+		}
+	}
+	broadcaster.mutex.Unlock()
+
+	edgexcontext.LoggingClient.Debug(fmt.Sprintf("Broadcast data to %d SSE clients", sent))
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "SSE", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
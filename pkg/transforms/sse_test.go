@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectSSE connects a client to the server, waiting for the broadcaster to add it
+func connectSSE(t *testing.T, server *httptest.Server, broadcaster *SSEBroadcaster, query string, header http.Header) (*http.Response, *bufio.Reader) {
+	broadcaster.mutex.RLock()
+	connected := len(broadcaster.clients)
+	broadcaster.mutex.RUnlock()
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+query, nil)
+	require.NoError(t, err)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	require.Eventually(t, func() bool {
+		broadcaster.mutex.RLock()
+		defer broadcaster.mutex.RUnlock()
+		return len(broadcaster.clients) > connected
+	}, 5*time.Second, 10*time.Millisecond)
+	return response, bufio.NewReader(response.Body)
+}
+
+// readSSE reads the next event, returning its lines
+func readSSE(t *testing.T, reader *bufio.Reader) []string {
+	lines := make(chan []string, 1)
+	go func() {
+		var event []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\n" {
+				lines <- event
+				return
+			}
+			event = append(event, strings.TrimSuffix(line, "\n"))
+		}
+	}()
+
+	select {
+	case event := <-lines:
+		return event
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out reading the event")
+		return nil
+	}
+}
+
+func broadcastSSE(t *testing.T, broadcaster *SSEBroadcaster, data interface{}) {
+	continuePipeline, result := broadcaster.Broadcast(context, data)
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+}
+
+func TestSSEBroadcast(t *testing.T) {
+	broadcaster := NewSSEBroadcaster(SSEConfig{}, context.LoggingClient)
+	server := httptest.NewServer(broadcaster)
+	defer server.Close()
+
+	all, allReader := connectSSE(t, server, broadcaster, "", nil)
+	defer all.Body.Close()
+	devices, devicesReader := connectSSE(t, server, broadcaster, "?device=thermostat2,thermostat3", nil)
+	defer devices.Body.Close()
+	readings, readingsReader := connectSSE(t, server, broadcaster, "?reading=humidity", nil)
+	defer readings.Body.Close()
+
+	broadcastSSE(t, broadcaster, models.Event{Device: "thermostat1", Readings: []models.Reading{{Name: "humidity"}}})
+	broadcastSSE(t, broadcaster, models.Event{Device: "thermostat2", Readings: []models.Reading{{Name: "temperature"}}})
+	broadcastSSE(t, broadcaster, "line1\nline2")
+
+	event := readSSE(t, allReader)
+	require.Len(t, event, 2)
+	assert.Equal(t, "id: 1", event[0])
+	assert.Contains(t, event[1], "thermostat1")
+	event = readSSE(t, allReader)
+	assert.Equal(t, "id: 2", event[0])
+	event = readSSE(t, allReader)
+	assert.Equal(t, []string{"id: 3", "data: line1", "data: line2"}, event, "Each line should be a data field")
+
+	event = readSSE(t, devicesReader)
+	assert.Equal(t, "id: 2", event[0], "The client should only receive its devices' data")
+
+	event = readSSE(t, readingsReader)
+	assert.Equal(t, "id: 1", event[0], "The client should only receive the data of its readings")
+}
+
+func TestSSEBroadcastNoData(t *testing.T) {
+	broadcaster := NewSSEBroadcaster(SSEConfig{}, context.LoggingClient)
+
+	continuePipeline, result := broadcaster.Broadcast(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestSSEReplay(t *testing.T) {
+	broadcaster := NewSSEBroadcaster(SSEConfig{ReplayBufferSize: 3}, context.LoggingClient)
+	server := httptest.NewServer(broadcaster)
+	defer server.Close()
+
+	for _, device := range []string{"thermostat1", "thermostat2", "thermostat1", "thermostat2"} {
+		broadcastSSE(t, broadcaster, models.Event{Device: device})
+	}
+
+	// Event 1 is no longer in the replay buffer, and event 2 is filtered out
+	header := http.Header{}
+	header.Set(SSELastEventIDHeader, "1")
+	response, reader := connectSSE(t, server, broadcaster, "?device=thermostat1", header)
+	defer response.Body.Close()
+
+	broadcastSSE(t, broadcaster, models.Event{Device: "thermostat1"})
+
+	assert.Equal(t, "id: 3", readSSE(t, reader)[0])
+	assert.Equal(t, "id: 5", readSSE(t, reader)[0], "Events should follow the replayed events")
+}
+
+func TestSSEInvalidLastEventID(t *testing.T) {
+	broadcaster := NewSSEBroadcaster(SSEConfig{}, context.LoggingClient)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(SSELastEventIDHeader, "abc")
+	recorder := httptest.NewRecorder()
+	broadcaster.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Empty(t, broadcaster.clients)
+}
+
+func TestSSEOrigin(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AllowedOrigins []string
+		ExpectedHeader string
+	}{
+		{"Not Allowed", nil, ""},
+		{"Allowed Origin", []string{"http://dashboard:8080"}, "http://dashboard:8080"},
+		{"All Origins", []string{"*"}, "http://dashboard:8080"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			broadcaster := NewSSEBroadcaster(SSEConfig{AllowedOrigins: test.AllowedOrigins}, context.LoggingClient)
+			server := httptest.NewServer(broadcaster)
+			defer server.Close()
+
+			header := http.Header{}
+			header.Set("Origin", "http://dashboard:8080")
+			response, _ := connectSSE(t, server, broadcaster, "", header)
+			defer response.Body.Close()
+
+			assert.Equal(t, test.ExpectedHeader, response.Header.Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestFormatSSEMessage(t *testing.T) {
+	assert.Equal(t, "id: 1\ndata: a\ndata: b\n\n", string(formatSSEMessage(1, []byte("a\r\nb"))))
+	assert.Equal(t, "id: 2\nevent: base64\ndata: //4=\n\n", string(formatSSEMessage(2, []byte{0xff, 0xfe})))
+}
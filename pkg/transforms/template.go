@@ -0,0 +1,219 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+// Template houses the parsed Go text/template used to reshape the pipeline data
+type Template struct {
+	template *template.Template
+}
+
+// NewTemplate creates, initializes and returns a new instance of Template from the template text.
+func NewTemplate(text string) (*Template, error) {
+	parsed, err := template.New("payload").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %s", err.Error())
+	}
+	return &Template{template: parsed}, nil
+}
+
+// NewTemplateFromFile creates, initializes and returns a new instance of Template from the template in the file.
+func NewTemplateFromFile(path string) (*Template, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read template file: %s", err.Error())
+	}
+	return NewTemplate(string(text))
+}
+
+// TransformWithTemplate renders the template against the data received from the previous function and returns the
+// result as a []byte. An EdgeX Event is passed to the template as is, so its fields and readings can be accessed
+// directly, i.e. {{.Device}} or {{range .Readings}}. A []byte or string holding JSON is decoded before being passed
+// to the template, anything else is passed as a string.
+// This function will return an error and stop the pipeline if no data is received or the template fails to render.
+func (t *Template) TransformWithTemplate(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		return false, errors.New("No Data Received")
+	}
+
+	edgexcontext.LoggingClient.Debug("Transforming with template")
+
+	var data interface{}
+	switch input := params[0].(type) {
+	case []byte:
+		data = decodeTemplateData(input)
+	case string:
+		data = decodeTemplateData([]byte(input))
+	default:
+		data = input
+	}
+
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, data); err != nil {
+		return false, fmt.Errorf("unable to render template: %s", err.Error())
+	}
+
+	return true, buf.Bytes()
+}
+
+// decodeTemplateData decodes JSON numbers as json.Number so large integers, such as an Event's origin, are rendered
+// exactly rather than as a float64 in exponent form
+func decodeTemplateData(input []byte) interface{} {
+	if !json.Valid(input) {
+		return string(input)
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(input)
+	}
+	return decoded
+}
+
+// templateFuncs are the helper functions available to all templates
+var templateFuncs = template.FuncMap{
+	// formatTime formats a timestamp in nanoseconds since the epoch, as EdgeX uses for origin, using the Go time layout
+	// or the name of one of the time package layouts, i.e. RFC3339.
+	"formatTime": formatTemplateTime,
+	// now returns the current time in UTC
+	"now": func() time.Time { return time.Now().UTC() },
+	"parseFloat": func(value interface{}) (float64, error) {
+		if number, ok := value.(json.Number); ok {
+			return number.Float64()
+		}
+		return strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(value)), 64)
+	},
+	"parseInt": func(value interface{}) (int64, error) {
+		if number, ok := value.(json.Number); ok {
+			return number.Int64()
+		}
+		return strconv.ParseInt(strings.TrimSpace(fmt.Sprint(value)), 10, 64)
+	},
+	"parseBool": func(value interface{}) (bool, error) {
+		return strconv.ParseBool(strings.TrimSpace(fmt.Sprint(value)))
+	},
+	// json encodes the value as JSON, quoting and escaping strings
+	"json": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+	// jsonEscape escapes the string for use inside a quoted JSON string
+	"jsonEscape": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(fmt.Sprint(value))
+		if err != nil {
+			return "", err
+		}
+		return string(encoded[1 : len(encoded)-1]), nil
+	},
+	// reading returns the first reading of the Event, which may also be decoded JSON, with the specified name
+	"reading": templateReading,
+	// isLast reports whether index is the last index of the list, useful for separators when ranging
+	"isLast": func(index int, list interface{}) bool {
+		value := reflect.ValueOf(list)
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array, reflect.String, reflect.Map:
+			return index == value.Len()-1
+		}
+		return false
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+func templateReading(data interface{}, name string) (models.Reading, error) {
+	var event models.Event
+	switch typed := data.(type) {
+	case models.Event:
+		event = typed
+	case *models.Event:
+		event = *typed
+	default:
+		// An Event received as JSON is decoded into a map before being passed to the template
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return models.Reading{}, fmt.Errorf("unable to get reading '%s', expecting an Event: %s", name, err.Error())
+		}
+		if err := json.Unmarshal(encoded, &event); err != nil {
+			return models.Reading{}, fmt.Errorf("unable to get reading '%s', expecting an Event: %s", name, err.Error())
+		}
+	}
+
+	for _, reading := range event.Readings {
+		if reading.Name == name {
+			return reading, nil
+		}
+	}
+	return models.Reading{}, fmt.Errorf("reading '%s' not found", name)
+}
+
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+}
+
+func formatTemplateTime(timestamp interface{}, layout string) (string, error) {
+	if named, ok := timeLayouts[layout]; ok {
+		layout = named
+	}
+
+	switch value := timestamp.(type) {
+	case time.Time:
+		return value.Format(layout), nil
+	case int64:
+		return time.Unix(0, value).UTC().Format(layout), nil
+	case int:
+		return time.Unix(0, int64(value)).UTC().Format(layout), nil
+	case float64:
+		return time.Unix(0, int64(value)).UTC().Format(layout), nil
+	case json.Number:
+		// JSON numbers are decoded as json.Number, falling back to float64 for those written with a fraction or exponent
+		if nanos, err := value.Int64(); err == nil {
+			return time.Unix(0, nanos).UTC().Format(layout), nil
+		}
+		if nanos, err := value.Float64(); err == nil {
+			return time.Unix(0, int64(nanos)).UTC().Format(layout), nil
+		}
+	}
+
+	nanos, err := strconv.ParseInt(strings.TrimSpace(fmt.Sprint(timestamp)), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("unable to format '%v' as a time", timestamp)
+	}
+	return time.Unix(0, nanos).UTC().Format(layout), nil
+}
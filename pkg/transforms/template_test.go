@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTemplateInvalid(t *testing.T) {
+	_, err := NewTemplate("{{.Device")
+	assert.Error(t, err)
+
+	_, err = NewTemplateFromFile("/does/not/exist.tmpl")
+	assert.Error(t, err)
+}
+
+func TestTransformWithTemplateEvent(t *testing.T) {
+	text := `{"site":"plant1","device":{{json .Device}},"time":"{{formatTime .Origin "RFC3339"}}","metrics":{ ` +
+		`{{range $i, $r := .Readings}}"{{jsonEscape $r.Name}}":{{parseFloat $r.Value}}{{if not (isLast $i $.Readings)}},{{end}}{{end}} }` +
+		`,"temp":{{(reading . "Temperature").Value}}}`
+	tmpl, err := NewTemplate(text)
+	require.NoError(t, err)
+
+	event := models.Event{
+		Device: "Thermostat \"A\"",
+		Origin: 1577836800000000000,
+		Readings: []models.Reading{
+			{Name: "Temperature", Value: "21.5"},
+			{Name: "Humidity", Value: "40"},
+		},
+	}
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, event)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	expected := `{"site":"plant1","device":"Thermostat \"A\"","time":"2020-01-01T00:00:00Z",` +
+		`"metrics":{"Temperature":21.5,"Humidity":40},"temp":21.5}`
+	assert.JSONEq(t, expected, string(result.([]byte)))
+}
+
+func TestTransformWithTemplateJSONBytes(t *testing.T) {
+	tmpl, err := NewTemplate(`{{.vendor.id}}:{{upper .status}}:{{formatTime .ts "2006-01-02"}}`)
+	require.NoError(t, err)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, []byte(`{"vendor":{"id":"v1"},"status":"ok","ts":1577836800000000000}`))
+	require.True(t, continuePipeline)
+	assert.Equal(t, "v1:OK:2020-01-01", string(result.([]byte)))
+}
+
+func TestTransformWithTemplateJSONEvent(t *testing.T) {
+	tmpl, err := NewTemplate(`{{.device}}:{{(reading . "Temperature").Value}}`)
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Readings: []models.Reading{{Name: "Temperature", Value: "21.5"}}}
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, data)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	assert.Equal(t, devID1+":21.5", string(result.([]byte)))
+
+	continuePipeline, result = tmpl.TransformWithTemplate(context, "not an event")
+	assert.False(t, continuePipeline, "Pipeline should stop when the data isn't an Event")
+	assert.Error(t, result.(error))
+}
+
+func TestTransformWithTemplateJSONLargeNumbers(t *testing.T) {
+	tmpl, err := NewTemplate(`{{.origin}}:{{formatTime .origin "RFC3339Nano"}}:{{parseInt .origin}}:{{parseFloat .value}}`)
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1, Origin: 1602999999123456789}
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	data = append(data[:len(data)-1], []byte(`,"value":21.5}`)...)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, data)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	assert.Equal(t, "1602999999123456789:2020-10-18T05:46:39.123456789Z:1602999999123456789:21.5", string(result.([]byte)))
+}
+
+func TestTransformWithTemplatePlainString(t *testing.T) {
+	tmpl, err := NewTemplate(`payload={{.}}`)
+	require.NoError(t, err)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, "not json")
+	require.True(t, continuePipeline)
+	assert.Equal(t, "payload=not json", string(result.([]byte)))
+}
+
+func TestTransformWithTemplateFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "payload.tmpl")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{{.Device}}`), 0644))
+
+	tmpl, err := NewTemplateFromFile(path)
+	require.NoError(t, err)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context, models.Event{Device: devID1})
+	require.True(t, continuePipeline)
+	assert.Equal(t, devID1, string(result.([]byte)))
+}
+
+func TestTransformWithTemplateErrors(t *testing.T) {
+	tmpl, err := NewTemplate(`{{(reading . "Missing").Value}}`)
+	require.NoError(t, err)
+
+	continuePipeline, result := tmpl.TransformWithTemplate(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+
+	continuePipeline, result = tmpl.TransformWithTemplate(context, models.Event{Device: devID1})
+	assert.False(t, continuePipeline, "Pipeline should stop when the template fails to render")
+	assert.Error(t, result.(error))
+}
@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+const (
+	// TopicDevice is replaced by the name of the device that sent the Event
+	TopicDevice = "device"
+	// TopicReading is replaced by the name of the first reading in the Event
+	TopicReading = "reading"
+	// TopicEventID is replaced by the ID of the Event that triggered the pipeline
+	TopicEventID = "eventid"
+	// TopicCorrelationID is replaced by the correlation ID of the Event that triggered the pipeline
+	TopicCorrelationID = "correlationid"
+)
+
+// topicPlaceholder matches the {name} placeholders in a dynamic topic or key
+var topicPlaceholder = regexp.MustCompile(`{([^{}]*)}`)
+
+// resolveTopic replaces the placeholders in the MQTT topic with values from the context and the data being exported.
+// The values can't contain the topic level separator or wildcards.
+func resolveTopic(topic string, edgexcontext *appcontext.Context, data interface{}) (string, error) {
+	return resolveDestination(topic, edgexcontext, data, "/+#")
+}
+
+// resolveDestination resolves the placeholders in the topic, key or subject the data is exported to and keeps the
+// result in the context's ExportTopics. It is stored along with the data for later retry, so a retry, whose context
+// no longer holds the Values and whose data may no longer be the Event, exports to the same destination.
+func resolveDestination(text string, edgexcontext *appcontext.Context, data interface{}, invalidChars string) (string, error) {
+	if !strings.Contains(text, "{") {
+		return text, nil
+	}
+	if resolved, ok := edgexcontext.ExportTopics[text]; ok {
+		return resolved, nil
+	}
+
+	resolved, err := resolvePlaceholders(text, edgexcontext, data, invalidChars)
+	if err != nil {
+		return "", err
+	}
+
+	if edgexcontext.ExportTopics == nil {
+		edgexcontext.ExportTopics = make(map[string]string)
+	}
+	edgexcontext.ExportTopics[text] = resolved
+	return resolved, nil
+}
+
+// resolvePlaceholders replaces the placeholders in the text with values from the context and the data being exported.
+// The context's Values are used first, so a previous function in the pipeline can set or override any placeholder,
+// i.e. {profile}, before the device, reading, event ID and correlation ID are looked up. An error is returned if a
+// value is empty or contains any of the invalid characters.
+func resolvePlaceholders(text string, edgexcontext *appcontext.Context, data interface{}, invalidChars string) (string, error) {
+	if !strings.Contains(text, "{") {
+		return text, nil
+	}
+
+	var event *models.Event
+	var err error
+	resolved := topicPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if err != nil {
+			return placeholder
+		}
+
+		name := strings.TrimSpace(placeholder[1 : len(placeholder)-1])
+		value, ok := edgexcontext.Values[name]
+		if !ok {
+			switch name {
+			case TopicEventID:
+				value = edgexcontext.EventID
+			case TopicCorrelationID:
+				value = edgexcontext.CorrelationID
+			case TopicDevice, TopicReading:
+				if event == nil {
+					event = topicEvent(data)
+				}
+				value = topicEventValue(name, event)
+			default:
+				err = fmt.Errorf("unknown placeholder '%s' in '%s'", name, text)
+				return placeholder
+			}
+		}
+
+		if value == "" {
+			err = fmt.Errorf("placeholder '%s' in '%s' has no value", name, text)
+		} else if strings.ContainsAny(value, invalidChars) {
+			err = fmt.Errorf("value '%s' for placeholder '%s' in '%s' can't contain any of '%s'", value, name, text, invalidChars)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resolved, nil
+}
+
+// topicEvent returns the Event held by the data, which is either an Event, a Reading or JSON holding one of them.
+// An empty Event is returned if the data holds neither.
+func topicEvent(data interface{}) *models.Event {
+	switch value := data.(type) {
+	case models.Event:
+		return &value
+	case *models.Event:
+		if value != nil {
+			return value
+		}
+	case models.Reading:
+		return &models.Event{Device: value.Device, Readings: []models.Reading{value}}
+	case string:
+		return topicEvent([]byte(value))
+	case []byte:
+		// The Event and Reading are validated when unmarshaled, the fields are set even when validation fails
+		var event models.Event
+		_ = json.Unmarshal(value, &event)
+		if len(event.Readings) == 0 {
+			var reading models.Reading
+			if _ = json.Unmarshal(value, &reading); reading.Name != "" {
+				return topicEvent(reading)
+			}
+		}
+		return &event
+	}
+	return &models.Event{}
+}
+
+func topicEventValue(name string, event *models.Event) string {
+	if name == TopicDevice {
+		if event.Device != "" || len(event.Readings) == 0 {
+			return event.Device
+		}
+		return event.Readings[0].Device
+	}
+
+	if len(event.Readings) == 0 {
+		return ""
+	}
+	return event.Readings[0].Name
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+func TestResolveTopic(t *testing.T) {
+	event := models.Event{
+		Device:   "thermostat",
+		Readings: []models.Reading{{Device: "thermostat", Name: "temperature", Value: "21.5"}},
+	}
+	eventJSON, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name        string
+		Topic       string
+		Data        interface{}
+		Expected    string
+		ExpectError bool
+	}{
+		{"Static", "site1/export", event, "site1/export", false},
+		{"Event", "site1/{device}/{reading}", event, "site1/thermostat/temperature", false},
+		{"Event Pointer", "site1/{device}", &event, "site1/thermostat", false},
+		{"Reading", "{device}/{reading}", event.Readings[0], "thermostat/temperature", false},
+		{"Event JSON", "{device}/{reading}", eventJSON, "thermostat/temperature", false},
+		{"Reading JSON", "{device}/{reading}", `{"device":"thermostat","name":"humidity"}`, "thermostat/humidity", false},
+		{"Context", "{profile}/{eventid}/{correlationid}", clearString, "Thermostat-Profile/event1/correlation1", false},
+		{"Spaces", "{ device }", event, "thermostat", false},
+		{"No Device", "{device}", clearString, "", true},
+		{"No Reading", "{reading}", models.Event{Device: "thermostat"}, "", true},
+		{"Unknown", "{unknown}", event, "", true},
+		{"Wildcard Value", "{site}", event, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx := &appcontext.Context{
+				EventID:       "event1",
+				CorrelationID: "correlation1",
+				Values:        map[string]string{"profile": "Thermostat-Profile", "site": "a/b"},
+			}
+			topic, err := resolveTopic(test.Topic, ctx, test.Data)
+			if test.ExpectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, topic)
+		})
+	}
+}
+
+func TestResolveTopicStoredForRetry(t *testing.T) {
+	ctx := &appcontext.Context{Values: map[string]string{"profile": "Thermostat-Profile"}}
+	topic, err := resolveTopic("{profile}/{device}", ctx, models.Event{Device: "thermostat"})
+	require.NoError(t, err)
+	assert.Equal(t, "Thermostat-Profile/thermostat", topic)
+	assert.Equal(t, map[string]string{"{profile}/{device}": topic}, ctx.ExportTopics)
+
+	// A retry has neither the Values nor the Event, only the topic stored along with the data
+	retryCtx := &appcontext.Context{ExportTopics: ctx.ExportTopics}
+	topic, err = resolveTopic("{profile}/{device}", retryCtx, []byte("compressed"))
+	require.NoError(t, err)
+	assert.Equal(t, "Thermostat-Profile/thermostat", topic)
+}
+
+func TestResolveTopicValuesOverride(t *testing.T) {
+	ctx := &appcontext.Context{Values: map[string]string{TopicDevice: "gateway"}}
+	topic, err := resolveTopic("{device}", ctx, models.Event{Device: "thermostat"})
+	require.NoError(t, err)
+	assert.Equal(t, "gateway", topic)
+}
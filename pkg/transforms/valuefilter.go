@@ -0,0 +1,239 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+)
+
+// Operators supported by ValueFilter
+const (
+	OperatorGreaterThan      = ">"
+	OperatorGreaterThanEqual = ">="
+	OperatorLessThan         = "<"
+	OperatorLessThanEqual    = "<="
+	OperatorEqual            = "=="
+	OperatorNotEqual         = "!="
+	OperatorBetween          = "between"
+	OperatorOutside          = "outside"
+	OperatorDeadband         = "deadband"
+)
+
+// ValueFilter houses the parameters for filtering readings by their value
+type ValueFilter struct {
+	// ReadingNames limits the readings the condition is applied to. Readings with other names are passed thru.
+	// If empty the condition is applied to all readings.
+	ReadingNames []string
+	// Operator is one of the Operator constants
+	Operator string
+	// Values holds the operand(s) for the Operator. The between and outside operators require a lower and upper bound,
+	// all others require a single value. For deadband the value is the minimum change from the last emitted value.
+	Values []string
+
+	lastValues map[string]float64
+	mutex      sync.Mutex
+}
+
+// NewValueFilter creates, initializes and returns a new instance of ValueFilter
+func NewValueFilter(readingNames []string, operator string, values []string) (*ValueFilter, error) {
+	filter := &ValueFilter{
+		ReadingNames: readingNames,
+		Operator:     strings.ToLower(strings.TrimSpace(operator)),
+		Values:       values,
+		lastValues:   make(map[string]float64),
+	}
+
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// FilterByReadingValue filters the readings of an Event by their value. Readings that do not meet the configured condition
+// are removed, leaving just the readings that match. If no readings remain the pipeline execution for that pass is terminated.
+// For the deadband operator a reading is only kept when its value has moved by more than the configured amount from the
+// last value kept for the same device and reading name. The first value seen is always kept.
+// This function will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+func (f *ValueFilter) FilterByReadingValue(edgexcontext *appcontext.Context, params ...interface{}) (continuePipeline bool, result interface{}) {
+
+	edgexcontext.LoggingClient.Debug("Filtering by Reading Value")
+
+	if len(params) < 1 {
+		return false, errors.New("no Event Received")
+	}
+
+	existingEvent, ok := params[0].(models.Event)
+	if !ok {
+		return false, errors.New("type received is not an Event")
+	}
+
+	auxEvent := models.Event{
+		Pushed:   existingEvent.Pushed,
+		Device:   existingEvent.Device,
+		Created:  existingEvent.Created,
+		Modified: existingEvent.Modified,
+		Origin:   existingEvent.Origin,
+		Readings: []models.Reading{},
+	}
+
+	for _, reading := range existingEvent.Readings {
+		if !f.appliesTo(reading.Name) || f.matches(existingEvent.Device, reading) {
+			auxEvent.Readings = append(auxEvent.Readings, reading)
+		}
+	}
+
+	thereExistReadings := len(auxEvent.Readings) > 0
+	var returnResult models.Event
+	if thereExistReadings {
+		returnResult = auxEvent
+	}
+	return thereExistReadings, returnResult
+}
+
+func (f *ValueFilter) validate() error {
+	switch f.Operator {
+	case OperatorBetween, OperatorOutside:
+		if len(f.Values) != 2 {
+			return fmt.Errorf("operator '%s' requires a lower and upper value", f.Operator)
+		}
+		for _, value := range f.Values {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return fmt.Errorf("operator '%s' requires numeric values, got '%s'", f.Operator, value)
+			}
+		}
+	case OperatorGreaterThan, OperatorGreaterThanEqual, OperatorLessThan, OperatorLessThanEqual, OperatorDeadband:
+		if len(f.Values) != 1 {
+			return fmt.Errorf("operator '%s' requires a single value", f.Operator)
+		}
+		if _, err := strconv.ParseFloat(f.Values[0], 64); err != nil {
+			return fmt.Errorf("operator '%s' requires a numeric value, got '%s'", f.Operator, f.Values[0])
+		}
+	case OperatorEqual, OperatorNotEqual:
+		if len(f.Values) != 1 {
+			return fmt.Errorf("operator '%s' requires a single value", f.Operator)
+		}
+	default:
+		return fmt.Errorf("unsupported operator '%s'", f.Operator)
+	}
+
+	return nil
+}
+
+func (f *ValueFilter) appliesTo(readingName string) bool {
+	if len(f.ReadingNames) == 0 {
+		return true
+	}
+
+	for _, name := range f.ReadingNames {
+		if name == readingName {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *ValueFilter) matches(device string, reading models.Reading) bool {
+	switch f.Operator {
+	case OperatorEqual:
+		return valuesEqual(reading.Value, f.Values[0])
+	case OperatorNotEqual:
+		return !valuesEqual(reading.Value, f.Values[0])
+	case OperatorDeadband:
+		return f.outsideDeadband(device, reading)
+	}
+
+	value, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil {
+		// Non-numeric readings can never satisfy a numeric comparison
+		return false
+	}
+
+	// Operands have already been validated as numeric
+	operand, _ := strconv.ParseFloat(f.Values[0], 64)
+
+	switch f.Operator {
+	case OperatorGreaterThan:
+		return value > operand
+	case OperatorGreaterThanEqual:
+		return value >= operand
+	case OperatorLessThan:
+		return value < operand
+	case OperatorLessThanEqual:
+		return value <= operand
+	}
+
+	upper, _ := strconv.ParseFloat(f.Values[1], 64)
+	lower := math.Min(operand, upper)
+	upper = math.Max(operand, upper)
+	inRange := value >= lower && value <= upper
+	if f.Operator == OperatorBetween {
+		return inRange
+	}
+	return !inRange
+}
+
+func (f *ValueFilter) outsideDeadband(device string, reading models.Reading) bool {
+	value, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil {
+		return false
+	}
+	deadband, _ := strconv.ParseFloat(f.Values[0], 64)
+
+	key := device + "/" + reading.Name
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.lastValues == nil {
+		f.lastValues = make(map[string]float64)
+	}
+
+	last, seen := f.lastValues[key]
+	if seen && math.Abs(value-last) <= deadband {
+		return false
+	}
+
+	f.lastValues[key] = value
+	return true
+}
+
+// valuesEqual compares values numerically if both are numbers, as booleans if both are booleans
+// and falls back to a string comparison otherwise.
+func valuesEqual(value string, expected string) bool {
+	if a, err := strconv.ParseFloat(value, 64); err == nil {
+		if b, err := strconv.ParseFloat(expected, 64); err == nil {
+			return a == b
+		}
+	}
+
+	if a, err := strconv.ParseBool(value); err == nil {
+		if b, err := strconv.ParseBool(expected); err == nil {
+			return a == b
+		}
+	}
+
+	return value == expected
+}
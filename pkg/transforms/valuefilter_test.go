@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newValueFilterEvent(device string, values ...string) models.Event {
+	event := models.Event{Device: device}
+	for _, value := range values {
+		event.Readings = append(event.Readings, models.Reading{Name: descriptor1, Value: value})
+	}
+	return event
+}
+
+func TestNewValueFilterValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		operator  string
+		values    []string
+		expectErr bool
+	}{
+		{"Greater Than", ">", []string{"10"}, false},
+		{"Between", "between", []string{"1", "10"}, false},
+		{"Outside Upper Case", "OUTSIDE", []string{"1", "10"}, false},
+		{"Equal String", "==", []string{"on"}, false},
+		{"Deadband", "deadband", []string{"0.5"}, false},
+		{"Unknown Operator", "~", []string{"10"}, true},
+		{"Between Missing Upper", "between", []string{"1"}, true},
+		{"Greater Than Non Numeric", ">", []string{"abc"}, true},
+		{"Equal Missing Value", "==", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewValueFilter(nil, tt.operator, tt.values)
+			if tt.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, filter)
+		})
+	}
+}
+
+func TestFilterByReadingValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		values   []string
+		readings []string
+		expected []string
+	}{
+		{"Greater Than", ">", []string{"10"}, []string{"5", "10", "15"}, []string{"15"}},
+		{"Greater Than Equal", ">=", []string{"10"}, []string{"5", "10", "15"}, []string{"10", "15"}},
+		{"Less Than", "<", []string{"10"}, []string{"5", "10", "15"}, []string{"5"}},
+		{"Less Than Equal", "<=", []string{"10"}, []string{"5", "10", "15"}, []string{"5", "10"}},
+		{"Between", "between", []string{"5", "10"}, []string{"1", "5", "7", "10", "11"}, []string{"5", "7", "10"}},
+		{"Outside", "outside", []string{"10", "5"}, []string{"1", "5", "7", "10", "11"}, []string{"1", "11"}},
+		{"Numeric Equal", "==", []string{"1.0"}, []string{"1", "2"}, []string{"1"}},
+		{"Boolean Equal", "==", []string{"true"}, []string{"TRUE", "false"}, []string{"TRUE"}},
+		{"String Equal", "==", []string{"open"}, []string{"open", "closed"}, []string{"open"}},
+		{"String Not Equal", "!=", []string{"open"}, []string{"open", "closed"}, []string{"closed"}},
+		{"Non Numeric Dropped", ">", []string{"10"}, []string{"abc", "20"}, []string{"20"}},
+		{"Nothing Matches", ">", []string{"100"}, []string{"5", "10"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewValueFilter(nil, tt.operator, tt.values)
+			require.NoError(t, err)
+
+			continuePipeline, result := filter.FilterByReadingValue(context, newValueFilterEvent(devID1, tt.readings...))
+			if len(tt.expected) == 0 {
+				assert.False(t, continuePipeline, "Pipeline should stop when no readings match")
+				return
+			}
+
+			require.True(t, continuePipeline, "Pipeline should continue")
+			event, ok := result.(models.Event)
+			require.True(t, ok, "Expected result to be an Event")
+			require.Len(t, event.Readings, len(tt.expected))
+			for i, reading := range event.Readings {
+				assert.Equal(t, tt.expected[i], reading.Value)
+			}
+		})
+	}
+}
+
+func TestFilterByReadingValueReadingNames(t *testing.T) {
+	filter, err := NewValueFilter([]string{descriptor1}, ">", []string{"10"})
+	require.NoError(t, err)
+
+	event := models.Event{Device: devID1}
+	event.Readings = append(event.Readings, models.Reading{Name: descriptor1, Value: "5"})
+	event.Readings = append(event.Readings, models.Reading{Name: descriptor2, Value: "5"})
+
+	continuePipeline, result := filter.FilterByReadingValue(context, event)
+	require.True(t, continuePipeline, "Pipeline should continue")
+	readings := result.(models.Event).Readings
+	require.Len(t, readings, 1, "Only the reading not covered by the filter should remain")
+	assert.Equal(t, descriptor2, readings[0].Name)
+}
+
+func TestFilterByReadingValueDeadband(t *testing.T) {
+	filter, err := NewValueFilter(nil, "deadband", []string{"1"})
+	require.NoError(t, err)
+
+	expectedPass := []struct {
+		device string
+		value  string
+		pass   bool
+	}{
+		{devID1, "10", true},
+		{devID1, "10.5", false},
+		{devID1, "11", false},
+		{devID1, "11.5", true},
+		{devID2, "11.5", true},
+		{devID1, "10.5", false},
+		{devID1, "9", true},
+	}
+
+	for _, step := range expectedPass {
+		continuePipeline, _ := filter.FilterByReadingValue(context, newValueFilterEvent(step.device, step.value))
+		assert.Equal(t, step.pass, continuePipeline, "device %s value %s", step.device, step.value)
+	}
+}
+
+func TestFilterByReadingValueNoParameters(t *testing.T) {
+	filter, err := NewValueFilter(nil, ">", []string{"10"})
+	require.NoError(t, err)
+
+	continuePipeline, result := filter.FilterByReadingValue(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "no Event Received")
+
+	continuePipeline, result = filter.FilterByReadingValue(context, "not an event")
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "type received is not an Event")
+}
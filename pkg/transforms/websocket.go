@@ -0,0 +1,273 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/clients"
+	"github.com/edgexfoundry/go-mod-core-contracts/clients/logger"
+	"github.com/gorilla/websocket"
+
+	"github.com/tuanldchainos/app-functions-sdk-go/appcontext"
+	"github.com/tuanldchainos/app-functions-sdk-go/pkg/util"
+)
+
+const (
+	// WebSocketDeviceParameter is the query parameter holding the devices a client receives the data of, as a comma
+	// separated list
+	WebSocketDeviceParameter = "device"
+
+	defaultWebSocketSendBufferSize = 16
+	defaultWebSocketMaxMessageSize = 1024 * 1024
+	webSocketWriteTimeout          = 10 * time.Second
+	webSocketPingInterval          = 30 * time.Second
+	webSocketPongTimeout           = 2 * webSocketPingInterval
+)
+
+// WebSocketConfig contains the settings of the WebSocketBroadcaster
+type WebSocketConfig struct {
+	// AllowedOrigins are the origins of the pages browsers can connect from, in addition to the service's own. All
+	// origins are allowed when it holds "*".
+	AllowedOrigins []string
+	// SendBufferSize is the number of messages queued for each client, 16 when not set. Messages are dropped for the
+	// clients too slow to receive them.
+	SendBufferSize int
+	// MaxMessageSize is the largest message accepted from the clients, 1MB when not set
+	MaxMessageSize int64
+}
+
+// WebSocketMessageHandler handles a message received from a client, returning the data sent back to the client, if any
+type WebSocketMessageHandler func(data []byte) []byte
+
+type webSocketClient struct {
+	conn    *websocket.Conn
+	address string
+	// devices are the devices whose data the client receives, all devices when nil
+	devices map[string]bool
+	send    chan []byte
+}
+
+// WebSocketBroadcaster pushes data to the WebSocket clients connected to it, which can choose the devices whose data
+// they receive, and passes the messages received from the clients to its message handler
+type WebSocketBroadcaster struct {
+	config   WebSocketConfig
+	lc       logger.LoggingClient
+	upgrader websocket.Upgrader
+	mutex    sync.RWMutex
+	clients  map[*webSocketClient]bool
+	handler  WebSocketMessageHandler
+}
+
+// NewWebSocketBroadcaster creates, initializes and returns a new instance of WebSocketBroadcaster. Its ServeHTTP
+// function must be added as a streaming route of the web server for clients to connect.
+func NewWebSocketBroadcaster(config WebSocketConfig, lc logger.LoggingClient) *WebSocketBroadcaster {
+	if config.SendBufferSize <= 0 {
+		config.SendBufferSize = defaultWebSocketSendBufferSize
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultWebSocketMaxMessageSize
+	}
+
+	broadcaster := &WebSocketBroadcaster{
+		config:  config,
+		lc:      lc,
+		clients: make(map[*webSocketClient]bool),
+	}
+	broadcaster.upgrader = websocket.Upgrader{CheckOrigin: broadcaster.checkOrigin}
+	return broadcaster
+}
+
+// SetMessageHandler sets the handler of the messages received from the clients, which are ignored when it isn't set
+func (broadcaster *WebSocketBroadcaster) SetMessageHandler(handler WebSocketMessageHandler) {
+	broadcaster.mutex.Lock()
+	defer broadcaster.mutex.Unlock()
+	broadcaster.handler = handler
+}
+
+// checkOrigin accepts the connections from pages served by the service itself or from one of the AllowedOrigins.
+// Clients other than browsers don't send an origin.
+func (broadcaster *WebSocketBroadcaster) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range broadcaster.config.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	originURL, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(originURL.Host, r.Host)
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection. The device query parameter, when set, holds the devices
+// whose data the client receives, i.e. ?device=thermostat1,thermostat2.
+func (broadcaster *WebSocketBroadcaster) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	devices := queryValues(request, WebSocketDeviceParameter)
+
+	conn, err := broadcaster.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		// The upgrader has already responded with the error
+		broadcaster.lc.Debug(fmt.Sprintf("Failed to upgrade to WebSocket connection: %s", err.Error()))
+		return
+	}
+
+	client := &webSocketClient{
+		conn:    conn,
+		address: request.RemoteAddr,
+		devices: devices,
+		send:    make(chan []byte, broadcaster.config.SendBufferSize),
+	}
+	broadcaster.mutex.Lock()
+	broadcaster.clients[client] = true
+	broadcaster.mutex.Unlock()
+	broadcaster.lc.Debug("WebSocket client connected", "remote address", request.RemoteAddr)
+
+	go broadcaster.writeMessages(client)
+	broadcaster.readMessages(client)
+
+	broadcaster.mutex.Lock()
+	delete(broadcaster.clients, client)
+	close(client.send)
+	broadcaster.mutex.Unlock()
+	broadcaster.lc.Debug("WebSocket client disconnected", "remote address", request.RemoteAddr)
+}
+
+// readMessages passes the messages received from the client to the handler until the connection is closed
+func (broadcaster *WebSocketBroadcaster) readMessages(client *webSocketClient) {
+	client.conn.SetReadLimit(broadcaster.config.MaxMessageSize)
+	_ = client.conn.SetReadDeadline(time.Now().Add(webSocketPongTimeout))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(webSocketPongTimeout))
+	})
+
+	for {
+		_, data, err := client.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				broadcaster.lc.Debug(fmt.Sprintf("WebSocket connection closed: %s", err.Error()))
+			}
+			return
+		}
+
+		broadcaster.mutex.RLock()
+		handler := broadcaster.handler
+		broadcaster.mutex.RUnlock()
+		if handler == nil {
+			continue
+		}
+
+		if output := handler(data); output != nil {
+			broadcaster.mutex.RLock()
+			broadcaster.queue(client, output)
+			broadcaster.mutex.RUnlock()
+		}
+	}
+}
+
+// writeMessages is the only writer of the connection. It sends the queued messages, and pings the client to detect
+// broken connections, until the client is removed.
+func (broadcaster *WebSocketBroadcaster) writeMessages(client *webSocketClient) {
+	ping := time.NewTicker(webSocketPingInterval)
+	defer func() {
+		ping.Stop()
+		// Closing the connection ends readMessages, which removes the client
+		_ = client.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-client.send:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+			if !ok {
+				_ = client.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+
+			messageType := websocket.BinaryMessage
+			if utf8.Valid(data) {
+				messageType = websocket.TextMessage
+			}
+			if err := client.conn.WriteMessage(messageType, data); err != nil {
+				broadcaster.lc.Debug(fmt.Sprintf("Failed to write to WebSocket client: %s", err.Error()))
+				return
+			}
+
+		case <-ping.C:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// queue queues the data for the client, dropping it if the client's queue is full. It must be called with the mutex
+// held, so the client can't be removed meanwhile.
+func (broadcaster *WebSocketBroadcaster) queue(client *webSocketClient, data []byte) bool {
+	select {
+	case client.send <- data:
+		return true
+	default:
+		broadcaster.lc.Warn("WebSocket client too slow, dropping message", "remote address", client.address)
+		return false
+	}
+}
+
+// Broadcast pushes data from the previous function to the connected WebSocket clients. If no previous function
+// exists, then the event that triggered the pipeline will be used. The clients that chose the devices whose data they
+// receive only get the data of those devices, which is resolved the same way as the {device} placeholder of the
+// MQTTSender topic, while the others get all data.
+func (broadcaster *WebSocketBroadcaster) Broadcast(edgexcontext *appcontext.Context, params ...interface{}) (bool, interface{}) {
+	if len(params) < 1 {
+		// We didn't receive a result
+		return false, errors.New("No Data Received")
+	}
+
+	data, err := util.CoerceType(params[0])
+	if err != nil {
+		return false, err
+	}
+
+	// Data without a device is only sent to the clients receiving all data
+	device, _ := resolvePlaceholders("{"+TopicDevice+"}", edgexcontext, params[0], "")
+
+	broadcaster.mutex.RLock()
+	sent := 0
+	for client := range broadcaster.clients {
+		if client.devices != nil && !client.devices[device] {
+			continue
+		}
+		if broadcaster.queue(client, data) {
+			sent++
+		}
+	}
+	broadcaster.mutex.RUnlock()
+
+	edgexcontext.LoggingClient.Debug(fmt.Sprintf("Broadcast data to %d WebSocket clients", sent))
+	edgexcontext.LoggingClient.Trace("Data exported", "Transport", "WebSocket", clients.CorrelationHeader, edgexcontext.CorrelationID)
+
+	return true, nil
+}
@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/models"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectWebSocket connects a client to the server, waiting for the broadcaster to add it
+func connectWebSocket(t *testing.T, server *httptest.Server, broadcaster *WebSocketBroadcaster, query string, header http.Header) *websocket.Conn {
+	broadcaster.mutex.RLock()
+	connected := len(broadcaster.clients)
+	broadcaster.mutex.RUnlock()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+query, header)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		broadcaster.mutex.RLock()
+		defer broadcaster.mutex.RUnlock()
+		return len(broadcaster.clients) > connected
+	}, 5*time.Second, 10*time.Millisecond)
+	return conn
+}
+
+func readWebSocket(t *testing.T, conn *websocket.Conn) (int, string) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	messageType, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	return messageType, string(data)
+}
+
+func TestWebSocketBroadcast(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(WebSocketConfig{}, context.LoggingClient)
+	server := httptest.NewServer(broadcaster)
+	defer server.Close()
+
+	all := connectWebSocket(t, server, broadcaster, "", nil)
+	defer all.Close()
+	filtered := connectWebSocket(t, server, broadcaster, "?device=thermostat2,thermostat3", nil)
+	defer filtered.Close()
+
+	continuePipeline, result := broadcaster.Broadcast(context, models.Event{Device: "thermostat1"})
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	continuePipeline, result = broadcaster.Broadcast(context, models.Event{Device: "thermostat2"})
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+	continuePipeline, result = broadcaster.Broadcast(context, []byte{0xff, 0xfe})
+	require.True(t, continuePipeline, "Pipeline should continue: %v", result)
+
+	messageType, data := readWebSocket(t, all)
+	assert.Equal(t, websocket.TextMessage, messageType)
+	assert.Contains(t, data, "thermostat1")
+	_, data = readWebSocket(t, all)
+	assert.Contains(t, data, "thermostat2")
+	messageType, data = readWebSocket(t, all)
+	assert.Equal(t, websocket.BinaryMessage, messageType, "Data that isn't text should be sent as binary")
+	assert.Equal(t, string([]byte{0xff, 0xfe}), data)
+
+	_, data = readWebSocket(t, filtered)
+	assert.Contains(t, data, "thermostat2", "The filtered client should only receive its devices' data")
+}
+
+func TestWebSocketBroadcastNoData(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(WebSocketConfig{}, context.LoggingClient)
+
+	continuePipeline, result := broadcaster.Broadcast(context)
+	assert.False(t, continuePipeline)
+	assert.EqualError(t, result.(error), "No Data Received")
+}
+
+func TestWebSocketMessageHandler(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(WebSocketConfig{}, context.LoggingClient)
+	server := httptest.NewServer(broadcaster)
+	defer server.Close()
+
+	conn := connectWebSocket(t, server, broadcaster, "", nil)
+	defer conn.Close()
+
+	broadcaster.SetMessageHandler(func(data []byte) []byte {
+		if string(data) == "ignored" {
+			return nil
+		}
+		return []byte("reply to " + string(data))
+	})
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ignored")))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("request")))
+	_, data := readWebSocket(t, conn)
+	assert.Equal(t, "reply to request", data)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AllowedOrigins []string
+		Origin         string
+		ExpectAllowed  bool
+	}{
+		{"No Origin", nil, "", true},
+		{"Same Origin", nil, "same", true},
+		{"Other Origin", nil, "http://dashboard:8080", false},
+		{"Allowed Origin", []string{"http://dashboard:8080"}, "http://dashboard:8080", true},
+		{"All Origins", []string{"*"}, "http://dashboard:8080", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			broadcaster := NewWebSocketBroadcaster(WebSocketConfig{AllowedOrigins: test.AllowedOrigins}, context.LoggingClient)
+			server := httptest.NewServer(broadcaster)
+			defer server.Close()
+
+			header := http.Header{}
+			if test.Origin == "same" {
+				header.Set("Origin", server.URL)
+			} else if test.Origin != "" {
+				header.Set("Origin", test.Origin)
+			}
+
+			conn, response, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), header)
+			if !test.ExpectAllowed {
+				require.Error(t, err)
+				assert.Equal(t, http.StatusForbidden, response.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			conn.Close()
+		})
+	}
+}
+
+func TestWebSocketSlowClient(t *testing.T) {
+	broadcaster := NewWebSocketBroadcaster(WebSocketConfig{}, context.LoggingClient)
+
+	client := &webSocketClient{send: make(chan []byte, 1)}
+	assert.True(t, broadcaster.queue(client, []byte("first")))
+	assert.False(t, broadcaster.queue(client, []byte("second")), "The message should be dropped once the queue is full")
+}
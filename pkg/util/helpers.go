@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"errors"
 	"strings"
+
+	"google.golang.org/protobuf/proto"
 )
 
 //SplitComma - use custom split func instead of .Split to eliminate empty values (i.e Test,,,)
@@ -39,7 +41,8 @@ func DeleteEmptyAndTrim(s []string) []string {
 	return r
 }
 
-//CoerceType will accept a string, []byte, or json.Marshaler type and convert it to a []byte for use and consistency in the SDK
+//CoerceType will accept a string, []byte, proto.Message or json.Marshaler type and convert it to a []byte for use and consistency in the SDK.
+//A proto.Message is converted to its Protocol Buffers binary encoding.
 func CoerceType(param interface{}) ([]byte, error) {
 	var data []byte
 	var err error
@@ -52,6 +55,12 @@ func CoerceType(param interface{}) ([]byte, error) {
 	case []byte:
 		data = param.([]byte)
 
+	case proto.Message:
+		data, err = proto.Marshal(param.(proto.Message))
+		if err != nil {
+			return nil, errors.New("marshaling input data to Protobuf failed: " + err.Error())
+		}
+
 	default:
 		data, err = json.Marshal(param)
 		if err != nil {
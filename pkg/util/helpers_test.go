@@ -24,6 +24,7 @@ import (
 	"github.com/edgexfoundry/go-mod-core-contracts/models"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestSplitComma(t *testing.T) {
@@ -85,3 +86,9 @@ func TestCoerceTypeNotSupportedToByteArray(t *testing.T) {
 	assert.Error(t, err)
 	assert.IsType(t, reflect.TypeOf(expectedType), reflect.TypeOf(result))
 }
+func TestCoerceTypeProtoMessageToByteArray(t *testing.T) {
+	myData := wrapperspb.String("hi")
+	result, err := CoerceType(myData)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0x02, 'h', 'i'}, result)
+}
@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	// KafkaSASLPlain authenticates with the username and password using SASL/PLAIN
+	KafkaSASLPlain = "plain"
+	// KafkaSASLScramSHA256 authenticates with the username and password using SASL/SCRAM-SHA-256
+	KafkaSASLScramSHA256 = "scram-sha-256"
+	// KafkaSASLScramSHA512 authenticates with the username and password using SASL/SCRAM-SHA-512
+	KafkaSASLScramSHA512 = "scram-sha-512"
+)
+
+// NewKafkaSASLMechanism returns the Kafka SASL mechanism, ignoring case, using the username and password. It returns
+// nil when the mechanism isn't set.
+func NewKafkaSASLMechanism(mechanism string, username string, password string) (sasl.Mechanism, error) {
+	switch strings.ToLower(mechanism) {
+	case "":
+		return nil, nil
+	case KafkaSASLPlain:
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case KafkaSASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, username, password)
+	case KafkaSASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported Kafka SASL mechanism '%s', must be %s, %s or %s",
+			mechanism, KafkaSASLPlain, KafkaSASLScramSHA256, KafkaSASLScramSHA512)
+	}
+}
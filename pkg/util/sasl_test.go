@@ -0,0 +1,46 @@
+//
+// Copyright (c) 2020 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package util
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKafkaSASLMechanism(t *testing.T) {
+	mechanism, err := NewKafkaSASLMechanism("", "user", "password")
+	require.NoError(t, err)
+	assert.Nil(t, mechanism)
+
+	mechanism, err = NewKafkaSASLMechanism("PLAIN", "user", "password")
+	require.NoError(t, err)
+	assert.Equal(t, plain.Mechanism{Username: "user", Password: "password"}, mechanism)
+
+	mechanism, err = NewKafkaSASLMechanism(KafkaSASLScramSHA256, "user", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-256", mechanism.Name())
+
+	mechanism, err = NewKafkaSASLMechanism(KafkaSASLScramSHA512, "user", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-512", mechanism.Name())
+
+	_, err = NewKafkaSASLMechanism("gssapi", "user", "password")
+	assert.Error(t, err)
+}